@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+)
+
+// adminCommands are subcommands of admin, they need Nexus 3 and an
+// administrative account.
+var adminCommands = map[string]func(args []string){
+	"sizes": adminSizesCommand,
+}
+
+func adminCommand(args []string) {
+	if len(args) > 0 {
+		if cmd, ok := adminCommands[args[0]]; ok {
+			cmd(args[1:])
+			return
+		}
+	}
+	var names []string
+	for name := range adminCommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Fprintf(os.Stderr, "Usage: %s admin <command> -h, commands: %s\n",
+		os.Args[0], strings.Join(names, ", "))
+	exit(exitUsage)
+}
+
+// repositorySize is the utilization of a repository in a blob store.
+type repositorySize struct {
+	Name      string `json:"name"`
+	Format    string `json:"format"`
+	Type      string `json:"type"`
+	Blobstore string `json:"blobStore,omitempty"`
+	Assets    int64  `json:"assets"`
+	Size      int64  `json:"size"`
+}
+
+type sizes struct {
+	Blobstores   []nexus3Blobstore `json:"blobStores"`
+	Repositories []repositorySize  `json:"repositories,omitempty"`
+}
+
+func repositorySizes(ctx context.Context, inst NexusInstance,
+	withAssets bool) ([]repositorySize, error) {
+	var settings []struct {
+		Name    string `json:"name"`
+		Format  string `json:"format"`
+		Type    string `json:"type"`
+		Storage *struct {
+			BlobStoreName string `json:"blobStoreName"`
+		} `json:"storage"`
+	}
+	if err := nexus3(ctx, http.MethodGet, inst, "v1/repositorySettings",
+		nil, &settings); err != nil {
+		return nil, err
+	}
+	var rs []repositorySize
+	for _, s := range settings {
+		r := repositorySize{Name: s.Name, Format: s.Format, Type: s.Type}
+		if s.Storage != nil {
+			r.Blobstore = s.Storage.BlobStoreName
+		}
+		// group repositories own no assets
+		if withAssets && s.Type != "group" {
+			err := nexus3Pages(ctx, inst, "v1/assets",
+				url.Values{"repository": {s.Name}},
+				func(items json.RawMessage) error {
+					var page []struct {
+						FileSize int64 `json:"fileSize"`
+					}
+					err := json.Unmarshal(items, &page)
+					for _, a := range page {
+						r.Assets++
+						r.Size += a.FileSize
+					}
+					return err
+				})
+			if err != nil {
+				return nil, err
+			}
+		}
+		rs = append(rs, r)
+	}
+	sort.Slice(rs, func(i, j int) bool {
+		return rs[i].Size > rs[j].Size ||
+			rs[i].Size == rs[j].Size && rs[i].Name < rs[j].Name
+	})
+	return rs, nil
+}
+
+func (a sizes) write(w io.Writer) {
+	for _, b := range a.Blobstores {
+		used := "-"
+		if total := b.TotalSizeInBytes + b.AvailableSpaceInBytes; total > 0 {
+			used = fmt.Sprintf("%.0f%%",
+				100*float64(b.TotalSizeInBytes)/float64(total))
+		}
+		fmt.Fprintf(w, "blob store %-20s %10s %10s free %5s used, "+
+			"%d blobs\n", b.Name, humanSize(b.TotalSizeInBytes),
+			humanSize(b.AvailableSpaceInBytes), used, b.BlobCount)
+	}
+	for _, r := range a.Repositories {
+		fmt.Fprintf(w, "repository %-20s %10s %8d assets  %s %s in %s\n",
+			r.Name, humanSize(r.Size), r.Assets, r.Format, r.Type,
+			r.Blobstore)
+	}
+}
+
+func adminSizesCommand(args []string) {
+	fs := newCommand("admin sizes", "")
+	nf := newNexusFlags(fs)
+	var (
+		repositories = fs.Bool("repositories", false, "Also sum asset "+
+			"sizes per repository, which lists every asset")
+		asJSON = fs.Bool("json", false, "Print sizes as JSON")
+	)
+	fs.Parse(args)
+	if fs.NArg() != 0 {
+		fs.Usage()
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt,
+		syscall.SIGTERM)
+	defer stop()
+	var s sizes
+	if err := nexus3(ctx, http.MethodGet, nf.instance(), "v1/blobstores",
+		nil, &s.Blobstores); err != nil {
+		fail(err)
+	}
+	var err error
+	if s.Repositories, err = repositorySizes(ctx, nf.instance(),
+		*repositories); err != nil {
+		fail(err)
+	}
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(s); err != nil {
+			fail(err)
+		}
+		return
+	}
+	s.write(os.Stdout)
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRepositorySizes(t *testing.T) {
+	inst := fakeNexus(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/nexus/service/rest/v1/repositorySettings":
+			w.Write([]byte(`[
+  {"name": "public", "format": "maven2", "type": "group"},
+  {"name": "releases", "format": "maven2", "type": "hosted",
+   "storage": {"blobStoreName": "default"}}]`))
+		case "/nexus/service/rest/v1/assets":
+			// two pages
+			if r.URL.Query().Get("continuationToken") == "" {
+				json.NewEncoder(w).Encode(map[string]interface{}{
+					"items": []map[string]int64{{"fileSize": 10},
+						{"fileSize": 20}},
+					"continuationToken": "next"})
+				return
+			}
+			w.Write([]byte(`{"items": [{"fileSize": 30}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	rs, err := repositorySizes(context.Background(), inst, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rs) != 2 || rs[0].Name != "releases" || rs[0].Size != 60 ||
+		rs[0].Assets != 3 || rs[0].Blobstore != "default" {
+		t.Fatalf("Expected releases with 3 assets of 60 bytes but got "+
+			"%+v\n", rs)
+	}
+
+	var sb strings.Builder
+	sizes{[]nexus3Blobstore{{Name: "default", TotalSizeInBytes: 300,
+		AvailableSpaceInBytes: 700, BlobCount: 3}}, rs}.write(&sb)
+	if !strings.Contains(sb.String(), " 30% used, 3 blobs") {
+		t.Fatalf("Expected utilization in %q\n", sb.String())
+	}
+}
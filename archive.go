@@ -0,0 +1,52 @@
+package main
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// archiveExtensions lists packagings that are zip files under the hood.
+var archiveExtensions = map[string]bool{
+	".jar": true,
+	".war": true,
+	".ear": true,
+	".zip": true,
+}
+
+func isArchive(filename string) bool {
+	return archiveExtensions[strings.ToLower(filepath.Ext(filename))]
+}
+
+// validateArchive opens a zip based file and reads every entry, forcing
+// archive/zip to verify central directory and CRC-32 checksums.
+// Truncated uploads fail here even if their checksum sidecars match.
+func validateArchive(filename string) error {
+	r, err := zip.OpenReader(filename)
+	if err != nil {
+		return fmt.Errorf("%s: %v", filename, err)
+	}
+	defer r.Close()
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		if err := validateEntry(f); err != nil {
+			return fmt.Errorf("%s: entry %s: %v", filename, f.Name, err)
+		}
+	}
+	return nil
+}
+
+func validateEntry(f *zip.File) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	// zip.Reader checks CRC-32 when hitting EOF
+	_, err = io.Copy(io.Discard, rc)
+	return err
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTestJar(t *testing.T, filename string) {
+	f, err := os.Create(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := zip.NewWriter(f)
+	e, err := w.Create("META-INF/MANIFEST.MF")
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.Write([]byte("Manifest-Version: 1.0\r\n\r\n"))
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestValidateArchive(t *testing.T) {
+	f := filepath.Join(t.TempDir(), "a-v.jar")
+	writeTestJar(t, f)
+	if err := validateArchive(f); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestValidateArchiveTruncated(t *testing.T) {
+	f := filepath.Join(t.TempDir(), "a-v.jar")
+	writeTestJar(t, f)
+	fi, err := os.Stat(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Truncate(f, fi.Size()-10); err != nil {
+		t.Fatal(err)
+	}
+	if err := validateArchive(f); err == nil {
+		t.Fatalf("expected truncated archive to fail validation\n")
+	}
+}
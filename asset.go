@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"path"
+	"syscall"
+
+	"github.com/jhinrichsen/nexus-fetch/nexus"
+)
+
+// nexus3Asset is a file as returned by the Nexus 3 assets and search APIs.
+type nexus3Asset struct {
+	ID          string            `json:"id"`
+	Path        string            `json:"path"`
+	DownloadURL string            `json:"downloadUrl"`
+	Repository  string            `json:"repository"`
+	Format      string            `json:"format"`
+	Checksum    map[string]string `json:"checksum"`
+}
+
+// nexus3Component groups the assets of one version of a package.
+type nexus3Component struct {
+	ID         string        `json:"id"`
+	Repository string        `json:"repository"`
+	Format     string        `json:"format"`
+	Group      string        `json:"group"`
+	Name       string        `json:"name"`
+	Version    string        `json:"version"`
+	Assets     []nexus3Asset `json:"assets"`
+}
+
+// requireNexus3 exits if the server does not offer the Nexus 3 REST API.
+func requireNexus3(feature string) {
+	if serverType != serverNexus3 {
+		log.Printf("%s needs Nexus 3, not %s\n", feature, serverType)
+		exit(exitUsage)
+	}
+}
+
+// fetchAsset downloads an asset into dir under the last element of its
+// path and verifies the checksums Nexus reports for it.
+func fetchAsset(ctx context.Context, inst NexusInstance, a nexus3Asset,
+	dir string) (string, error) {
+	res, err := getAs(ctx, inst, a.DownloadURL)
+	if err != nil {
+		return "", err
+	}
+	f, err := persistBody(res, dir, nexus.SanitizeName(path.Base(a.Path)), 0)
+	if err != nil {
+		return "", err
+	}
+	verified := false
+	for _, c := range verifiedChecksums() {
+		want := a.Checksum[c]
+		if want == "" {
+			continue
+		}
+		if err := verifyChecksum(f, c, want); err != nil {
+			return f, err
+		}
+		verified = true
+	}
+	if !verified {
+		return f, warn("%s: none of the checksums %v reported for "+
+			"verification", a.Path, verifiedChecksums())
+	}
+	return f, nil
+}
+
+// planAsset prints what fetchAsset would do.
+func planAsset(ctx context.Context, a nexus3Asset, dir string) error {
+	return planFetch(ctx, a.DownloadURL, dir,
+		nexus.SanitizeName(path.Base(a.Path)), Gav{})
+}
+
+func assetCommand(args []string) {
+	fs := newCommand("asset", "<assetId>...")
+	nf := newNexusFlags(fs)
+	outputDir := fs.String("outputDir", ".",
+		"Directory to put fetched assets into")
+	dryRun := fs.Bool("dry-run", false,
+		"Print what would be fetched without downloading")
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		fs.Usage()
+	}
+
+	inst := nf.instance()
+	requireNexus3("fetching by asset ID")
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt,
+		syscall.SIGTERM)
+	defer stop()
+	// dry runs go on after failures
+	var failed error
+	for _, id := range fs.Args() {
+		var a nexus3Asset
+		if err := nexus3(ctx, http.MethodGet, inst,
+			"v1/assets/"+url.PathEscape(id), nil, &a); err != nil {
+			fail(err)
+		}
+		if *dryRun {
+			if err := planAsset(ctx, a, *outputDir); err != nil {
+				failed = err
+			}
+			continue
+		}
+		f, err := fetchAsset(ctx, inst, a, *outputDir)
+		if err != nil {
+			fail(err)
+		}
+		fmt.Println(f)
+	}
+	if failed != nil {
+		exit(exitCode(failed))
+	}
+}
+
+func componentCommand(args []string) {
+	fs := newCommand("component", "<componentId>...")
+	nf := newNexusFlags(fs)
+	outputDir := fs.String("outputDir", ".",
+		"Directory to put fetched assets into")
+	withChecksums := fs.Bool("with-checksums", false,
+		"Also fetch checksum and metadata assets")
+	dryRun := fs.Bool("dry-run", false,
+		"Print what would be fetched without downloading")
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		fs.Usage()
+	}
+
+	inst := nf.instance()
+	requireNexus3("fetching by component ID")
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt,
+		syscall.SIGTERM)
+	defer stop()
+	// dry runs go on after failures
+	var failed error
+	for _, id := range fs.Args() {
+		var c nexus3Component
+		if err := nexus3(ctx, http.MethodGet, inst,
+			"v1/components/"+url.PathEscape(id), nil, &c); err != nil {
+			fail(err)
+		}
+		log.Printf("%s %s/%s %s in %s has %d assets\n", c.Format, c.Group,
+			c.Name, c.Version, c.Repository, len(c.Assets))
+		for _, a := range c.Assets {
+			if !*withChecksums && nexus.Ignored(path.Base(a.Path)) {
+				continue
+			}
+			if *dryRun {
+				if err := planAsset(ctx, a, *outputDir); err != nil {
+					failed = err
+				}
+				continue
+			}
+			f, err := fetchAsset(ctx, inst, a, *outputDir)
+			if err != nil {
+				fail(err)
+			}
+			fmt.Println(f)
+		}
+	}
+	if failed != nil {
+		exit(exitCode(failed))
+	}
+}
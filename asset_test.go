@@ -0,0 +1,33 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"testing"
+)
+
+func TestFetchAsset(t *testing.T) {
+	inst := fakeNexus(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("jar"))
+	})
+	a := nexus3Asset{ID: "bWF2ZW4", Path: "com/acme/lib/1.0/lib-1.0.jar",
+		DownloadURL: "http://" + inst.Server + ":" + inst.Port +
+			"/nexus/repository/releases/com/acme/lib/1.0/lib-1.0.jar",
+		Checksum: map[string]string{
+			"sha1": "f92e777f4341930bad9b2422283c4680d00dbc06"}}
+	dir := t.TempDir()
+	f, err := fetchAsset(context.Background(), inst, a, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if buf, _ := os.ReadFile(f); string(buf) != "jar" {
+		t.Fatalf("Expected jar but got %q\n", buf)
+	}
+
+	a.Checksum["sha1"] = "0000000000000000000000000000000000000000"
+	_, err = fetchAsset(context.Background(), inst, a, dir)
+	if exitCode(err) != exitIntegrity {
+		t.Fatalf("Expected integrity error but got %v\n", err)
+	}
+}
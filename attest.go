@@ -0,0 +1,250 @@
+package main
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// in-toto and SLSA identifiers of the attestation.
+const (
+	intotoStatement   = "https://in-toto.io/Statement/v1"
+	intotoPayloadType = "application/vnd.in-toto+json"
+	slsaProvenance    = "https://slsa.dev/provenance/v1"
+	attestBuilder     = "https://github.com/jhinrichsen/nexus-fetch"
+	attestBuildType   = attestBuilder + "/fetch/v1"
+)
+
+// attestedFile is a download recorded in the attestation.
+type attestedFile struct {
+	fqa  Fqa
+	url  string
+	file string
+}
+
+// attestation writes a signed in-toto statement with SLSA provenance of
+// all fetched files, wrapped in a DSSE envelope.
+type attestation struct {
+	file    *string
+	key     *string
+	signer  crypto.Signer
+	started time.Time
+	files   []attestedFile
+}
+
+func newAttestation(fs *flag.FlagSet) *attestation {
+	return &attestation{
+		file: fs.String("attestation", "", "Write a signed in-toto "+
+			"provenance attestation of all fetched files to this file"),
+		key: fs.String("attestation-key", "", "PEM private key "+
+			"(Ed25519, ECDSA or RSA) signing the attestation"),
+		started: time.Now(),
+	}
+}
+
+// validate loads the signing key, so that a bad key fails before any
+// download.
+func (a *attestation) validate() error {
+	if *a.file == "" {
+		return nil
+	}
+	if *a.key == "" {
+		return errors.New("-attestation needs -attestation-key")
+	}
+	var err error
+	a.signer, err = loadSigningKey(*a.key)
+	return err
+}
+
+// loadSigningKey reads a PKCS #8, EC or PKCS #1 private key.
+func loadSigningKey(fn string) (crypto.Signer, error) {
+	buf, err := os.ReadFile(fn)
+	if err != nil {
+		return nil, err
+	}
+	b, _ := pem.Decode(buf)
+	if b == nil {
+		return nil, fmt.Errorf("%s: no PEM data", fn)
+	}
+	var key interface{}
+	switch b.Type {
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(b.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(b.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(b.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", fn, err)
+	}
+	s, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("%s: %T cannot sign", fn, key)
+	}
+	return s, nil
+}
+
+// add records a successful download.
+func (a *attestation) add(fqa Fqa, url, file string, err error) {
+	if *a.file == "" || err != nil || file == "" {
+		return
+	}
+	a.files = append(a.files, attestedFile{fqa, url, file})
+}
+
+type intotoSubject struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+type slsaDependency struct {
+	URI         string            `json:"uri"`
+	Name        string            `json:"name"`
+	Digest      map[string]string `json:"digest"`
+	Annotations map[string]string `json:"annotations"`
+}
+
+type intotoStatementV1 struct {
+	Type          string          `json:"_type"`
+	Subject       []intotoSubject `json:"subject"`
+	PredicateType string          `json:"predicateType"`
+	Predicate     struct {
+		BuildDefinition struct {
+			BuildType            string            `json:"buildType"`
+			ExternalParameters   map[string]string `json:"externalParameters"`
+			ResolvedDependencies []slsaDependency  `json:"resolvedDependencies"`
+		} `json:"buildDefinition"`
+		RunDetails struct {
+			Builder struct {
+				ID string `json:"id"`
+			} `json:"builder"`
+			Metadata struct {
+				StartedOn  time.Time `json:"startedOn"`
+				FinishedOn time.Time `json:"finishedOn"`
+			} `json:"metadata"`
+		} `json:"runDetails"`
+	} `json:"predicate"`
+}
+
+type dsseSignature struct {
+	KeyID string `json:"keyid"`
+	Sig   string `json:"sig"`
+}
+
+type dsseEnvelope struct {
+	PayloadType string          `json:"payloadType"`
+	Payload     string          `json:"payload"`
+	Signatures  []dsseSignature `json:"signatures"`
+}
+
+// dssePAE is the pre-authentication encoding that DSSE signs.
+func dssePAE(payloadType string, payload []byte) []byte {
+	return []byte(fmt.Sprintf("DSSEv1 %d %s %d %s", len(payloadType),
+		payloadType, len(payload), payload))
+}
+
+// statement describes the fetched files as subjects and their source as
+// resolved dependencies.
+func (a *attestation) statement(now time.Time) (intotoStatementV1, error) {
+	var st intotoStatementV1
+	st.Type = intotoStatement
+	st.PredicateType = slsaProvenance
+	bd := &st.Predicate.BuildDefinition
+	bd.BuildType = attestBuildType
+	bd.ExternalParameters = map[string]string{}
+	rd := &st.Predicate.RunDetails
+	rd.Builder.ID = attestBuilder
+	rd.Metadata.StartedOn = a.started.UTC()
+	rd.Metadata.FinishedOn = now.UTC()
+	st.Subject = []intotoSubject{}
+	bd.ResolvedDependencies = []slsaDependency{}
+	for _, f := range a.files {
+		digest := make(map[string]string)
+		for _, c := range []string{"sha256", "sha512"} {
+			sum, err := digestFile(f.file, checksumAlgorithms[c]())
+			if err != nil {
+				return st, err
+			}
+			digest[c] = sum
+		}
+		fi, err := os.Stat(f.file)
+		if err != nil {
+			return st, err
+		}
+		st.Subject = append(st.Subject, intotoSubject{
+			filepath.ToSlash(f.file), digest})
+		bd.ResolvedDependencies = append(bd.ResolvedDependencies,
+			slsaDependency{URI: f.url, Name: f.fqa.Gav.ConciseNotation(),
+				Digest: digest, Annotations: map[string]string{
+					"repository": f.fqa.RepositoryID,
+					"fetchedOn": fi.ModTime().UTC().Format(
+						time.RFC3339),
+				}})
+	}
+	return st, nil
+}
+
+// write signs the statement and writes the envelope.
+func (a *attestation) write() error {
+	if *a.file == "" {
+		return nil
+	}
+	st, err := a.statement(time.Now())
+	if err != nil {
+		return err
+	}
+	env, err := signStatement(st, a.signer)
+	if err != nil {
+		return err
+	}
+	buf, err := json.MarshalIndent(env, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(*a.file, append(buf, '\n'), 0644)
+}
+
+// signStatement wraps st in a DSSE envelope signed by s. The key ID is
+// the hex SHA-256 of the PKIX public key.
+func signStatement(st intotoStatementV1, s crypto.Signer) (dsseEnvelope,
+	error) {
+	var env dsseEnvelope
+	payload, err := json.Marshal(st)
+	if err != nil {
+		return env, err
+	}
+	pae := dssePAE(intotoPayloadType, payload)
+	var sig []byte
+	if _, ok := s.(ed25519.PrivateKey); ok {
+		sig, err = s.Sign(rand.Reader, pae, crypto.Hash(0))
+	} else {
+		h := sha256.Sum256(pae)
+		sig, err = s.Sign(rand.Reader, h[:], crypto.SHA256)
+	}
+	if err != nil {
+		return env, err
+	}
+	pub, err := x509.MarshalPKIXPublicKey(s.Public())
+	if err != nil {
+		return env, err
+	}
+	id := sha256.Sum256(pub)
+	env = dsseEnvelope{PayloadType: intotoPayloadType,
+		Payload: base64.StdEncoding.EncodeToString(payload),
+		Signatures: []dsseSignature{{hex.EncodeToString(id[:]),
+			base64.StdEncoding.EncodeToString(sig)}}}
+	return env, nil
+}
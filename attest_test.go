@@ -0,0 +1,89 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAttestation(t *testing.T) {
+	dir := t.TempDir()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(key, pem.EncodeToMemory(&pem.Block{
+		Type: "PRIVATE KEY", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	jar := filepath.Join(dir, "a-1.0.jar")
+	if err := os.WriteFile(jar, []byte("abc"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "attestation.json")
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	a := newAttestation(fs)
+	if err := fs.Parse([]string{"-attestation", out}); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.validate(); err == nil {
+		t.Fatalf("Expected an error without key\n")
+	}
+	*a.key = key
+	if err := a.validate(); err != nil {
+		t.Fatal(err)
+	}
+	fqa := Fqa{NexusRepository: NexusRepository{RepositoryID: "releases"},
+		Gav: Gav{Group: "g", Artifact: "a", Version: "1.0"}}
+	a.add(fqa, "http://nexus/a-1.0.jar", jar, nil)
+	if err := a.write(); err != nil {
+		t.Fatal(err)
+	}
+
+	buf, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var env dsseEnvelope
+	if err := json.Unmarshal(buf, &env); err != nil {
+		t.Fatal(err)
+	}
+	payload, err := base64.StdEncoding.DecodeString(env.Payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := base64.StdEncoding.DecodeString(env.Signatures[0].Sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ed25519.Verify(pub, dssePAE(env.PayloadType, payload), sig) {
+		t.Fatalf("Expected a valid signature\n")
+	}
+	var st intotoStatementV1
+	if err := json.Unmarshal(payload, &st); err != nil {
+		t.Fatal(err)
+	}
+	want := "ba7816bf8f01cfea414140de5dae2223" +
+		"b00361a396177a9cb410ff61f20015ad"
+	if got := st.Subject[0].Digest["sha256"]; want != got {
+		t.Fatalf("Expected %s but got %s\n", want, got)
+	}
+	dep := st.Predicate.BuildDefinition.ResolvedDependencies[0]
+	if dep.URI != "http://nexus/a-1.0.jar" || dep.Name != "g:a:1.0" ||
+		dep.Annotations["repository"] != "releases" {
+		t.Fatalf("Expected the source of a-1.0.jar but got %+v\n", dep)
+	}
+}
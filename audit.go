@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"os/user"
+	"time"
+
+	"github.com/jhinrichsen/nexus-fetch/nexus"
+)
+
+// AuditRecord documents a single operation that changes a repository.
+type AuditRecord struct {
+	Time time.Time `json:"time"`
+	// User is the local account, NexusUser the account used on the server
+	User       string `json:"user"`
+	NexusUser  string `json:"nexusUser,omitempty"`
+	Server     string `json:"server"`
+	Operation  string `json:"operation"`
+	Repository string `json:"repository"`
+	Gav        string `json:"gav,omitempty"`
+	Path       string `json:"path,omitempty"`
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+}
+
+func newAuditRecord(op string, repo NexusRepository, gav Gav, path string,
+	err error) AuditRecord {
+	r := AuditRecord{
+		Time:       time.Now(),
+		NexusUser:  repo.Username,
+		Server:     nexus.BaseURL(repo).String(),
+		Operation:  op,
+		Repository: repo.RepositoryID,
+		Path:       path,
+		Status:     statusOK,
+	}
+	if gav != (Gav{}) {
+		r.Gav = gav.ConciseNotation()
+	}
+	if u, err := user.Current(); err == nil {
+		r.User = u.Username
+	}
+	if err != nil {
+		r.Status = statusFailed
+		r.Error = err.Error()
+	}
+	return r
+}
+
+// auditLog appends records of mutating operations as JSON lines to a file
+// and/or POSTs them to an endpoint.
+type auditLog struct {
+	file *string
+	url  *string
+}
+
+func newAuditLog(fs *flag.FlagSet) *auditLog {
+	return &auditLog{
+		file: fs.String("audit-log", "",
+			"Append a JSON record of every change to this file"),
+		url: fs.String("audit-url", "",
+			"POST a JSON record of every change to this URL"),
+	}
+}
+
+// record returns an error if the record cannot be stored, callers must
+// not continue changing repositories without an audit trail.
+func (a *auditLog) record(r AuditRecord) error {
+	if *a.file == "" && *a.url == "" {
+		return nil
+	}
+	buf, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	if *a.file != "" {
+		f, err := os.OpenFile(*a.file,
+			os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+		if err != nil {
+			return err
+		}
+		_, err = f.Write(append(buf, '\n'))
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if *a.url != "" {
+		return post(*a.url, buf)
+	}
+	return nil
+}
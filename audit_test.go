@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAuditLog(t *testing.T) {
+	var posted AuditRecord
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter,
+		r *http.Request) {
+		buf, _ := ioutil.ReadAll(r.Body)
+		json.Unmarshal(buf, &posted)
+	}))
+	defer ts.Close()
+	file := filepath.Join(t.TempDir(), "audit.jsonl")
+	url := ts.URL
+	al := auditLog{&file, &url}
+
+	repo := NexusRepository{NexusInstance: NexusInstance{Protocol: "http",
+		Server: "nexus", Contextroot: "nexus/", Username: "admin"},
+		RepositoryID: "releases"}
+	gav := Gav{Group: "g", Artifact: "a", Version: "1.0"}
+	for _, err := range []error{nil, errors.New("forbidden")} {
+		if err := al.record(newAuditRecord("delete", repo, gav, "",
+			err)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var rs []AuditRecord
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var r AuditRecord
+		if err := json.Unmarshal(sc.Bytes(), &r); err != nil {
+			t.Fatal(err)
+		}
+		rs = append(rs, r)
+	}
+	if len(rs) != 2 || rs[0].Status != statusOK ||
+		rs[1].Error != "forbidden" || rs[0].Gav != "g:a:1.0" ||
+		rs[0].NexusUser != "admin" {
+		t.Fatalf("unexpected audit records %+v\n", rs)
+	}
+	if posted.Operation != "delete" || posted.Status != statusFailed {
+		t.Fatalf("Expected posted failed delete but got %+v\n", posted)
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"sync"
+
+	"github.com/jhinrichsen/nexus-fetch/nexus"
+)
+
+// anonymous suppresses all credentials, see -anonymous.
+var anonymous bool
+
+// credentials holds the configured user and password by host, so that
+// requests sent without them can be repeated with them.
+var credentials sync.Map
+
+type userPassword struct {
+	user, password string
+}
+
+// rememberCredentials registers the credentials of inst for its host.
+func rememberCredentials(inst NexusInstance) {
+	if inst.Username == "" {
+		return
+	}
+	host := nexus.BaseURL(NexusRepository{NexusInstance: inst}).Host
+	credentials.Store(host, userPassword{inst.Username, inst.Password})
+}
+
+// authTransport repeats requests rejected with 401: with credentials if
+// they were sent without, e.g. releases fetched anonymously from a server
+// that requires a login, and without credentials if those were rejected
+// but anonymous access is allowed.
+type authTransport struct {
+	http.RoundTripper
+}
+
+var authModes sync.Map
+
+func (a authTransport) RoundTrip(req *http.Request) (*http.Response,
+	error) {
+	res, err := a.RoundTripper.RoundTrip(req)
+	// requests with a body cannot be repeated
+	if err != nil || res.StatusCode != http.StatusUnauthorized ||
+		req.Body != nil && req.Body != http.NoBody {
+		return res, err
+	}
+	retry := req.Clone(req.Context())
+	mode := "anonymously"
+	if req.Header.Get("Authorization") != "" {
+		retry.Header.Del("Authorization")
+	} else {
+		c, ok := credentials.Load(req.URL.Host)
+		if anonymous || !ok {
+			return res, nil
+		}
+		up := c.(userPassword)
+		retry.SetBasicAuth(up.user, up.password)
+		mode = "as " + up.user
+	}
+	res.Body.Close()
+	res, err = a.RoundTripper.RoundTrip(retry)
+	if err == nil && res.StatusCode != http.StatusUnauthorized {
+		if _, loaded := authModes.LoadOrStore(req.URL.Host+" "+mode,
+			true); !loaded {
+			log.Printf("%s rejected the request, succeeded %s\n",
+				req.URL.Host, mode)
+		}
+	}
+	return res, err
+}
@@ -0,0 +1,466 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"path"
+	"sort"
+	"strings"
+	"syscall"
+
+	"github.com/jhinrichsen/nexus-fetch/nexus"
+)
+
+// browseHeight is the number of entries shown at once.
+const browseHeight = 20
+
+const browseHelp = "j/k move  l open  h back  i info  space fetch  " +
+	"d delete  q done"
+
+// browseItem is an entry of the current level: a repository at the top, a
+// directory or a file below.
+type browseItem struct {
+	name     string
+	dir      bool
+	size     int64
+	modified string
+}
+
+// browseMark is a file marked for download or an item marked for
+// deletion. Paths of directories end in a slash.
+type browseMark struct {
+	repo, path string
+}
+
+func (a browseMark) String() string {
+	return a.repo + "/" + a.path
+}
+
+// browser navigates repositories, groups, artifacts and versions.
+type browser struct {
+	inst    NexusInstance
+	repo    string // empty lists repositories
+	dir     string
+	items   []browseItem
+	cursor  int
+	fetch   map[browseMark]bool
+	remove  map[browseMark]bool
+	preview []string
+	status  string
+}
+
+func newBrowser(inst NexusInstance, repo, dir string) (*browser, error) {
+	b := &browser{inst: inst, repo: repo, dir: dir,
+		fetch: make(map[browseMark]bool), remove: make(map[browseMark]bool)}
+	return b, b.load()
+}
+
+// load lists the current level.
+func (a *browser) load() error {
+	a.items, a.cursor, a.preview = nil, 0, nil
+	if a.repo == "" {
+		rs, err := repositories(a.inst)
+		if err != nil {
+			return err
+		}
+		for _, r := range rs {
+			a.items = append(a.items, browseItem{name: r.ID, dir: true})
+		}
+		return nil
+	}
+	cs, err := listContent(NexusRepository{NexusInstance: a.inst,
+		RepositoryID: a.repo}, a.dir)
+	if err != nil {
+		return err
+	}
+	for _, c := range cs {
+		a.items = append(a.items, browseItem{c.Name, !c.Leaf, c.Size,
+			c.LastModified})
+	}
+	return nil
+}
+
+func (a *browser) mark(item browseItem) browseMark {
+	p := path.Join(a.dir, item.name)
+	if item.dir {
+		p += "/"
+	}
+	return browseMark{a.repo, p}
+}
+
+// key handles a key and reports whether browsing is done. Failing
+// requests are shown as status, not returned.
+func (a *browser) key(k string) bool {
+	a.status = ""
+	if k == "q" {
+		return true
+	}
+	if len(a.items) == 0 && k != "h" && k != "left" {
+		return false
+	}
+	var err error
+	switch k {
+	case "j", "down":
+		if a.cursor < len(a.items)-1 {
+			a.cursor++
+		}
+	case "k", "up":
+		if a.cursor > 0 {
+			a.cursor--
+		}
+	case "l", "right", "enter":
+		err = a.open()
+	case "h", "left":
+		err = a.back()
+	case "i":
+		err = a.info()
+	case " ", "m":
+		item := a.items[a.cursor]
+		if item.dir {
+			a.status = "only files can be fetched"
+			break
+		}
+		m := a.mark(item)
+		a.fetch[m] = !a.fetch[m]
+	case "d":
+		if a.repo == "" {
+			a.status = "repositories cannot be deleted"
+			break
+		}
+		m := a.mark(a.items[a.cursor])
+		a.remove[m] = !a.remove[m]
+	}
+	if err != nil {
+		a.status = err.Error()
+	}
+	return false
+}
+
+// open descends into a directory or previews a file.
+func (a *browser) open() error {
+	item := a.items[a.cursor]
+	if !item.dir {
+		return a.info()
+	}
+	if a.repo == "" {
+		a.repo = item.name
+	} else {
+		a.dir = path.Join(a.dir, item.name)
+	}
+	return a.load()
+}
+
+// back returns to the parent level, with the cursor on where we came from.
+func (a *browser) back() error {
+	from := path.Base(a.dir)
+	switch {
+	case a.dir != "":
+		if a.dir = path.Dir(a.dir); a.dir == "." {
+			a.dir = ""
+		}
+	case a.repo != "":
+		from, a.repo = a.repo, ""
+	default:
+		return nil
+	}
+	if err := a.load(); err != nil {
+		return err
+	}
+	for i, item := range a.items {
+		if item.name == from {
+			a.cursor = i
+		}
+	}
+	return nil
+}
+
+// info previews the maven-metadata.xml of a directory or the details of
+// a file.
+func (a *browser) info() error {
+	item := a.items[a.cursor]
+	if a.repo == "" {
+		return nil
+	}
+	m := a.mark(item)
+	repo := NexusRepository{NexusInstance: a.inst, RepositoryID: a.repo}
+	if !item.dir {
+		a.preview = []string{m.String(),
+			fmt.Sprintf("%s, modified %s", humanSize(item.size),
+				item.modified),
+			RepositoryFileURL(repo, m.path)}
+		return nil
+	}
+	res, err := getAs(context.Background(), a.inst,
+		RepositoryFileURL(repo, m.path+"maven-metadata.xml"))
+	if nexus.IsNotFound(err) {
+		a.preview = []string{m.String() + " has no maven-metadata.xml"}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	var md mavenMetadata
+	if err := xml.NewDecoder(res.Body).Decode(&md); err != nil {
+		return err
+	}
+	v := md.Versioning
+	if md.Version != "" {
+		// a snapshot version
+		a.preview = []string{md.Group + ":" + md.Artifact + ":" +
+			md.Version, "build " + md.build()}
+		return nil
+	}
+	a.preview = []string{md.Group + ":" + md.Artifact,
+		fmt.Sprintf("latest %s, release %s, updated %s", v.Latest,
+			v.Release, v.LastUpdated),
+		fmt.Sprintf("%d versions: %s", len(v.Versions),
+			strings.Join(v.Versions, " "))}
+	return nil
+}
+
+// render draws the current level, clearing the screen first on a
+// terminal.
+func (a *browser) render(w io.Writer, clear bool) {
+	if clear {
+		fmt.Fprint(w, "\x1b[H\x1b[2J")
+	}
+	fmt.Fprintf(w, "%s/%s\n", a.repo, a.dir)
+	from := 0
+	if a.cursor >= browseHeight {
+		from = a.cursor - browseHeight + 1
+	}
+	for i := from; i < len(a.items) && i < from+browseHeight; i++ {
+		item := a.items[i]
+		cursor, flag, size := " ", " ", "-"
+		if i == a.cursor {
+			cursor = ">"
+		}
+		m := a.mark(item)
+		if a.fetch[m] {
+			flag = "+"
+		}
+		if a.repo != "" && a.remove[m] {
+			flag = "D"
+		}
+		name := item.name
+		if item.dir {
+			name += "/"
+		} else {
+			size = humanSize(item.size)
+		}
+		fmt.Fprintf(w, "%s%s %10s  %s\n", cursor, flag, size, name)
+	}
+	for _, l := range a.preview {
+		fmt.Fprintf(w, "  %s\n", l)
+	}
+	if a.status != "" {
+		fmt.Fprintln(w, a.status)
+	}
+	fmt.Fprintf(w, "%d to fetch, %d to delete; %s\n", len(marked(a.fetch)),
+		len(marked(a.remove)), browseHelp)
+}
+
+// marked returns the marks set in m, sorted.
+func marked(m map[browseMark]bool) []browseMark {
+	var ms []browseMark
+	for k, v := range m {
+		if v {
+			ms = append(ms, k)
+		}
+	}
+	sort.Slice(ms, func(i, j int) bool {
+		return ms[i].String() < ms[j].String()
+	})
+	return ms
+}
+
+// readKey returns the next key: a character, or up, down, left, right or
+// enter. Without a terminal keys arrive as lines, an empty line is enter
+// and the end of other lines is returned as "".
+func readKey(r *bufio.Reader, raw bool, lineStart *bool) (string, error) {
+	for {
+		c, _, err := r.ReadRune()
+		if err != nil {
+			return "", err
+		}
+		if c == '\n' || c == '\r' {
+			if raw || *lineStart {
+				return "enter", nil
+			}
+			*lineStart = true
+			return "", nil
+		}
+		*lineStart = false
+		switch c {
+		case 0x1b:
+			// arrow keys send ESC [ A to D
+			if s, err := r.Peek(2); err == nil && s[0] == '[' {
+				r.Discard(2)
+				switch s[1] {
+				case 'A':
+					return "up", nil
+				case 'B':
+					return "down", nil
+				case 'C':
+					return "right", nil
+				case 'D':
+					return "left", nil
+				}
+			}
+			continue
+		case 0x7f, '\b':
+			return "left", nil
+		}
+		return string(c), nil
+	}
+}
+
+// browse lets the user navigate until done and returns the marks.
+func (a *browser) browse(in io.Reader, out io.Writer, raw bool) error {
+	r := bufio.NewReader(in)
+	lineStart := true
+	for {
+		// draw once all keys typed ahead, or a line of keys, are handled
+		if raw && r.Buffered() == 0 || !raw && lineStart {
+			a.render(out, raw)
+		}
+		k, err := readKey(r, raw, &lineStart)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if a.key(k) {
+			return nil
+		}
+	}
+}
+
+// filterMarks drops marks holding protected coordinates.
+func (a *protection) filterMarks(inst NexusInstance, ms []browseMark) (
+	[]browseMark, error) {
+	var ok []browseMark
+	for _, m := range ms {
+		ps, err := a.filterPaths(NexusRepository{NexusInstance: inst,
+			RepositoryID: m.repo}, []string{m.path})
+		if err != nil {
+			return nil, err
+		}
+		if len(ps) > 0 {
+			ok = append(ok, m)
+		}
+	}
+	return ok, nil
+}
+
+func browseCommand(args []string) {
+	fs := newCommand("browse", "[<repository>/[path]]")
+	nf := newNexusFlags(fs)
+	outputDir := fs.String("outputDir", ".",
+		"Directory to put fetched files into")
+	confirm := newConfirmation(fs)
+	audit := newAuditLog(fs)
+	protected := newProtection(fs)
+	dryRun := fs.Bool("dry-run", false,
+		"Print what would be fetched and deleted without doing it")
+	fs.Parse(args)
+	if fs.NArg() > 1 {
+		fs.Usage()
+	}
+
+	p := strings.Trim(fs.Arg(0), "/")
+	repo, dir := p, ""
+	if i := strings.Index(p, "/"); i >= 0 {
+		repo, dir = p[:i], p[i+1:]
+	}
+	inst := nf.instance()
+	b, err := newBrowser(inst, repo, dir)
+	if err != nil {
+		fail(err)
+	}
+	// keep log output from scrolling the screen while browsing
+	log.SetOutput(io.Discard)
+	restore, err := rawTerminal()
+	raw := err == nil
+	err = b.browse(os.Stdin, os.Stderr, raw)
+	if raw {
+		restore()
+	}
+	log.SetOutput(os.Stderr)
+	if err != nil {
+		fail(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt,
+		syscall.SIGTERM)
+	defer stop()
+	fetch, remove := marked(b.fetch), marked(b.remove)
+	remove, err = protected.filterMarks(inst, remove)
+	if err != nil {
+		fail(err)
+	}
+	if *dryRun {
+		var failures []error
+		for _, m := range fetch {
+			err := planFetch(ctx, RepositoryFileURL(NexusRepository{
+				NexusInstance: inst, RepositoryID: m.repo}, m.path),
+				*outputDir, nexus.SanitizeName(path.Base(m.path)), Gav{})
+			if err != nil {
+				failures = append(failures, err)
+			}
+		}
+		for _, m := range remove {
+			fmt.Printf("would delete %s\n", RepositoryFileURL(
+				NexusRepository{NexusInstance: inst, RepositoryID: m.repo},
+				m.path))
+		}
+		exit(summaryExitCode(len(fetch), failures))
+	}
+	var failures []error
+	for _, m := range fetch {
+		res, err := getAs(ctx, inst,
+			RepositoryFileURL(NexusRepository{NexusInstance: inst,
+				RepositoryID: m.repo}, m.path))
+		var f string
+		if err == nil {
+			f, err = persistBody(res, *outputDir,
+				nexus.SanitizeName(path.Base(m.path)), 0)
+		}
+		if err != nil {
+			log.Printf("%s: %v\n", m, err)
+			failures = append(failures, err)
+			continue
+		}
+		fmt.Println(f)
+	}
+	if len(remove) > 0 {
+		var items []string
+		for _, m := range remove {
+			items = append(items, m.String())
+		}
+		if err := confirm.confirm("delete", items); err != nil {
+			fail(err)
+		}
+	}
+	for _, m := range remove {
+		repo := NexusRepository{NexusInstance: inst, RepositoryID: m.repo}
+		err := deletePath(ctx, repo, m.path)
+		if aerr := audit.record(newAuditRecord("browse delete", repo,
+			Gav{}, m.path, err)); aerr != nil {
+			fail(aerr)
+		}
+		if err != nil {
+			log.Printf("%s: %v\n", m, err)
+			failures = append(failures, err)
+		}
+	}
+	exit(summaryExitCode(len(fetch)+len(remove), failures))
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"flag"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jhinrichsen/nexus-fetch/nexusfetchtest"
+)
+
+func TestBrowse(t *testing.T) {
+	uploaded := time.Date(2018, 3, 12, 17, 39, 14, 0, time.UTC)
+	_, inst := newFakeNexus(t,
+		nexusfetchtest.Artifact{Repository: "releases", Group: "com.acme",
+			Artifact: "app", Version: "1.0", Content: []byte("12345"),
+			Uploaded: uploaded},
+		nexusfetchtest.Artifact{Repository: "releases", Group: "com.acme",
+			Artifact: "app", Version: "1.1", Content: []byte("123456"),
+			Uploaded: uploaded})
+	b, err := newBrowser(inst, "releases", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// preview com/acme/app, mark version 1.0 for deletion, fetch the jar
+	// of 1.1, back up and done
+	in := "l\nl\ni\nl\nd\njl\n \nh\nq\n"
+	var sb strings.Builder
+	if err := b.browse(strings.NewReader(in), &sb, false); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"com.acme:app", "2 versions: 1.0 1.1",
+		"1 to fetch, 1 to delete"} {
+		if !strings.Contains(sb.String(), want) {
+			t.Fatalf("Expected %s but got\n%s\n", want, sb.String())
+		}
+	}
+	if b.dir != "com/acme/app" || b.cursor != 1 {
+		t.Fatalf("Expected cursor on 1.1 in com/acme/app but got %d in %s\n",
+			b.cursor, b.dir)
+	}
+	want := []browseMark{{"releases", "com/acme/app/1.1/app-1.1.jar"}}
+	if got := marked(b.fetch); !reflect.DeepEqual(want, got) {
+		t.Fatalf("Expected %v but got %v\n", want, got)
+	}
+	want = []browseMark{{"releases", "com/acme/app/1.0/"}}
+	if got := marked(b.remove); !reflect.DeepEqual(want, got) {
+		t.Fatalf("Expected %v but got %v\n", want, got)
+	}
+}
+
+func TestBrowseDeleteSkipsProtected(t *testing.T) {
+	_, inst := newFakeNexus(t,
+		nexusfetchtest.Artifact{Repository: "releases", Group: "com.acme",
+			Artifact: "app", Version: "1.0"},
+		nexusfetchtest.Artifact{Repository: "releases", Group: "com.acme",
+			Artifact: "app", Version: "1.1"})
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	p := newProtection(fs)
+	fs.Parse([]string{"-protect", "com.acme:app:1.0"})
+	ms := []browseMark{{"releases", "com/acme/app/1.0/"},
+		{"releases", "com/acme/app/1.1/"}}
+	got, err := p.filterMarks(inst, ms)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := ms[1:]; !reflect.DeepEqual(want, got) {
+		t.Fatalf("Expected %v but got %v\n", want, got)
+	}
+}
@@ -0,0 +1,296 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"path"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/jhinrichsen/nexus-fetch/nexus"
+)
+
+// bundleManifestName is the tar entry listing the contents of a bundle.
+const bundleManifestName = "manifest.json"
+
+// bundleManifest describes the files of a bundle, which are stored in
+// Maven layout.
+type bundleManifest struct {
+	Created time.Time    `json:"created"`
+	Files   []bundleFile `json:"files"`
+}
+
+type bundleFile struct {
+	Path     string `json:"path"`
+	Group    string `json:"groupId"`
+	Artifact string `json:"artifactId"`
+	Version  string `json:"version"`
+	Size     int64  `json:"size"`
+	SHA256   string `json:"sha256"`
+}
+
+// exportBundle writes all files of the versions of gavs in repo as a gzip
+// compressed tar with a manifest as last entry.
+func exportBundle(ctx context.Context, w io.Writer, repo NexusRepository,
+	gavs []Gav) (bundleManifest, error) {
+	m := bundleManifest{Created: time.Now().UTC()}
+	zw := gzip.NewWriter(w)
+	tw := tar.NewWriter(zw)
+	seen := make(map[string]bool)
+	for _, gav := range gavs {
+		dir := path.Dir(gav.DefaultLayout())
+		if seen[dir] {
+			continue
+		}
+		seen[dir] = true
+		items, err := listContent(repo, dir)
+		if err != nil {
+			return m, fmt.Errorf("%s: %w", gav.ConciseNotation(), err)
+		}
+		sort.Slice(items, func(i, j int) bool {
+			return items[i].Name < items[j].Name
+		})
+		for _, item := range items {
+			if !item.Leaf || nexus.Ignored(item.Name) {
+				continue
+			}
+			f := bundleFile{Path: dir + "/" + item.Name, Group: gav.Group,
+				Artifact: gav.Artifact, Version: gav.Version}
+			if err := addBundleFile(ctx, tw, repo, &f); err != nil {
+				return m, err
+			}
+			m.Files = append(m.Files, f)
+		}
+	}
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return m, err
+	}
+	err = tw.WriteHeader(&tar.Header{Name: bundleManifestName, Mode: 0644,
+		Size: int64(len(data)), ModTime: m.Created})
+	if err == nil {
+		_, err = tw.Write(data)
+	}
+	if err == nil {
+		err = tw.Close()
+	}
+	if err == nil {
+		err = zw.Close()
+	}
+	return m, err
+}
+
+// addBundleFile streams f into the tar and completes its size and
+// checksum. The tar header needs the size upfront, downloads without a
+// Content-Length are spooled to a temporary file first.
+func addBundleFile(ctx context.Context, tw *tar.Writer,
+	repo NexusRepository, f *bundleFile) error {
+	res, err := getAs(ctx, repo.NexusInstance,
+		RepositoryFileURL(repo, f.Path))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	var r io.Reader = res.Body
+	size := res.ContentLength
+	if size < 0 {
+		tmp, err := os.CreateTemp("", "bundle-")
+		if err != nil {
+			return err
+		}
+		defer os.Remove(tmp.Name())
+		defer tmp.Close()
+		if size, err = io.Copy(tmp, res.Body); err != nil {
+			return err
+		}
+		if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		r = tmp
+	}
+	if err := tw.WriteHeader(&tar.Header{Name: f.Path, Mode: 0644,
+		Size: size, ModTime: time.Now()}); err != nil {
+		return err
+	}
+	hash := sha256.New()
+	if _, err := io.Copy(tw, io.TeeReader(r, hash)); err != nil {
+		return err
+	}
+	f.Size = size
+	f.SHA256 = fmt.Sprintf("%x", hash.Sum(nil))
+	return nil
+}
+
+// walkBundle calls fn for each entry of the bundle in filename.
+func walkBundle(filename string, fn func(h *tar.Header,
+	r io.Reader) error) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	tr := tar.NewReader(zr)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(h, tr); err != nil {
+			return err
+		}
+	}
+}
+
+// verifyBundle checks every file of a bundle against its manifest, before
+// anything is imported.
+func verifyBundle(filename string) (bundleManifest, error) {
+	var m bundleManifest
+	sums := make(map[string]string)
+	err := walkBundle(filename, func(h *tar.Header, r io.Reader) error {
+		if h.Name == bundleManifestName {
+			return json.NewDecoder(r).Decode(&m)
+		}
+		hash := sha256.New()
+		n, err := io.Copy(hash, r)
+		if err != nil {
+			return err
+		}
+		sums[h.Name] = fmt.Sprintf("%d %x", n, hash.Sum(nil))
+		return nil
+	})
+	if err != nil {
+		return m, err
+	}
+	if m.Files == nil {
+		return m, fmt.Errorf("%s: missing %s", filename,
+			bundleManifestName)
+	}
+	for _, f := range m.Files {
+		if path.IsAbs(f.Path) || path.Clean(f.Path) != f.Path ||
+			strings.HasPrefix(f.Path, "../") {
+			return m, fmt.Errorf("%s: bad path %q", filename, f.Path)
+		}
+		sum, ok := sums[f.Path]
+		if !ok {
+			return m, &integrityError{fmt.Errorf("%s: missing %s",
+				filename, f.Path)}
+		}
+		if sum != fmt.Sprintf("%d %s", f.Size, f.SHA256) {
+			return m, &integrityError{fmt.Errorf("%s: checksum mismatch "+
+				"of %s", filename, f.Path)}
+		}
+		delete(sums, f.Path)
+	}
+	for p := range sums {
+		return m, &integrityError{fmt.Errorf("%s: %s is not in the "+
+			"manifest", filename, p)}
+	}
+	return m, nil
+}
+
+// importBundle deploys all files of a verified bundle into repo.
+func importBundle(ctx context.Context, repo NexusRepository,
+	filename string) (int, error) {
+	var n int
+	err := walkBundle(filename, func(h *tar.Header, r io.Reader) error {
+		if h.Name == bundleManifestName {
+			return nil
+		}
+		if err := uploadStream(ctx, repo, h.Name, r, h.Size); err != nil {
+			return fmt.Errorf("%s: %w", h.Name, err)
+		}
+		n++
+		return nil
+	})
+	return n, err
+}
+
+func exportBundleCommand(args []string) {
+	fs := newCommand("export-bundle", "[GAV in concise notation...]")
+	nf := newNexusFlags(fs)
+	output := fs.String("o", "bundle.tar.gz", "Bundle file to write")
+	fs.Parse(args)
+	if nf.repo().RepositoryID == "" {
+		fs.Usage()
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt,
+		syscall.SIGTERM)
+	defer stop()
+	gavs := coordinates(fs.Args(), nf.repos())
+	f, err := os.Create(*output)
+	if err != nil {
+		fail(err)
+	}
+	m, err := exportBundle(ctx, f, nf.repo(), gavs)
+	if err == nil {
+		err = f.Close()
+	} else {
+		f.Close()
+	}
+	if err != nil {
+		os.Remove(*output)
+		fail(err)
+	}
+	log.Printf("wrote %d files to %s\n", len(m.Files), *output)
+}
+
+func importBundleCommand(args []string) {
+	fs := newCommand("import-bundle", "<bundle.tar.gz>")
+	nf := newNexusFlags(fs)
+	audit := newAuditLog(fs)
+	verifyOnly := fs.Bool("verify-only", false,
+		"Check the bundle against its manifest, deploy nothing")
+	dryRun := fs.Bool("dry-run", false,
+		"Print what would be deployed without deploying it")
+	fs.Parse(args)
+	if fs.NArg() != 1 || nf.repo().RepositoryID == "" {
+		fs.Usage()
+	}
+
+	m, err := verifyBundle(fs.Arg(0))
+	if err != nil {
+		fail(err)
+	}
+	log.Printf("%s: %d files verified\n", fs.Arg(0), len(m.Files))
+	if *verifyOnly {
+		return
+	}
+	repo := nf.repo()
+	if *dryRun {
+		for _, f := range m.Files {
+			fmt.Printf("would upload %s (%s)\n",
+				RepositoryFileURL(repo, f.Path), humanSize(f.Size))
+		}
+		return
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt,
+		syscall.SIGTERM)
+	defer stop()
+	n, err := importBundle(ctx, repo, fs.Arg(0))
+	if aerr := audit.record(newAuditRecord("import-bundle", repo, Gav{},
+		fs.Arg(0), err)); aerr != nil {
+		fail(aerr)
+	}
+	if err != nil {
+		fail(err)
+	}
+	log.Printf("deployed %d files to %s\n", n, repo.RepositoryID)
+}
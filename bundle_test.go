@@ -0,0 +1,131 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jhinrichsen/nexus-fetch/nexusfetchtest"
+)
+
+func TestBundle(t *testing.T) {
+	_, inst := newFakeNexus(t,
+		nexusfetchtest.Artifact{Repository: "releases", Group: "com.acme",
+			Artifact: "app", Version: "1.0", Content: []byte("jar")},
+		pomArtifact("com.acme", "app", "1.0", "<project/>"))
+	ctx := context.Background()
+	gav := Gav{Group: "com.acme", Artifact: "app", Version: "1.0"}
+
+	var buf bytes.Buffer
+	m, err := exportBundle(ctx, &buf, NexusRepository{NexusInstance: inst,
+		RepositoryID: "releases"},
+		[]Gav{gav, gav})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Files) != 2 {
+		t.Fatalf("Expected %d but got %d\n", 2, len(m.Files))
+	}
+	f := filepath.Join(t.TempDir(), "bundle.tar.gz")
+	if err := os.WriteFile(f, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := verifyBundle(f); err != nil {
+		t.Fatal(err)
+	}
+
+	target := NexusRepository{NexusInstance: inst, RepositoryID: "airgap"}
+	n, err := importBundle(ctx, target, f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Fatalf("Expected %d but got %d\n", 2, n)
+	}
+	found, err := exists(ctx, Fqa{NexusRepository: target, Gav: gav})
+	if err != nil || !found {
+		t.Fatalf("Expected imported artifact but got %v, %v\n", found, err)
+	}
+}
+
+func TestBundleTampered(t *testing.T) {
+	_, inst := newFakeNexus(t, nexusfetchtest.Artifact{
+		Repository: "releases", Group: "com.acme", Artifact: "app",
+		Version: "1.0", Content: []byte("payload")})
+	var buf bytes.Buffer
+	_, err := exportBundle(context.Background(), &buf,
+		NexusRepository{NexusInstance: inst, RepositoryID: "releases"},
+		[]Gav{{Group: "com.acme", Artifact: "app", Version: "1.0"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// same size, different content
+	data := bytes.Replace(gunzip(t, buf.Bytes()), []byte("payload"),
+		[]byte("PAYLOAD"), 1)
+	f := filepath.Join(t.TempDir(), "bundle.tar.gz")
+	if err := os.WriteFile(f, gzipped(t, data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := verifyBundle(f); exitCode(err) != exitIntegrity {
+		t.Fatalf("Expected integrity error but got %v\n", err)
+	}
+}
+
+func gunzip(t *testing.T, data []byte) []byte {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err = io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func gzipped(t *testing.T, data []byte) []byte {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write(data)
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestAddBundleFileWithoutLength(t *testing.T) {
+	inst := fakeNexus(t, func(w http.ResponseWriter, r *http.Request) {
+		// flushing forces a chunked response without Content-Length
+		io.WriteString(w, "ja")
+		w.(http.Flusher).Flush()
+		io.WriteString(w, "r")
+	})
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	f := bundleFile{Path: "com/acme/app/1.0/app-1.0.jar"}
+	err := addBundleFile(context.Background(), tw,
+		NexusRepository{NexusInstance: inst, RepositoryID: "releases"}, &f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	want := fmt.Sprintf("%x", sha256.Sum256([]byte("jar")))
+	if f.Size != 3 || f.SHA256 != want {
+		t.Fatalf("Expected size 3 and %s but got %d and %s\n", want,
+			f.Size, f.SHA256)
+	}
+	h, err := tar.NewReader(&buf).Next()
+	if err != nil || h.Size != 3 {
+		t.Fatalf("Expected tar entry of size 3 but got %v, %v\n", h, err)
+	}
+}
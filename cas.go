@@ -0,0 +1,138 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// casStore keeps files once by their SHA-256 below dir/sha256/, and maps
+// keys such as repository paths to hashes in small files below dir/index/.
+// Identical content cached under different keys is stored once.
+type casStore struct {
+	dir string
+}
+
+func (a casStore) object(sum string) string {
+	return filepath.Join(a.dir, "sha256", sum[:2], sum)
+}
+
+// ref returns the index file of key, which must stay below the index
+// directory.
+func (a casStore) ref(key string) (string, error) {
+	index := filepath.Join(a.dir, "index")
+	p := filepath.Join(index, filepath.FromSlash(key))
+	if !strings.HasPrefix(p, index+string(filepath.Separator)) {
+		return "", fmt.Errorf("illegal cache key %q", key)
+	}
+	return p, nil
+}
+
+// validSum reports whether s looks like a hex encoded SHA-256.
+func validSum(s string) bool {
+	if len(s) != 2*sha256.Size {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
+
+// open returns the content stored for key.
+func (a casStore) open(key string) (*os.File, error) {
+	ref, err := a.ref(key)
+	if err != nil {
+		return nil, err
+	}
+	buf, err := os.ReadFile(ref)
+	if err != nil {
+		return nil, err
+	}
+	sum := strings.TrimSpace(string(buf))
+	if !validSum(sum) {
+		return nil, fmt.Errorf("corrupt cache index %s", ref)
+	}
+	return os.Open(a.object(sum))
+}
+
+// create returns a writer for the content of key, which becomes visible
+// on commit.
+func (a casStore) create(key string) (*casWriter, error) {
+	if _, err := a.ref(key); err != nil {
+		return nil, err
+	}
+	tmp, err := tempFile(filepath.Join(a.dir, "sha256", "new"))
+	if err != nil {
+		return nil, err
+	}
+	return &casWriter{a, key, tmp, sha256.New()}, nil
+}
+
+type casWriter struct {
+	store casStore
+	key   string
+	tmp   *os.File
+	h     hash.Hash
+}
+
+func (a *casWriter) Write(p []byte) (int, error) {
+	a.h.Write(p)
+	return a.tmp.Write(p)
+}
+
+// commit stores the written content unless err is set, dropping it if the
+// same content is already stored.
+func (a *casWriter) commit(err error) error {
+	sum := hex.EncodeToString(a.h.Sum(nil))
+	obj := a.store.object(sum)
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(obj), 0755)
+	}
+	if _, serr := os.Stat(obj); err == nil && serr == nil {
+		a.tmp.Close()
+		os.Remove(a.tmp.Name())
+	} else {
+		commit(a.tmp, obj, err)
+	}
+	if err != nil {
+		return err
+	}
+	ref, err := a.store.ref(a.key)
+	if err != nil {
+		return err
+	}
+	tmp, err := tempFile(ref)
+	if err != nil {
+		return err
+	}
+	_, err = tmp.WriteString(sum + "\n")
+	commit(tmp, ref, err)
+	return err
+}
+
+// verify hashes all stored files and returns those whose content does not
+// match their name.
+func (a casStore) verify() ([]string, error) {
+	var corrupt []string
+	err := filepath.Walk(filepath.Join(a.dir, "sha256"),
+		func(p string, fi os.FileInfo, err error) error {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			if err != nil || fi.IsDir() || !validSum(fi.Name()) {
+				return err
+			}
+			sum, err := digestFile(p, sha256.New())
+			if err != nil {
+				return err
+			}
+			if sum != fi.Name() {
+				corrupt = append(corrupt, p)
+			}
+			return nil
+		})
+	return corrupt, err
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCasStoreDedup(t *testing.T) {
+	store := casStore{t.TempDir()}
+	for _, key := range []string{"releases/g/a/1.0/a-1.0.jar",
+		"thirdparty/g/b/1.0/b-1.0.jar"} {
+		cw, err := store.create(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cw.Write([]byte("same"))
+		if err := cw.commit(nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f, err := store.open("thirdparty/g/b/1.0/b-1.0.jar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf, _ := ioutil.ReadAll(f)
+	f.Close()
+	if string(buf) != "same" {
+		t.Fatalf("Expected %q but got %q\n", "same", buf)
+	}
+	objects, _ := filepath.Glob(filepath.Join(store.dir, "sha256", "*",
+		"*"))
+	if len(objects) != 1 {
+		t.Fatalf("Expected one stored object but got %v\n", objects)
+	}
+
+	if corrupt, err := store.verify(); err != nil || len(corrupt) != 0 {
+		t.Fatalf("Expected intact store but got %v %v\n", corrupt, err)
+	}
+	os.WriteFile(objects[0], []byte("changed"), 0644)
+	if corrupt, _ := store.verify(); len(corrupt) != 1 {
+		t.Fatalf("Expected one corrupt object but got %v\n", corrupt)
+	}
+}
+
+func TestCasStoreFailedWrite(t *testing.T) {
+	store := casStore{t.TempDir()}
+	cw, err := store.create("k")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cw.Write([]byte("partial"))
+	cw.commit(os.ErrClosed)
+	if _, err := store.open("k"); err == nil {
+		t.Fatalf("Expected failed write not to be stored\n")
+	}
+}
+
+func TestCasRefStaysInIndex(t *testing.T) {
+	s := casStore{t.TempDir()}
+	if _, err := s.ref("../../x/g/a/1.0/a-1.0.jar"); err == nil {
+		t.Fatalf("Expected an error for a key outside the index\n")
+	}
+	if _, err := s.ref("releases/g/a/1.0/a-1.0.jar"); err != nil {
+		t.Fatal(err)
+	}
+}
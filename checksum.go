@@ -0,0 +1,171 @@
+package main
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// checksumAlgorithms are the digests of Maven checksum sidecars by their
+// extension without dot.
+var checksumAlgorithms = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+// fipsApproved algorithms may decide about integrity in FIPS mode, others
+// are still accepted as server provided metadata.
+var fipsApproved = map[string]bool{"sha256": true, "sha512": true}
+
+// checksumSelection is a flag value of comma separated algorithms.
+type checksumSelection []string
+
+func (a *checksumSelection) String() string {
+	if a == nil {
+		return ""
+	}
+	return strings.Join(*a, ",")
+}
+
+func (a *checksumSelection) Set(s string) error {
+	var cs []string
+	for _, c := range strings.Split(s, ",") {
+		c = strings.ToLower(strings.TrimSpace(c))
+		if c == "" {
+			continue
+		}
+		if _, ok := checksumAlgorithms[c]; !ok {
+			var names []string
+			for name := range checksumAlgorithms {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			return fmt.Errorf("unknown checksum %q, want one of %s", c,
+				strings.Join(names, ", "))
+		}
+		cs = append(cs, c)
+	}
+	*a = cs
+	return checkFIPS(fips, cs)
+}
+
+// fipsFlag is the -fips flag, rejecting a -checksum selection FIPS mode
+// would leave empty regardless of flag order.
+type fipsFlag struct{}
+
+func (fipsFlag) IsBoolFlag() bool { return true }
+
+func (fipsFlag) String() string { return strconv.FormatBool(fips) }
+
+func (fipsFlag) Set(s string) error {
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	fips = b
+	return checkFIPS(fips, checksums)
+}
+
+// checkFIPS fails if FIPS mode leaves none of the selected checksums. No
+// selection verifies sha256 in FIPS mode.
+func checkFIPS(fips bool, cs []string) error {
+	if !fips || cs == nil {
+		return nil
+	}
+	for _, c := range cs {
+		if fipsApproved[c] {
+			return nil
+		}
+	}
+	return fmt.Errorf("-fips leaves none of -checksum %s, add sha256 or "+
+		"sha512", strings.Join(cs, ","))
+}
+
+var (
+	// checksums verify downloads, see -checksum. If not set, -strict
+	// verifies sha1.
+	checksums checksumSelection
+	// fips restricts verification to FIPS approved algorithms.
+	fips bool
+)
+
+// verifiedChecksums returns the algorithms verifying downloads, without
+// those FIPS mode does not approve.
+func verifiedChecksums() []string {
+	cs := []string(checksums)
+	if cs == nil {
+		cs = []string{"sha1"}
+		if fips {
+			cs = []string{"sha256"}
+		}
+	}
+	var ok []string
+	for _, c := range cs {
+		if !fips || fipsApproved[c] {
+			ok = append(ok, c)
+		}
+	}
+	return ok
+}
+
+// verifyChecksum compares file with the expected hex digest of algorithm
+// c.
+func verifyChecksum(file, c, want string) error {
+	got, err := digestFile(file, checksumAlgorithms[c]())
+	if err != nil {
+		return err
+	}
+	if got != strings.ToLower(want) {
+		return &integrityError{fmt.Errorf("%s: expected %s %s but got %s",
+			file, strings.ToUpper(c), want, got)}
+	}
+	return nil
+}
+
+// digestFile returns the hex encoded digest of a file.
+func digestFile(path string, h hash.Hash) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// remoteChecksum reads a checksum sidecar such as a-v.jar.sha1. Some tools
+// append the filename to the hash, which is ignored.
+func remoteChecksum(u string) (string, error) {
+	res, err := httpClient.Get(u)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return "", &StatusError{URL: u, StatusCode: res.StatusCode}
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(res.Body, 1024))
+	if err != nil {
+		return "", err
+	}
+	fields := strings.Fields(string(body))
+	if len(fields) == 0 {
+		return "", nil
+	}
+	return strings.ToLower(fields[0]), nil
+}
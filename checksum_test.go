@@ -0,0 +1,82 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestVerifiedChecksums(t *testing.T) {
+	defer func(cs checksumSelection, f bool) {
+		checksums, fips = cs, f
+	}(checksums, fips)
+	for _, tt := range []struct {
+		flag string
+		fips bool
+		want []string
+	}{
+		{"", false, []string{"sha1"}},
+		{"", true, []string{"sha256"}},
+		{"SHA256, sha512", false, []string{"sha256", "sha512"}},
+		{"md5,sha1,sha512", true, []string{"sha512"}},
+	} {
+		checksums, fips = nil, tt.fips
+		if tt.flag != "" {
+			if err := checksums.Set(tt.flag); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if got := verifiedChecksums(); !reflect.DeepEqual(tt.want, got) {
+			t.Fatalf("Expected %v but got %v\n", tt.want, got)
+		}
+	}
+	if err := checksums.Set("crc32"); err == nil {
+		t.Fatalf("Expected an error for crc32\n")
+	}
+}
+
+func TestFIPSRejectsUnapprovedChecksums(t *testing.T) {
+	defer func(cs checksumSelection, f bool) {
+		checksums, fips = cs, f
+	}(checksums, fips)
+	for _, args := range [][]string{
+		{"-fips", "-checksum", "sha1,md5"},
+		{"-checksum", "sha1,md5", "-fips"},
+	} {
+		checksums, fips = nil, false
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		newNexusFlags(fs)
+		if err := fs.Parse(args); err == nil {
+			t.Fatalf("%q: expected error but got nil\n", args)
+		}
+	}
+	checksums, fips = nil, false
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	newNexusFlags(fs)
+	err := fs.Parse([]string{"-fips", "-checksum", "sha1,sha256"})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVerifyChecksum(t *testing.T) {
+	f := filepath.Join(t.TempDir(), "a.jar")
+	if err := os.WriteFile(f, []byte("abc"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	const sha256abc = "BA7816BF8F01CFEA414140DE5DAE2223" +
+		"B00361A396177A9CB410FF61F20015AD"
+	if err := verifyChecksum(f, "sha256", sha256abc); err != nil {
+		t.Fatal(err)
+	}
+	var ie *integrityError
+	if err := verifyChecksum(f, "sha512", sha256abc); !errors.As(err,
+		&ie) {
+		t.Fatalf("Expected an integrity error but got %v\n", err)
+	}
+}
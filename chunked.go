@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// errNoRanges reports a server that cannot serve byte ranges, callers fall
+// back to a plain download.
+var errNoRanges = errors.New("server does not support byte ranges")
+
+// fetchChunked downloads u using n concurrent range requests into a file
+// below dir named by name, which receives the HEAD response.
+func fetchChunked(ctx context.Context, inst NexusInstance, u string, n int,
+	dir string, name func(*http.Response) (string, error),
+	maxSize int64) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, u, nil)
+	if err != nil {
+		return "", err
+	}
+	if inst.Username != "" {
+		req.SetBasicAuth(inst.Username, inst.Password)
+	}
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return "", &StatusError{URL: u, StatusCode: res.StatusCode}
+	}
+	size := res.ContentLength
+	if res.Header.Get("Accept-Ranges") != "bytes" || size <= 0 {
+		return "", errNoRanges
+	}
+	// chunks go to where redirects ended, so that a snapshot is not
+	// resolved once per chunk
+	final := res.Request.URL.String()
+
+	base, err := name(res)
+	if err != nil {
+		return "", err
+	}
+	f := filepath.Join(dir, base)
+	if err := os.MkdirAll(longPath(filepath.Dir(f)), 0755); err != nil {
+		return "", err
+	}
+	if err := checkSize(size, maxSize, dir); err != nil {
+		return "", err
+	}
+	out, err := os.Create(longPath(f))
+	if err != nil {
+		return "", err
+	}
+	err = out.Truncate(size)
+	if err == nil {
+		log.Printf("writing %s in %d chunks\n", f, n)
+		err = fetchChunks(ctx, inst, final, out, size, n)
+	}
+	if err == nil {
+		err = out.Close()
+	} else {
+		out.Close()
+	}
+	if err != nil {
+		os.Remove(longPath(f))
+		return "", err
+	}
+	return f, nil
+}
+
+// fetchChunks splits size bytes into n ranges and fetches them in
+// parallel, the first failure cancels all others.
+func fetchChunks(ctx context.Context, inst NexusInstance, u string,
+	out io.WriterAt, size int64, n int) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	chunk := (size + int64(n) - 1) / int64(n)
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for from := int64(0); from < size; from += chunk {
+		to := from + chunk - 1
+		if to >= size {
+			to = size - 1
+		}
+		wg.Add(1)
+		go func(from, to int64) {
+			defer wg.Done()
+			if err := fetchRange(ctx, inst, u, out, from, to); err != nil {
+				errs <- err
+				cancel()
+			}
+		}(from, to)
+	}
+	wg.Wait()
+	close(errs)
+	return <-errs
+}
+
+func fetchRange(ctx context.Context, inst NexusInstance, u string,
+	out io.WriterAt, from, to int64) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	if inst.Username != "" {
+		req.SetBasicAuth(inst.Username, inst.Password)
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", from, to))
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusPartialContent {
+		return &StatusError{URL: u, StatusCode: res.StatusCode}
+	}
+	n, err := io.Copy(&offsetWriter{out, from}, res.Body)
+	if err != nil {
+		return err
+	}
+	if want := to - from + 1; n != want {
+		return fmt.Errorf("range %d-%d of %s: got %d of %d bytes", from, to,
+			u, n, want)
+	}
+	return nil
+}
+
+// offsetWriter writes sequentially starting at an offset.
+type offsetWriter struct {
+	w   io.WriterAt
+	off int64
+}
+
+func (a *offsetWriter) Write(p []byte) (int, error) {
+	n, err := a.w.WriteAt(p, a.off)
+	a.off += int64(n)
+	return n, err
+}
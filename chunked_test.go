@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFetchChunked(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 10001)
+	var ranges int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter,
+		r *http.Request) {
+		if r.Header.Get("Range") != "" {
+			atomic.AddInt32(&ranges, 1)
+		}
+		http.ServeContent(w, r, "a-1.0.jar", time.Time{},
+			bytes.NewReader(content))
+	}))
+	defer ts.Close()
+
+	dir := t.TempDir()
+	name := func(*http.Response) (string, error) { return "a-1.0.jar", nil }
+	p, err := fetchChunked(context.Background(), NexusInstance{}, ts.URL, 8,
+		dir, name, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ioutil.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(content, got) {
+		t.Fatalf("Expected reassembled content of %d bytes but got %d\n",
+			len(content), len(got))
+	}
+	if ranges != 8 {
+		t.Fatalf("Expected 8 range requests but got %d\n", ranges)
+	}
+}
+
+func TestFetchChunkedWithoutRanges(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter,
+		r *http.Request) {
+		w.Write([]byte("no ranges"))
+	}))
+	defer ts.Close()
+	_, err := fetchChunked(context.Background(), NexusInstance{}, ts.URL, 4,
+		t.TempDir(), func(*http.Response) (string, error) { return "f", nil },
+		0)
+	if err != errNoRanges {
+		t.Fatalf("Expected %v but got %v\n", errNoRanges, err)
+	}
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/jhinrichsen/nexus-fetch/nexus"
+)
+
+// Nexus 3 task types that delete components and reclaim their space.
+const (
+	taskCleanup = "repository.cleanup"
+	taskCompact = "blobstore.compact"
+)
+
+type nexus3Task struct {
+	ID            string `json:"id"`
+	Name          string `json:"name"`
+	Type          string `json:"type"`
+	Message       string `json:"message"`
+	CurrentState  string `json:"currentState"`
+	LastRun       string `json:"lastRun"`
+	LastRunResult string `json:"lastRunResult"`
+}
+
+type nexus3Blobstore struct {
+	Name                  string `json:"name"`
+	Type                  string `json:"type"`
+	BlobCount             int64  `json:"blobCount"`
+	TotalSizeInBytes      int64  `json:"totalSizeInBytes"`
+	AvailableSpaceInBytes int64  `json:"availableSpaceInBytes"`
+}
+
+// nexus3CleanupPolicy deletes components not updated for
+// CriteriaLastBlobUpdated days once assigned to a repository.
+type nexus3CleanupPolicy struct {
+	Name                    string `json:"name"`
+	Format                  string `json:"format"`
+	Notes                   string `json:"notes,omitempty"`
+	CriteriaLastBlobUpdated int    `json:"criteriaLastBlobUpdated,omitempty"`
+	CriteriaReleaseType     string `json:"criteriaReleaseType,omitempty"`
+}
+
+func nexus3Tasks(ctx context.Context, inst NexusInstance,
+	typ string) ([]nexus3Task, error) {
+	var ts []nexus3Task
+	err := nexus3Pages(ctx, inst, "v1/tasks", url.Values{"type": {typ}},
+		func(items json.RawMessage) error {
+			var page []nexus3Task
+			err := json.Unmarshal(items, &page)
+			ts = append(ts, page...)
+			return err
+		})
+	return ts, err
+}
+
+// runTask starts a task and polls until it finished a new run.
+func runTask(ctx context.Context, inst NexusInstance, t nexus3Task,
+	poll time.Duration) (nexus3Task, error) {
+	log.Printf("running task %s (%s)\n", t.Name, t.Type)
+	if err := nexus3(ctx, http.MethodPost, inst,
+		"v1/tasks/"+t.ID+"/run", nil, nil); err != nil {
+		return t, err
+	}
+	lastRun := t.LastRun
+	for {
+		select {
+		case <-ctx.Done():
+			return t, ctx.Err()
+		case <-time.After(poll):
+		}
+		if err := nexus3(ctx, http.MethodGet, inst, "v1/tasks/"+t.ID, nil,
+			&t); err != nil {
+			return t, err
+		}
+		if t.LastRun != lastRun && t.CurrentState != "RUNNING" {
+			return t, nil
+		}
+	}
+}
+
+// blobstoreUsage returns the bytes used by a blob store.
+func blobstoreUsage(ctx context.Context, inst NexusInstance,
+	name string) (int64, error) {
+	var bs []nexus3Blobstore
+	if err := nexus3(ctx, http.MethodGet, inst, "v1/blobstores", nil,
+		&bs); err != nil {
+		return 0, err
+	}
+	for _, b := range bs {
+		if b.Name == name {
+			return b.TotalSizeInBytes, nil
+		}
+	}
+	return 0, fmt.Errorf("no blob store %s", name)
+}
+
+// saveCleanupPolicy updates the policy or creates it if it does not exist.
+func saveCleanupPolicy(ctx context.Context, inst NexusInstance,
+	p nexus3CleanupPolicy) error {
+	err := nexus3(ctx, http.MethodPut, inst,
+		"v1/cleanup-policies/"+url.PathEscape(p.Name), p, nil)
+	if nexus.IsNotFound(err) {
+		err = nexus3(ctx, http.MethodPost, inst, "v1/cleanup-policies", p,
+			nil)
+	}
+	return err
+}
+
+// repositoryConfig returns the API path and the configuration of a
+// repository. The configuration is kept as a map so that writing it back
+// preserves all settings.
+func repositoryConfig(ctx context.Context, inst NexusInstance,
+	id string) (string, map[string]interface{}, error) {
+	var r struct {
+		Format string `json:"format"`
+		Type   string `json:"type"`
+	}
+	if err := nexus3(ctx, http.MethodGet, inst,
+		"v1/repositories/"+url.PathEscape(id), nil, &r); err != nil {
+		return "", nil, err
+	}
+	// the API names the maven2 format maven
+	if r.Format == "maven2" {
+		r.Format = "maven"
+	}
+	p := "v1/repositories/" + r.Format + "/" + r.Type + "/" +
+		url.PathEscape(id)
+	var config map[string]interface{}
+	err := nexus3(ctx, http.MethodGet, inst, p, nil, &config)
+	return p, config, err
+}
+
+// assignCleanupPolicy adds a cleanup policy to a repository configuration
+// and reports whether it was missing.
+func assignCleanupPolicy(config map[string]interface{}, name string) bool {
+	cleanup, _ := config["cleanup"].(map[string]interface{})
+	if cleanup == nil {
+		cleanup = make(map[string]interface{})
+		config["cleanup"] = cleanup
+	}
+	names, _ := cleanup["policyNames"].([]interface{})
+	for _, n := range names {
+		if n == name {
+			return false
+		}
+	}
+	cleanup["policyNames"] = append(names, name)
+	return true
+}
+
+// blobstoreName returns the blob store of a repository configuration.
+func blobstoreName(config map[string]interface{}) string {
+	storage, _ := config["storage"].(map[string]interface{})
+	name, _ := storage["blobStoreName"].(string)
+	return name
+}
+
+// runTasks runs all tasks of a type that match and fails if there is none.
+func runTasks(ctx context.Context, inst NexusInstance, typ string,
+	match func(nexus3Task) bool, poll time.Duration) error {
+	ts, err := nexus3Tasks(ctx, inst, typ)
+	if err != nil {
+		return err
+	}
+	n := 0
+	for _, t := range ts {
+		if !match(t) {
+			continue
+		}
+		n++
+		t, err := runTask(ctx, inst, t, poll)
+		if err != nil {
+			return err
+		}
+		if t.LastRunResult == "FAILED" {
+			return fmt.Errorf("task %s failed: %s", t.Name, t.Message)
+		}
+	}
+	if n == 0 {
+		return fmt.Errorf("no task of type %s configured", typ)
+	}
+	return nil
+}
+
+// serverSideCleanup lets Nexus 3 delete components of a repository by
+// running the cleanup task and then compacting the repository's blob store.
+// A policy is saved and assigned to the repository first. It returns the
+// reclaimed bytes.
+func serverSideCleanup(ctx context.Context, repo NexusRepository,
+	policy *nexus3CleanupPolicy, poll time.Duration) (int64, error) {
+	inst := repo.NexusInstance
+	p, config, err := repositoryConfig(ctx, inst, repo.RepositoryID)
+	if err != nil {
+		return 0, err
+	}
+	blobstore := blobstoreName(config)
+	if blobstore == "" {
+		return 0, fmt.Errorf("repository %s has no blob store",
+			repo.RepositoryID)
+	}
+	before, err := blobstoreUsage(ctx, inst, blobstore)
+	if err != nil {
+		return 0, err
+	}
+	if policy != nil {
+		log.Printf("saving cleanup policy %s\n", policy.Name)
+		if err := saveCleanupPolicy(ctx, inst, *policy); err != nil {
+			return 0, err
+		}
+		if assignCleanupPolicy(config, policy.Name) {
+			log.Printf("assigning cleanup policy %s to %s\n", policy.Name,
+				repo.RepositoryID)
+			if err := nexus3(ctx, http.MethodPut, inst, p, config,
+				nil); err != nil {
+				return 0, err
+			}
+		}
+	}
+	// there is a single cleanup task applying all policies, but one compact
+	// task per blob store, named in its message
+	all := func(nexus3Task) bool { return true }
+	if err := runTasks(ctx, inst, taskCleanup, all, poll); err != nil {
+		return 0, err
+	}
+	compacts := func(t nexus3Task) bool {
+		return t.Message == "Compacting "+blobstore+" blob store"
+	}
+	if err := runTasks(ctx, inst, taskCompact, compacts, poll); err != nil {
+		return 0, err
+	}
+	after, err := blobstoreUsage(ctx, inst, blobstore)
+	return before - after, err
+}
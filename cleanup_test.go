@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestServerSideCleanup(t *testing.T) {
+	size := int64(1000)
+	tasks := map[string]*nexus3Task{
+		"1": {ID: "1", Name: "cleanup", Type: taskCleanup,
+			CurrentState: "WAITING"},
+		"2": {ID: "2", Name: "compact", Type: taskCompact,
+			Message: "Compacting default blob store", CurrentState: "WAITING"},
+		"3": {ID: "3", Name: "compact other", Type: taskCompact,
+			Message: "Compacting other blob store", CurrentState: "WAITING"},
+	}
+	var policy nexus3CleanupPolicy
+	var ran []string
+	config := map[string]interface{}{"name": "releases",
+		"storage": map[string]interface{}{"blobStoreName": "default"}}
+	inst := fakeNexus(t, func(w http.ResponseWriter, r *http.Request) {
+		p := r.URL.Path
+		switch {
+		case p == "/nexus/service/rest/v1/blobstores":
+			json.NewEncoder(w).Encode([]nexus3Blobstore{
+				{Name: "other", TotalSizeInBytes: 5000},
+				{Name: "default", TotalSizeInBytes: size}})
+		case p == "/nexus/service/rest/v1/repositories/releases":
+			json.NewEncoder(w).Encode(map[string]string{
+				"format": "maven2", "type": "hosted"})
+		case p == "/nexus/service/rest/v1/repositories/maven/hosted/releases":
+			if r.Method == http.MethodPut {
+				json.NewDecoder(r.Body).Decode(&config)
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			json.NewEncoder(w).Encode(config)
+		case p == "/nexus/service/rest/v1/tasks":
+			var items []*nexus3Task
+			for _, task := range tasks {
+				if task.Type == r.URL.Query().Get("type") {
+					items = append(items, task)
+				}
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"items": items})
+		case p == "/nexus/service/rest/v1/cleanup-policies/old" &&
+			r.Method == http.MethodPut:
+			w.WriteHeader(http.StatusNotFound)
+		case p == "/nexus/service/rest/v1/cleanup-policies":
+			json.NewDecoder(r.Body).Decode(&policy)
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == http.MethodPost:
+			// v1/tasks/<id>/run completes at once
+			task := tasks[p[len("/nexus/service/rest/v1/tasks/"):][:1]]
+			ran = append(ran, task.Name)
+			task.LastRun, task.LastRunResult = time.Now().String(), "OK"
+			size -= 400
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			json.NewEncoder(w).Encode(
+				tasks[p[len("/nexus/service/rest/v1/tasks/"):]])
+		}
+	})
+	n, err := serverSideCleanup(context.Background(),
+		NexusRepository{NexusInstance: inst, RepositoryID: "releases"},
+		&nexus3CleanupPolicy{Name: "old", Format: "maven2",
+			CriteriaLastBlobUpdated: 90}, time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 800 || len(ran) != 2 || ran[0] != "cleanup" {
+		t.Fatalf("Expected cleanup and compact reclaiming 800 bytes but "+
+			"got %v, %d\n", ran, n)
+	}
+	if policy.CriteriaLastBlobUpdated != 90 {
+		t.Fatalf("Expected created policy but got %+v\n", policy)
+	}
+	want := []interface{}{"old"}
+	got := config["cleanup"].(map[string]interface{})["policyNames"]
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("Expected %v but got %v\n", want, got)
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/jhinrichsen/nexus-fetch/nexus"
+)
+
+// The coordinates and the client are those of the nexus package, the
+// command line tool only adds flags and output around them.
+type (
+	NexusInstance   = nexus.NexusInstance
+	NexusRepository = nexus.NexusRepository
+	Gav             = nexus.Gav
+	Fqa             = nexus.Fqa
+	Client          = nexus.Client
+	Option          = nexus.Option
+	StatusError     = nexus.StatusError
+)
+
+// httpClient is used for all requests that do not go through a Client.
+// Tests and embedding applications may replace it.
+var httpClient = &http.Client{
+	Transport: limitedTransport{authTransport{sessionTransport{
+		hostTransport{signingTransport{
+			tracingTransport{baseTransport}}},
+	}}},
+}
+
+// newClient returns a client for repo that shares the HTTP client, the
+// server type and the search cache of the command line tool.
+func newClient(repo NexusRepository, opts ...Option) *Client {
+	return nexus.NewClient(repo, append([]Option{
+		nexus.WithHTTPClient(httpClient),
+		nexus.WithServer(serverType),
+		nexus.WithSearchFunc(srCache.do),
+		nexus.WithDefaultPackaging(defaultPackaging)}, opts...)...)
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestSearchCache(t *testing.T) {
+	defer func(c *searchCache) { srCache = c }(srCache)
+	srCache = &searchCache{dir: t.TempDir(), ttl: time.Minute}
+	requests := 0
+	inst := fakeNexus(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprint(w, "<searchNGResponse><totalCount>0</totalCount>"+
+			"</searchNGResponse>")
+	})
+	for i := 0; i < 2; i++ {
+		if _, err := searchAll([]NexusRepository{{NexusInstance: inst,
+			RepositoryID: ""}},
+			Gav{Group: "g"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if requests != 1 {
+		t.Fatalf("Expected %d but got %d\n", 1, requests)
+	}
+	srCache.ttl = 0
+	searchAll([]NexusRepository{{NexusInstance: inst}},
+		Gav{Group: "g"})
+	if requests != 2 {
+		t.Fatalf("Expected %d but got %d\n", 2, requests)
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// commands maps subcommand names to their entry points, which receive the
+// remaining command line arguments. Without a known subcommand, nexus-fetch
+// searches and fetches.
+var commands map[string]func(args []string)
+
+// init breaks the initialization cycle between commands and commandNames.
+func init() {
+	commands = map[string]func(args []string){
+		"__complete":    completeCommand,
+		"admin":         adminCommand,
+		"again":         againCommand,
+		"asset":         assetCommand,
+		"browse":        browseCommand,
+		"completion":    completionCommand,
+		"component":     componentCommand,
+		"delete-path":   deletePathCommand,
+		"from-gradle":   fromGradleCommand,
+		"from-pom":      fromPomCommand,
+		"history":       historyCommand,
+		"import-bundle": importBundleCommand,
+		"export":        exportCommand,
+		"export-bundle": exportBundleCommand,
+		"info":          infoCommand,
+		"inspect":       inspectCommand,
+		"ls":            lsCommand,
+		"move":          moveCommand,
+		"p2":            p2Command,
+		"ping":          pingCommand,
+		"prune":         pruneCommand,
+		"referenced-by": referencedByCommand,
+		"relocate":      relocateCommand,
+		"serve":         serveCommand,
+		"serve-repo":    serveRepoCommand,
+		"tag":           tagCommand,
+		"tree":          treeCommand,
+		"tree-deps":     treeDepsCommand,
+		"verify-tree":   verifyTreeCommand,
+		"watch":         watchCommand,
+		"where":         whereCommand,
+	}
+}
+
+func commandNames() string {
+	var names []string
+	for name := range commands {
+		// hide internal commands
+		if !strings.HasPrefix(name, "__") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// nexusFlags holds the Nexus coordinates shared by all subcommands.
+type nexusFlags struct {
+	protocol    *string
+	server      *string
+	port        *string
+	contextroot *string
+	username    *string
+	password    *string
+	repository  *repositoryIDs
+	basePath    *string
+}
+
+func newNexusFlags(fs *flag.FlagSet) *nexusFlags {
+	transportFlags(fs)
+	traceFlags(fs)
+	fs.Var(&serverType, "server-type", "Repository manager API: auto, "+
+		"nexus2, nexus3 or artifactory")
+	fs.BoolVar(&strict, "strict", false, "Fail on any warning, e.g. "+
+		"duplicate results, missing checksums or inconsistent metadata")
+	fs.Var(&checksums, "checksum", "Verify downloads against these "+
+		"checksums, comma separated from md5, sha1, sha256 and sha512, "+
+		"default sha1 with -strict")
+	fs.Var(fipsFlag{}, "fips", "Decide about integrity with FIPS "+
+		"approved checksums only, MD5 and SHA-1 remain metadata")
+	fs.StringVar(&defaultPackaging, "default-packaging", defaultPackaging,
+		"Packaging of coordinates without one, by default searches "+
+			"match any packaging and downloads get a jar")
+	newProfile(fs)
+	return &nexusFlags{
+		protocol: fs.String("protocol", "http", "Nexus protocol"),
+		server: fs.String("server", defaultServer,
+			"Nexus server name"),
+		port: fs.String("port", defaultPort, "Nexus port"),
+		contextroot: fs.String("contextroot", "nexus/",
+			"Nexus context root, empty if Nexus runs at /"),
+		username: fs.String("username", defaultUsername,
+			"Nexus user"),
+		password: fs.String("password", defaultPassword,
+			"Nexus password"),
+		repository: newRepositoryIDs(fs),
+		basePath: fs.String("base-path", "", "Path of a reverse proxy "+
+			"in front of the context root, e.g. /tools"),
+	}
+}
+
+// repositoryIDs is a flag value collecting repository IDs, either comma
+// separated or by repeating the flag. The first use replaces the default.
+type repositoryIDs struct {
+	ids []string
+	set bool
+}
+
+func newRepositoryIDs(fs *flag.FlagSet) *repositoryIDs {
+	a := &repositoryIDs{ids: []string{defaultRepository}}
+	fs.Var(a, "repository", "Nexus repository IDs, comma separated or "+
+		"repeated, empty for global search")
+	return a
+}
+
+func (a *repositoryIDs) String() string {
+	return strings.Join(a.ids, ",")
+}
+
+func (a *repositoryIDs) Set(s string) error {
+	if !a.set {
+		a.ids = nil
+		a.set = true
+	}
+	for _, id := range strings.Split(s, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			a.ids = append(a.ids, id)
+		}
+	}
+	return nil
+}
+
+func (a *nexusFlags) instance() NexusInstance {
+	inst := NexusInstance{Protocol: *a.protocol, Server: *a.server,
+		Port: *a.port, Contextroot: *a.contextroot,
+		Username: *a.username, Password: *a.password, BasePath: *a.basePath}
+	if anonymous {
+		inst.Username, inst.Password = "", ""
+	}
+	rememberCredentials(inst)
+	resolveServerType(inst)
+	return inst
+}
+
+// repo returns the first repository, or none for a global search.
+func (a *nexusFlags) repo() NexusRepository {
+	var id string
+	if len(a.repository.ids) > 0 {
+		id = a.repository.ids[0]
+	}
+	return NexusRepository{NexusInstance: a.instance(), RepositoryID: id}
+}
+
+// repos returns all repositories, or none for a global search.
+func (a *nexusFlags) repos() []NexusRepository {
+	var rs []NexusRepository
+	for _, id := range a.repository.ids {
+		rs = append(rs, NexusRepository{NexusInstance: a.instance(),
+			RepositoryID: id})
+	}
+	return rs
+}
+
+// newCommand returns a flag set for a subcommand that exits with 2 on
+// wrong usage, just like the main command.
+func newCommand(name, synopsis string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s %s %s\n",
+			os.Args[0], name, synopsis)
+		fs.PrintDefaults()
+		exit(2)
+	}
+	return fs
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"flag"
+	"reflect"
+	"testing"
+)
+
+func TestRepositoryIDs(t *testing.T) {
+	for _, tt := range []struct {
+		args []string
+		want []string
+	}{
+		{nil, []string{defaultRepository}},
+		{[]string{"-repository", ""}, nil},
+		{[]string{"-repository", "a,b"}, []string{"a", "b"}},
+		{[]string{"-repository", "a", "-repository", "b, c"},
+			[]string{"a", "b", "c"}},
+	} {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		nf := newNexusFlags(fs)
+		if err := fs.Parse(tt.args); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(tt.want, nf.repository.ids) {
+			t.Fatalf("%q: expected %v but got %v\n",
+				tt.args, tt.want, nf.repository.ids)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Completion scripts call back into nexus-fetch via the hidden __complete
+// command for dynamic values. PROG is replaced by the program name.
+var completionScripts = map[string]string{
+	"bash": `_PROG_complete() {
+    local cur prev words
+    cur="${COMP_WORDS[COMP_CWORD]}"
+    prev="${COMP_WORDS[COMP_CWORD-1]}"
+    if [[ "$prev" == "-repository" ]]; then
+        words=$(PROG __complete repositories "${COMP_WORDS[@]:1:COMP_CWORD-1}" 2>/dev/null)
+    elif [[ $COMP_CWORD -eq 1 ]]; then
+        words="$(PROG __complete commands) $(PROG __complete coordinates)"
+    else
+        words=$(PROG __complete coordinates)
+    fi
+    COMPREPLY=($(compgen -W "$words" -- "$cur"))
+}
+complete -F _PROG_complete PROG
+`,
+	"zsh": `#compdef PROG
+_PROG_complete() {
+    local -a reply
+    if [[ "${words[CURRENT-1]}" == "-repository" ]]; then
+        reply=(${(f)"$(PROG __complete repositories ${words[2,CURRENT-1]} 2>/dev/null)"})
+    elif (( CURRENT == 2 )); then
+        reply=(${(f)"$(PROG __complete commands)"} ${(f)"$(PROG __complete coordinates)"})
+    else
+        reply=(${(f)"$(PROG __complete coordinates)"})
+    fi
+    compadd -a reply
+}
+compdef _PROG_complete PROG
+`,
+	"fish": `function __PROG_repositories
+    PROG __complete repositories (commandline -opc)[2..-1] 2>/dev/null
+end
+complete -c PROG -o repository -x -a '(__PROG_repositories)'
+complete -c PROG -n '__fish_is_first_arg' -f -a '(PROG __complete commands)'
+complete -c PROG -f -a '(PROG __complete coordinates)'
+`,
+}
+
+func completionCommand(args []string) {
+	var shells []string
+	for shell := range completionScripts {
+		shells = append(shells, shell)
+	}
+	sort.Strings(shells)
+	fs := newCommand("completion", strings.Join(shells, "|"))
+	fs.Parse(args)
+	script, ok := completionScripts[fs.Arg(0)]
+	if fs.NArg() != 1 || !ok {
+		fs.Usage()
+	}
+	prog := filepath.Base(os.Args[0])
+	// shell function names must not contain dashes
+	fn := strings.Replace(prog, "-", "_", -1)
+	script = strings.Replace(script, "_PROG_", "_"+fn+"_", -1)
+	fmt.Print(strings.Replace(script, "PROG", prog, -1))
+}
+
+// completeCommand prints dynamic completion candidates, one per line.
+// Any Nexus coordinates present on the command line being completed are
+// honored when listing repositories.
+func completeCommand(args []string) {
+	if len(args) == 0 {
+		exit(2)
+	}
+	switch args[0] {
+	case "commands":
+		for _, name := range strings.Split(commandNames(), ", ") {
+			fmt.Println(name)
+		}
+	case "coordinates":
+		for _, c := range recent() {
+			fmt.Println(c)
+		}
+	case "repositories":
+		log.SetOutput(io.Discard)
+		fs := newCommand("__complete", "")
+		nf := newNexusFlags(fs)
+		fs.Parse(nexusArgs(args[1:]))
+		rs, err := repositories(nf.instance())
+		if err != nil {
+			exit(1)
+		}
+		for _, r := range rs {
+			fmt.Println(r.ID)
+		}
+	}
+}
+
+// nexusArgs picks Nexus instance flags and their values from a partial
+// command line, skipping everything else. known maps flag names to whether
+// they take a value.
+func nexusArgs(args []string) []string {
+	known := map[string]bool{"protocol": true, "server": true,
+		"port": true, "contextroot": true, "username": true,
+		"password": true, "base-path": true, "resolve": true,
+		"host-header": true, "server-type": true,
+		"sign-hmac": true, "no-session": false, "anonymous": false,
+		"strict": false, "profile": true, "checksum": true,
+		"fips": false}
+	var as []string
+	for i := 0; i < len(args); i++ {
+		name := strings.TrimLeft(args[i], "-")
+		if !strings.HasPrefix(args[i], "-") {
+			continue
+		}
+		if j := strings.Index(name, "="); j >= 0 {
+			if _, ok := known[name[:j]]; ok {
+				as = append(as, args[i])
+			}
+			continue
+		}
+		if value, ok := known[name]; ok && !value {
+			as = append(as, args[i])
+		} else if value && i+1 < len(args) {
+			as = append(as, args[i], args[i+1])
+			i++
+		}
+	}
+	return as
+}
@@ -0,0 +1,16 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNexusArgs(t *testing.T) {
+	args := []string{"-server", "nexus", "-fetch=false", "-port=8082",
+		"-anonymous", "g:a", "-outputDir", "/tmp", "-repository"}
+	want := []string{"-server", "nexus", "-port=8082", "-anonymous"}
+	got := nexusArgs(args)
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("Expected %v but got %v\n", want, got)
+	}
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/jhinrichsen/nexus-fetch/nexus"
+)
+
+// setting is a flag name and value from the configuration file.
+type setting struct {
+	name, value string
+}
+
+// config is the user's configuration file. Profiles are sections like
+//
+//	[distributions]
+//	default-packaging = tar.gz
+//	default-classifier = linux-x86_64
+//
+// whose settings are flag defaults, selected with -profile. Aliases like
+//
+//	app => com.acme:application:@tar.gz
+//
+// abbreviate coordinates in concise notation, anywhere in the file. The
+// section [exit-codes] maps exit codes, by number or name:
+//
+//	[exit-codes]
+//	not-found = 0
+type config struct {
+	profiles  map[string][]setting
+	aliases   map[string]alias
+	exitCodes map[int]int
+}
+
+// alias holds the coordinates an alias expands to, without trailing empty
+// segments.
+type alias struct {
+	segments  []string
+	packaging *string
+}
+
+// parseAlias parses the definition of an alias.
+func parseAlias(name, value string) (alias, error) {
+	if err := nexus.CheckCoordinate(name); err != nil {
+		return alias{}, fmt.Errorf("alias %q %v", name, err)
+	}
+	segments, packaging, err := nexus.SplitConcise(value)
+	if err != nil {
+		return alias{}, err
+	}
+	for len(segments) > 0 && segments[len(segments)-1] == "" {
+		segments = segments[:len(segments)-1]
+	}
+	return alias{segments, packaging}, nil
+}
+
+// expand replaces the alias in the first of segments. More segments
+// follow those of the alias, packaging overrides the alias' packaging.
+func (a alias) expand(segments []string, packaging *string) ([]string,
+	*string) {
+	ss := append(append([]string(nil), a.segments...), segments[1:]...)
+	if packaging == nil {
+		packaging = a.packaging
+	}
+	return ss, packaging
+}
+
+var (
+	userConfigOnce sync.Once
+	userConfig     config
+	userConfigErr  error
+)
+
+// loadUserConfig reads the configuration file once.
+func loadUserConfig() (config, error) {
+	userConfigOnce.Do(func() {
+		userConfig, userConfigErr = loadConfig(configFile())
+	})
+	return userConfig, userConfigErr
+}
+
+// lookupAlias returns the alias name from the configuration file.
+func lookupAlias(name string) (alias, bool, error) {
+	cfg, err := loadUserConfig()
+	a, ok := cfg.aliases[name]
+	return a, ok, err
+}
+
+// defaultPackaging applies to coordinates without packaging, see
+// -default-packaging.
+var defaultPackaging string
+
+// parseConcise parses a coordinate in concise notation, see
+// nexus.ParseConcise. A group naming an alias of the configuration file is
+// replaced by the alias' coordinates, a missing packaging by
+// defaultPackaging.
+func parseConcise(c string) (Gav, error) {
+	gav, err := nexus.ParseConciseFunc(c, func(segments []string,
+		packaging *string) ([]string, *string, error) {
+		a, ok, err := lookupAlias(segments[0])
+		if err != nil || !ok {
+			return segments, packaging, err
+		}
+		segments, packaging = a.expand(segments, packaging)
+		return segments, packaging, nil
+	})
+	return gav.WithPackaging(defaultPackaging), err
+}
+
+// configFile returns the location of the configuration file,
+// $NEXUS_FETCH_CONFIG or nexus-fetch/config in the user's configuration
+// directory.
+func configFile() string {
+	if fn := os.Getenv("NEXUS_FETCH_CONFIG"); fn != "" {
+		return fn
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "nexus-fetch", "config")
+}
+
+// loadConfig reads the configuration file fn, a missing file is an empty
+// configuration.
+func loadConfig(fn string) (config, error) {
+	cfg := config{profiles: make(map[string][]setting),
+		aliases: make(map[string]alias), exitCodes: make(map[int]int)}
+	f, err := os.Open(fn)
+	if os.IsNotExist(err) || fn == "" {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
+	}
+	defer f.Close()
+	profile := ""
+	sc := bufio.NewScanner(f)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+			continue
+		case strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]"):
+			profile = strings.TrimSpace(line[1 : len(line)-1])
+			if profile == "" {
+				return cfg, fmt.Errorf("%s:%d: empty profile name", fn, n)
+			}
+			// a profile may be empty, it still exists
+			if _, ok := cfg.profiles[profile]; !ok &&
+				profile != "exit-codes" {
+				cfg.profiles[profile] = nil
+			}
+			continue
+		}
+		if i := strings.Index(line, "=>"); i >= 0 {
+			name := strings.TrimSpace(line[:i])
+			a, err := parseAlias(name, strings.TrimSpace(line[i+2:]))
+			if err != nil {
+				return cfg, fmt.Errorf("%s:%d: %v", fn, n, err)
+			}
+			cfg.aliases[name] = a
+			continue
+		}
+		i := strings.Index(line, "=")
+		if i < 0 {
+			return cfg, fmt.Errorf("%s:%d: expected name = value: %q", fn,
+				n, line)
+		}
+		if profile == "" {
+			return cfg, fmt.Errorf("%s:%d: %q outside of a [profile]", fn,
+				n, line)
+		}
+		s := setting{strings.TrimSpace(strings.TrimPrefix(line[:i], "-")),
+			strings.TrimSpace(line[i+1:])}
+		if profile == "exit-codes" {
+			from, err1 := parseExitCode(s.name)
+			to, err2 := parseExitCode(s.value)
+			if err1 != nil || err2 != nil {
+				return cfg, fmt.Errorf("%s:%d: expected exit code = exit "+
+					"code: %q", fn, n, line)
+			}
+			cfg.exitCodes[from] = to
+			continue
+		}
+		cfg.profiles[profile] = append(cfg.profiles[profile], s)
+	}
+	return cfg, sc.Err()
+}
+
+// profile is a flag value applying the settings of a configuration profile
+// to the flags of a command. Flags given on the command line win,
+// regardless of their position; repeatable flags like -repository add to
+// the profile's value if given after -profile.
+type profile struct {
+	fs   *flag.FlagSet
+	name string
+}
+
+func newProfile(fs *flag.FlagSet) *profile {
+	a := &profile{fs: fs}
+	fs.Var(a, "profile", "Use flag defaults of this profile in "+
+		configFile())
+	return a
+}
+
+func (a *profile) String() string {
+	if a == nil {
+		return ""
+	}
+	return a.name
+}
+
+func (a *profile) Set(name string) error {
+	cfg, err := loadConfig(configFile())
+	if err != nil {
+		return err
+	}
+	settings, ok := cfg.profiles[name]
+	if !ok {
+		return fmt.Errorf("no profile %q in %s", name, configFile())
+	}
+	a.name = name
+	given := make(map[string]bool)
+	a.fs.Visit(func(f *flag.Flag) {
+		given[f.Name] = true
+	})
+	for _, s := range settings {
+		// profiles are shared by commands, not all have all flags
+		if given[s.name] || a.fs.Lookup(s.name) == nil {
+			continue
+		}
+		if err := a.fs.Set(s.name, s.value); err != nil {
+			return fmt.Errorf("profile %s: -%s: %v", name, s.name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestProfile(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "config")
+	cfg := "# team defaults\n" +
+		"[distributions]\n" +
+		"default-packaging = tar.gz\n" +
+		"-classifier = linux-x86_64\n" +
+		"repository = dists\n" +
+		"unknown-to-this-command = 1\n" +
+		"[empty]\n"
+	if err := os.WriteFile(fn, []byte(cfg), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("NEXUS_FETCH_CONFIG", fn)
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	packaging := fs.String("default-packaging", "jar", "")
+	classifier := fs.String("classifier", "", "")
+	repository := fs.String("repository", "releases", "")
+	newProfile(fs)
+	if err := fs.Parse([]string{"-repository", "other", "-profile",
+		"distributions"}); err != nil {
+		t.Fatal(err)
+	}
+	for want, got := range map[string]string{
+		"tar.gz":       *packaging,
+		"linux-x86_64": *classifier,
+		"other":        *repository,
+	} {
+		if want != got {
+			t.Fatalf("Expected %s but got %s\n", want, got)
+		}
+	}
+
+	fs.SetOutput(ioutil.Discard)
+	if err := fs.Parse([]string{"-profile", "empty"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.Parse([]string{"-profile", "missing"}); err == nil {
+		t.Fatalf("Expected an error for an unknown profile\n")
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	dir := t.TempDir()
+	for _, cfg := range []string{"default-packaging = zip\n",
+		"[p]\nno value\n", "[]\n"} {
+		fn := filepath.Join(dir, "config")
+		if err := os.WriteFile(fn, []byte(cfg), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := loadConfig(fn); err == nil {
+			t.Fatalf("Expected an error for %q\n", cfg)
+		}
+	}
+	if _, err := loadConfig(filepath.Join(dir, "missing")); err != nil {
+		t.Fatalf("Expected an empty configuration but got %v\n", err)
+	}
+}
+
+func TestAliases(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "config")
+	cfg := "app => com.acme:application:@tar.gz\n" +
+		"[p]\n" +
+		"tool=>com.acme:tool:1.0\n"
+	if err := os.WriteFile(fn, []byte(cfg), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c, err := loadConfig(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	userConfigOnce.Do(func() {})
+	defer func(c config) { userConfig = c }(userConfig)
+	userConfig = c
+
+	for in, want := range map[string]string{
+		"app:2.3.1":              "com.acme:application:2.3.1@tar.gz",
+		"app:2.3.1:linux@zip":    "com.acme:application:2.3.1:linux@zip",
+		"tool":                   "com.acme:tool:1.0",
+		"com.acme:application:1": "com.acme:application:1",
+	} {
+		gav, err := parseConcise(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := gav.ConciseNotation(); want != got {
+			t.Fatalf("Expected %s but got %s\n", want, got)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// errNotConfirmed is returned if the user declines a destructive operation.
+var errNotConfirmed = errors.New("not confirmed, use -yes to skip " +
+	"confirmation")
+
+// confirmation guards operations that delete or overwrite items in a
+// repository.
+type confirmation struct {
+	yes       *bool
+	above     *int
+	maxDelete *int
+	in        io.Reader
+	out       io.Writer
+}
+
+func newConfirmation(fs *flag.FlagSet) *confirmation {
+	a := &confirmation{
+		yes: fs.Bool("yes", false, "Do not ask for confirmation"),
+		above: fs.Int("confirm-above", 0, "Ask for confirmation if "+
+			"more than this many items are affected, 0 always asks"),
+		maxDelete: fs.Int("max-delete", 100, "Never delete or "+
+			"overwrite more than this many items, 0 for no limit"),
+		in:  os.Stdin,
+		out: os.Stderr,
+	}
+	fs.BoolVar(a.yes, "force", false, "Same as -yes")
+	return a
+}
+
+// confirm lists the affected items and asks the user unless -yes is set.
+// Exceeding -max-delete is an error even with -yes.
+func (a *confirmation) confirm(verb string, items []string) error {
+	if *a.maxDelete > 0 && len(items) > *a.maxDelete {
+		return fmt.Errorf("refusing to %s %d items, more than "+
+			"-max-delete %d", verb, len(items), *a.maxDelete)
+	}
+	if *a.yes || len(items) <= *a.above {
+		return nil
+	}
+	for _, item := range items {
+		fmt.Fprintf(a.out, "  %s\n", item)
+	}
+	fmt.Fprintf(a.out, "%s %d items? [y/N] ", verb, len(items))
+	answer, _ := bufio.NewReader(a.in).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return nil
+	}
+	return errNotConfirmed
+}
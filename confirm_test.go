@@ -0,0 +1,43 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestConfirm(t *testing.T) {
+	items := []string{"g:a:1.0", "g:a:1.1", "g:a:1.2"}
+	for _, tt := range []struct {
+		args   []string
+		items  int
+		answer string
+		ok     bool
+	}{
+		// a single item asks, too
+		{nil, 1, "", false},
+		{[]string{"-confirm-above", "1"}, 1, "", true},
+		{nil, 3, "y\n", true},
+		{nil, 3, "yes\n", true},
+		{nil, 3, "n\n", false},
+		// no terminal
+		{nil, 3, "", false},
+		{[]string{"-yes"}, 3, "", true},
+		{[]string{"-force"}, 3, "", true},
+		{[]string{"-confirm-above", "3"}, 3, "", true},
+		{[]string{"-yes", "-max-delete", "2"}, 3, "", false},
+	} {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		c := newConfirmation(fs)
+		if err := fs.Parse(tt.args); err != nil {
+			t.Fatal(err)
+		}
+		c.in = strings.NewReader(tt.answer)
+		c.out = ioutil.Discard
+		if err := c.confirm("delete", items[:tt.items]); (err == nil) != tt.ok {
+			t.Fatalf("%v %q: expected ok=%t but got %v\n", tt.args,
+				tt.answer, tt.ok, err)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/jhinrichsen/nexus-fetch/nexus"
+)
+
+// ContentItem is an entry of a repository directory listing.
+type ContentItem struct {
+	Name         string `xml:"text"`
+	RelativePath string `xml:"relativePath"`
+	Leaf         bool   `xml:"leaf"`
+	LastModified string `xml:"lastModified"`
+	Size         int64  `xml:"sizeOnDisk"`
+}
+
+type contentResponse struct {
+	Items []ContentItem `xml:"data>content-item"`
+}
+
+// ContentListURL returns the URL of the content service listing the
+// repository directory dir.
+func ContentListURL(repo NexusRepository, dir string) string {
+	dir = strings.Trim(dir, "/")
+	if dir != "" {
+		dir += "/"
+	}
+	return nexus.BaseURL(repo).String() + fmt.Sprintf(
+		"service/local/repositories/%s/content/%s", repo.RepositoryID, dir)
+}
+
+// listContent returns the entries of a repository directory.
+func listContent(repo NexusRepository, dir string) ([]ContentItem, error) {
+	if err := unsupported("directory listing"); err != nil {
+		return nil, err
+	}
+	u := ContentListURL(repo, dir)
+	res, err := httpClient.Get(u)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, &StatusError{URL: u, StatusCode: res.StatusCode}
+	}
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	var cr contentResponse
+	err = xml.Unmarshal(body, &cr)
+	return cr.Items, err
+}
+
+// RepositoryFileURL returns the download URL of a file in a repository.
+func RepositoryFileURL(repo NexusRepository, path string) string {
+	return nexus.BaseURL(repo).String() + repositoryPrefix(repo.RepositoryID) +
+		strings.TrimLeft(path, "/")
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"archive/zip"
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"strings"
+)
+
+// sameVersion accepts a timestamped snapshot for X-SNAPSHOT.
+func sameVersion(want, got string) bool {
+	if want == got {
+		return true
+	}
+	if !strings.HasSuffix(want, "-SNAPSHOT") {
+		return false
+	}
+	base := strings.TrimSuffix(want, "SNAPSHOT")
+	return strings.HasPrefix(got, base) &&
+		timestampedVersion.MatchString(strings.TrimPrefix(got, base))
+}
+
+// mismatchedCoordinates compares declared coordinates to the requested
+// ones, ignoring what was not requested.
+func mismatchedCoordinates(want, got Gav) error {
+	var diffs []string
+	if want.Group != "" && got.Group != want.Group {
+		diffs = append(diffs, "groupId "+got.Group)
+	}
+	if want.Artifact != "" && got.Artifact != want.Artifact {
+		diffs = append(diffs, "artifactId "+got.Artifact)
+	}
+	if want.Version != "" && !sameVersion(want.Version, got.Version) {
+		diffs = append(diffs, "version "+got.Version)
+	}
+	if len(diffs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("requested %s but found %s", want.ConciseNotation(),
+		strings.Join(diffs, ", "))
+}
+
+// pomCoordinates reads the coordinates a POM declares, inheriting group
+// and version from the parent element.
+func pomCoordinates(filename string) (Gav, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return Gav{}, err
+	}
+	defer f.Close()
+	p, err := parsePom(f)
+	if err != nil {
+		return Gav{}, err
+	}
+	gav := Gav{Group: p.GroupID, Artifact: p.ArtifactID, Version: p.Version}
+	if gav.Group == "" {
+		gav.Group = p.Parent.GroupID
+	}
+	if gav.Version == "" {
+		gav.Version = p.Parent.Version
+	}
+	return gav, nil
+}
+
+// jarCoordinates reads the pom.properties Maven embeds into archives. Its
+// path names group and artifact, shaded archives carry one per included
+// artifact. ok is false if the archive has no usable pom.properties.
+func jarCoordinates(filename string, want Gav) (gav Gav, ok bool,
+	err error) {
+	r, err := zip.OpenReader(filename)
+	if err != nil {
+		return Gav{}, false, err
+	}
+	defer r.Close()
+	var props []*zip.File
+	exact := path.Join("META-INF/maven", want.Group, want.Artifact,
+		"pom.properties")
+	for _, f := range r.File {
+		if f.Name == exact {
+			props = []*zip.File{f}
+			break
+		}
+		if strings.HasPrefix(f.Name, "META-INF/maven/") &&
+			strings.HasSuffix(f.Name, "/pom.properties") {
+			props = append(props, f)
+		}
+	}
+	// which of several belongs to the archive is unknown
+	if len(props) != 1 {
+		return Gav{}, false, nil
+	}
+	rc, err := props[0].Open()
+	if err != nil {
+		return Gav{}, false, err
+	}
+	defer rc.Close()
+	m, err := readProperties(rc)
+	if err != nil {
+		return Gav{}, false, err
+	}
+	return Gav{Group: m["groupId"], Artifact: m["artifactId"],
+		Version: m["version"]}, true, nil
+}
+
+// readProperties parses the simple key=value files Maven writes.
+func readProperties(r io.Reader) (map[string]string, error) {
+	m := make(map[string]string)
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || line[0] == '#' || line[0] == '!' {
+			continue
+		}
+		if i := strings.IndexAny(line, "=:"); i > 0 {
+			m[strings.TrimSpace(line[:i])] = strings.TrimSpace(line[i+1:])
+		}
+	}
+	return m, sc.Err()
+}
+
+// checkCoordinates optionally verifies that a downloaded POM or archive
+// declares the coordinates it was requested by.
+func checkCoordinates(f string, gav Gav, enabled bool) error {
+	if !enabled {
+		return nil
+	}
+	var got Gav
+	var err error
+	switch {
+	case strings.HasSuffix(f, ".pom"):
+		got, err = pomCoordinates(f)
+	case isArchive(f):
+		var ok bool
+		got, ok, err = jarCoordinates(f, gav)
+		if err == nil && !ok {
+			log.Printf("%s: no pom.properties to validate\n", f)
+			return nil
+		}
+	default:
+		return nil
+	}
+	if err == nil {
+		err = mismatchedCoordinates(gav, got)
+	}
+	if err != nil {
+		return &integrityError{fmt.Errorf("%s: %v", f, err)}
+	}
+	return nil
+}
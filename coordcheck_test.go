@@ -0,0 +1,66 @@
+package main
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeZip(t *testing.T, filename string, entries map[string]string) {
+	f, err := os.Create(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := zip.NewWriter(f)
+	for name, content := range entries {
+		e, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		e.Write([]byte(content))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheckCoordinates(t *testing.T) {
+	dir := t.TempDir()
+	gav := Gav{Group: "com.acme", Artifact: "app", Version: "1.0-SNAPSHOT"}
+
+	jar := filepath.Join(dir, "app.jar")
+	writeZip(t, jar, map[string]string{
+		"META-INF/maven/com.acme/app/pom.properties": "#generated\n" +
+			"groupId=com.acme\nartifactId=app\nversion=1.0-SNAPSHOT\n",
+		"META-INF/maven/org.dep/dep/pom.properties": "groupId=org.dep\n" +
+			"artifactId=dep\nversion=2\n",
+	})
+	if err := checkCoordinates(jar, gav, true); err != nil {
+		t.Fatal(err)
+	}
+
+	wrong := filepath.Join(dir, "wrong.jar")
+	writeZip(t, wrong, map[string]string{
+		"META-INF/maven/com.other/app/pom.properties": "groupId=com.other\n" +
+			"artifactId=app\nversion=1.0-SNAPSHOT\n",
+	})
+	if err := checkCoordinates(wrong, gav, true); exitCode(err) !=
+		exitIntegrity {
+		t.Fatalf("Expected integrity error but got %v\n", err)
+	}
+
+	pom := filepath.Join(dir, "app.pom")
+	err := os.WriteFile(pom, []byte(`<project><parent><groupId>com.acme`+
+		`</groupId><version>1.0-20240101.120000-3</version></parent>`+
+		`<artifactId>app</artifactId></project>`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := checkCoordinates(pom, gav, true); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path"
+	"sort"
+	"strings"
+	"syscall"
+)
+
+// splitPath returns the segments of a repository path.
+func splitPath(p string) []string {
+	var ss []string
+	for _, s := range strings.Split(p, "/") {
+		if s != "" {
+			ss = append(ss, s)
+		}
+	}
+	return ss
+}
+
+// matchSegments matches path segments ss against pattern segments ps as
+// path.Match does, with ** matching any number of segments. With prefix,
+// ss only needs to be the beginning of a matching path.
+func matchSegments(ps, ss []string, prefix bool) bool {
+	if len(ps) > 0 && ps[0] == "**" {
+		return matchSegments(ps[1:], ss, prefix) ||
+			len(ss) > 0 && matchSegments(ps, ss[1:], prefix)
+	}
+	if len(ss) == 0 {
+		return prefix || len(ps) == 0
+	}
+	if len(ps) == 0 {
+		return false
+	}
+	ok, err := path.Match(ps[0], ss[0])
+	return err == nil && ok && matchSegments(ps[1:], ss[1:], prefix)
+}
+
+// matchingPaths walks the content listing of repo and returns all paths
+// matching pattern. Matching directories are returned as a whole with a
+// trailing slash and not descended into.
+func matchingPaths(repo NexusRepository, pattern string) ([]string,
+	error) {
+	ps := splitPath(pattern)
+	for _, p := range ps {
+		if _, err := path.Match(p, ""); err != nil {
+			return nil, fmt.Errorf("bad pattern %q: %v", pattern, err)
+		}
+	}
+	// start listing below the part without wildcards, matches are
+	// entries of the start directory or below
+	var start []string
+	for _, p := range ps {
+		if strings.ContainsAny(p, `*?[\`) {
+			break
+		}
+		start = append(start, p)
+	}
+	if len(start) > 0 && (len(start) == len(ps) || ps[len(start)] == "**") {
+		start = start[:len(start)-1]
+	}
+	var ms []string
+	var walk func(dir string) error
+	walk = func(dir string) error {
+		items, err := listContent(repo, dir)
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			ss := splitPath(item.RelativePath)
+			switch {
+			case matchSegments(ps, ss, false):
+				p := strings.Join(ss, "/")
+				if !item.Leaf {
+					p += "/"
+				}
+				ms = append(ms, p)
+			case !item.Leaf && matchSegments(ps, ss, true):
+				if err := walk(strings.Join(ss, "/")); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	if err := walk(strings.Join(start, "/")); err != nil {
+		return nil, err
+	}
+	sort.Strings(ms)
+	return ms, nil
+}
+
+func deletePathCommand(args []string) {
+	fs := newCommand("delete-path", "<pattern>...")
+	nf := newNexusFlags(fs)
+	confirm := newConfirmation(fs)
+	audit := newAuditLog(fs)
+	protected := newProtection(fs)
+	dryRun := fs.Bool("dry-run", false,
+		"Print matching paths without deleting them")
+	fs.Parse(args)
+	if fs.NArg() == 0 || nf.repo().RepositoryID == "" {
+		fs.Usage()
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt,
+		syscall.SIGTERM)
+	defer stop()
+	repo := nf.repo()
+	var ps []string
+	for _, pattern := range fs.Args() {
+		if len(splitPath(pattern)) == 0 {
+			log.Println("refusing to delete the whole repository")
+			exit(exitUsage)
+		}
+		ms, err := matchingPaths(repo, pattern)
+		if err != nil {
+			fail(err)
+		}
+		ps = append(ps, ms...)
+	}
+	ps, err := protected.filterPaths(repo, ps)
+	if err != nil {
+		fail(err)
+	}
+	if *dryRun {
+		for _, p := range ps {
+			fmt.Println(p)
+		}
+		return
+	}
+	if err := confirm.confirm("delete", ps); err != nil {
+		fail(err)
+	}
+	var failures []error
+	for _, p := range ps {
+		err := deletePath(ctx, repo, p)
+		if aerr := audit.record(newAuditRecord("delete-path", repo, Gav{},
+			p, err)); aerr != nil {
+			fail(aerr)
+		}
+		if err != nil {
+			log.Printf("%s: %v\n", p, err)
+			failures = append(failures, err)
+		}
+	}
+	exit(summaryExitCode(len(ps), failures))
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"flag"
+	"reflect"
+	"testing"
+
+	"github.com/jhinrichsen/nexus-fetch/nexusfetchtest"
+)
+
+func TestMatchSegments(t *testing.T) {
+	for _, tt := range []struct {
+		pattern, path string
+		prefix, want  bool
+	}{
+		{"com/acme/**", "com/acme", false, true},
+		{"com/acme/**", "com/acme/app/1.0/app-1.0.jar", false, true},
+		{"com/acme/**", "com/other", false, false},
+		{"com/**/*.pom", "com/acme/app/1.0/app-1.0.pom", false, true},
+		{"com/**/*.pom", "com/acme/app/1.0/app-1.0.jar", false, false},
+		{"com/*/app/*", "com/acme", true, true},
+		{"com/*/app/*", "com/acme/lib", true, false},
+	} {
+		got := matchSegments(splitPath(tt.pattern), splitPath(tt.path),
+			tt.prefix)
+		if got != tt.want {
+			t.Fatalf("%s %s: Expected %v but got %v\n", tt.pattern,
+				tt.path, tt.want, got)
+		}
+	}
+}
+
+func TestMatchingPaths(t *testing.T) {
+	jar := func(a, v string) nexusfetchtest.Artifact {
+		return nexusfetchtest.Artifact{Repository: "releases",
+			Group: "com.acme", Artifact: a, Version: v, Extension: "jar",
+			Content: []byte(a)}
+	}
+	_, inst := newFakeNexus(t, jar("old-app", "1.0"), jar("old-app", "2.0"),
+		jar("app", "1.0"))
+	repo := NexusRepository{NexusInstance: inst, RepositoryID: "releases"}
+
+	got, err := matchingPaths(repo, "com/acme/old-app/**")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"com/acme/old-app/"}
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("Expected %v but got %v\n", want, got)
+	}
+
+	got, err = matchingPaths(repo, "com/acme/*/1.0/*.jar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []string{"com/acme/app/1.0/app-1.0.jar",
+		"com/acme/old-app/1.0/old-app-1.0.jar"}
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("Expected %v but got %v\n", want, got)
+	}
+}
+
+func TestFilterProtectedPaths(t *testing.T) {
+	jar := func(a, v string) nexusfetchtest.Artifact {
+		return nexusfetchtest.Artifact{Repository: "releases",
+			Group: "com.acme", Artifact: a, Version: v, Extension: "jar",
+			Content: []byte(a)}
+	}
+	_, inst := newFakeNexus(t, jar("old-app", "1.0"), jar("old-app", "2.0"),
+		jar("app", "1.0"))
+	repo := NexusRepository{NexusInstance: inst, RepositoryID: "releases"}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	p := newProtection(fs)
+	fs.Parse([]string{"-protect", "com.acme:old-app:2.0"})
+
+	got, err := p.filterPaths(repo, []string{"com/acme/old-app/",
+		"com/acme/old-app/1.0/old-app-1.0.jar",
+		"com/acme/old-app/2.0/old-app-2.0.jar",
+		"com/acme/old-app/maven-metadata.xml", "com/acme/app/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"com/acme/old-app/1.0/old-app-1.0.jar",
+		"com/acme/old-app/maven-metadata.xml", "com/acme/app/"}
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("Expected %v but got %v\n", want, got)
+	}
+}
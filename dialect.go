@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/jhinrichsen/nexus-fetch/nexus"
+)
+
+// serverKind selects the API dialect of the repository manager.
+type serverKind = nexus.ServerKind
+
+const (
+	serverAuto        = nexus.Auto
+	serverNexus2      = nexus.Nexus2
+	serverNexus3      = nexus.Nexus3
+	serverArtifactory = nexus.Artifactory
+)
+
+// serverType is detected on first use of the Nexus flags unless set with
+// -server-type. All dialects serve content in Maven layout, the Nexus 2 API
+// is used for everything else.
+var serverType = serverAuto
+
+// detectServer probes the status endpoints of all dialects, servers that
+// cannot be identified are treated as Nexus 2.
+func detectServer(inst NexusInstance) serverKind {
+	ctx, cancel := context.WithTimeout(context.Background(),
+		30*time.Second)
+	defer cancel()
+	base := nexus.BaseURL(NexusRepository{NexusInstance: inst}).String()
+	probe := func(p string) bool {
+		res, err := getAs(ctx, inst, base+p)
+		if err != nil {
+			return false
+		}
+		res.Body.Close()
+		return true
+	}
+	switch {
+	case probe("service/local/status"):
+		return serverNexus2
+	case probe("service/rest/v1/status"):
+		return serverNexus3
+	}
+	res, err := getAs(ctx, inst, base+"api/system/version")
+	if err == nil {
+		defer res.Body.Close()
+		var v struct {
+			Version string `json:"version"`
+		}
+		if json.NewDecoder(res.Body).Decode(&v) == nil && v.Version != "" {
+			return serverArtifactory
+		}
+	}
+	log.Printf("cannot detect server type of %s, assuming nexus2\n", base)
+	return serverNexus2
+}
+
+// resolveServerType replaces auto by the detected dialect.
+func resolveServerType(inst NexusInstance) {
+	if serverType == serverAuto {
+		serverType = detectServer(inst)
+		log.Printf("detected %s\n", serverType)
+	}
+}
+
+// repositoryPrefix returns the path of repository content below the
+// context root.
+func repositoryPrefix(id string) string {
+	return nexus.RepositoryPrefix(serverType, id)
+}
+
+// layoutOnly reports whether the server only serves plain repository paths
+// instead of the Nexus 2 REST API.
+func layoutOnly() bool {
+	return nexus.LayoutOnly(serverType)
+}
+
+var warned sync.Map
+
+// unsupported warns once if feature requires the Nexus 2 API.
+func unsupported(feature string) error {
+	if !layoutOnly() {
+		return nil
+	}
+	if _, loaded := warned.LoadOrStore(feature, true); !loaded {
+		return warn("%s is not supported by %s, trying the Nexus 2 API",
+			feature, serverType)
+	}
+	return nil
+}
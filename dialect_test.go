@@ -0,0 +1,54 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestDetectServer(t *testing.T) {
+	for want, p := range map[serverKind]string{
+		serverNexus2:      "/nexus/service/local/status",
+		serverNexus3:      "/nexus/service/rest/v1/status",
+		serverArtifactory: "/nexus/api/system/version",
+	} {
+		inst := fakeNexus(t, func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != p {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write([]byte(`{"version": "7.0.0"}`))
+		})
+		if got := detectServer(inst); got != want {
+			t.Fatalf("Expected %s but got %s\n", want, got)
+		}
+	}
+}
+
+func TestDialectURLs(t *testing.T) {
+	defer func(k serverKind) { serverType = k }(serverType)
+	inst := NexusInstance{Protocol: "https", Server: "repo"}
+	fqa := Fqa{NexusRepository: NexusRepository{NexusInstance: inst,
+		RepositoryID: "releases"},
+		Gav: Gav{Group: "com.acme", Artifact: "app", Version: "1.0"}}
+	for _, tt := range []struct {
+		kind serverKind
+		want string
+	}{
+		{serverNexus3,
+			"https://repo/repository/releases/com/acme/app/1.0/app-1.0.jar"},
+		{serverArtifactory,
+			"https://repo/releases/com/acme/app/1.0/app-1.0.jar"},
+	} {
+		serverType = tt.kind
+		if got := mavenURL("content", fqa); got != tt.want {
+			t.Fatalf("Expected %s but got %s\n", tt.want, got)
+		}
+	}
+	serverType = serverNexus2
+	want := "https://repo/content/repositories/releases/com/acme/app/" +
+		"maven-metadata.xml"
+	if got := metadataURL(Fqa{NexusRepository: fqa.NexusRepository,
+		Gav: Gav{Group: "com.acme", Artifact: "app"}}); got != want {
+		t.Fatalf("Expected %s but got %s\n", want, got)
+	}
+}
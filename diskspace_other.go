@@ -0,0 +1,8 @@
+//go:build !unix
+
+package main
+
+// freeSpace is not supported on this platform, -1 disables the precheck.
+func freeSpace(dir string) (int64, error) {
+	return -1, nil
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"path/filepath"
+)
+
+// planFetch prints what a fetch of u would do without downloading, using
+// a HEAD request to learn about size and server side filename. A failing
+// request is printed and returned, so that dry runs go on.
+func planFetch(ctx context.Context, u, outputDirectory, userSupplied string,
+	gav Gav) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, u, nil)
+	if err != nil {
+		return err
+	}
+	res, err := httpClient.Do(req)
+	if err != nil {
+		fmt.Printf("would fail: %s: %v\n", u, err)
+		return err
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		log.Printf("%v returns HTTP status code %v\n", u, res.StatusCode)
+	}
+	name, err := filename(userSupplied, res, gav)
+	if err != nil {
+		log.Println(err)
+	}
+	f := filepath.Join(outputDirectory, name)
+	size := "unknown size"
+	if res.ContentLength >= 0 {
+		size = humanSize(res.ContentLength)
+	}
+	fmt.Printf("would fetch %s -> %s (%s)\n", u, f, size)
+	return nil
+}
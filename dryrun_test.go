@@ -0,0 +1,17 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestPlanFetchFailureGoesOn(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := planFetch(ctx, "http://localhost:1/x.jar", t.TempDir(), "x.jar",
+		Gav{})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected %v but got %v\n", context.Canceled, err)
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jhinrichsen/nexus-fetch/nexusfetchtest"
+)
+
+func newFakeNexus(t *testing.T, as ...nexusfetchtest.Artifact) (
+	*nexusfetchtest.Server, NexusInstance) {
+	s := nexusfetchtest.NewServer(as...)
+	t.Cleanup(s.Close)
+	return s, NexusInstance{Protocol: "http", Server: s.Host(),
+		Port: s.Port(), Contextroot: nexusfetchtest.ContextRoot}
+}
+
+func TestEndToEndSearchAndFetch(t *testing.T) {
+	_, inst := newFakeNexus(t,
+		nexusfetchtest.Artifact{Repository: "releases", Group: "com.acme",
+			Artifact: "app", Version: "1.0", Content: []byte("v1")},
+		nexusfetchtest.Artifact{Repository: "releases", Group: "com.acme",
+			Artifact: "app", Version: "1.1", Content: []byte("v1.1")},
+		nexusfetchtest.Artifact{Repository: "releases", Group: "com.acme",
+			Artifact: "lib", Version: "1.0", Content: []byte("lib")})
+	c := newClient(NexusRepository{NexusInstance: inst,
+		RepositoryID: "releases"})
+	it, err := c.SearchIter(Gav{Group: "com.acme", Artifact: "app"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var versions []string
+	for it.Next() {
+		versions = append(versions, it.Fqa().Version)
+	}
+	if got := strings.Join(versions, ","); got != "1.0,1.1" {
+		t.Fatalf("Expected versions 1.0,1.1 but got %s\n", got)
+	}
+
+	var sb strings.Builder
+	fqa := Fqa{NexusRepository: c.NexusRepository,
+		Gav: Gav{Group: "com.acme", Artifact: "app", Version: "1.1"}}
+	info, err := c.FetchTo(context.Background(), fqa, &sb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sb.String() != "v1.1" || info.Filename != "app-1.1.jar" {
+		t.Fatalf("unexpected fetch %q %+v\n", sb.String(), info)
+	}
+}
+
+func TestEndToEndMetadata(t *testing.T) {
+	_, inst := newFakeNexus(t,
+		nexusfetchtest.Artifact{Repository: "releases", Group: "com.acme",
+			Artifact: "app", Version: "1.0"},
+		nexusfetchtest.Artifact{Repository: "releases", Group: "com.acme",
+			Artifact: "app", Version: "1.1"})
+	repo := NexusRepository{NexusInstance: inst, RepositoryID: "releases"}
+	md, err := fetchMetadata(Fqa{NexusRepository: repo,
+		Gav: Gav{Group: "com.acme", Artifact: "app"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(md.Versioning.Versions, ","); got != "1.0,1.1" {
+		t.Fatalf("Expected versions 1.0,1.1 but got %s\n", got)
+	}
+}
+
+func TestEndToEndAuthenticatedSnapshotRedirect(t *testing.T) {
+	s, inst := newFakeNexus(t,
+		nexusfetchtest.Artifact{Repository: "snapshots", Group: "com.acme",
+			Artifact: "app", Version: "1.0-20180312.173914-4",
+			Content: []byte("snap")})
+	s.Username, s.Password = "deployer", "secret"
+	inst.Username, inst.Password = "deployer", "secret"
+	fqa := Fqa{NexusRepository: NexusRepository{NexusInstance: inst,
+		RepositoryID: "snapshots"}, Gav: Gav{Group: "com.acme",
+		Artifact: "app", Version: "1.0-SNAPSHOT"}}
+	u := mavenURL("redirect", fqa)
+	if _, err := get(context.Background(), u); err == nil {
+		t.Fatalf("Expected anonymous redirect to fail\n")
+	}
+	res, err := getAs(context.Background(), inst, u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	want := "app-1.0-20180312.173914-4.jar"
+	if got, err := filename("", res, fqa.Gav); err != nil || got != want {
+		t.Fatalf("Expected %s but got %s, %v\n", want, got, err)
+	}
+}
+
+func TestEndToEndClassifiedSnapshot(t *testing.T) {
+	// the main jar is added last, so that it wins without classifier
+	_, inst := newFakeNexus(t,
+		nexusfetchtest.Artifact{Repository: "snapshots", Group: "com.acme",
+			Artifact: "app", Version: "1.0-20180312.173914-4",
+			Classifier: "dist", Content: []byte("dist")},
+		nexusfetchtest.Artifact{Repository: "snapshots", Group: "com.acme",
+			Artifact: "app", Version: "1.0-20180312.173914-4",
+			Content: []byte("main")})
+	fqa := Fqa{NexusRepository: NexusRepository{NexusInstance: inst,
+		RepositoryID: "snapshots"}, Gav: Gav{Group: "com.acme",
+		Artifact: "app", Version: "1.0-SNAPSHOT", Classifier: "dist",
+		Packaging: "jar"}}
+	for _, u := range []string{mavenURL("redirect", fqa),
+		mavenURL("content", fqa)} {
+		res, err := get(context.Background(), u)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var sb strings.Builder
+		io.Copy(&sb, res.Body)
+		res.Body.Close()
+		if sb.String() != "dist" {
+			t.Fatalf("%s: Expected classified jar but got %q\n", u,
+				sb.String())
+		}
+		want := "app-1.0-20180312.173914-4-dist.jar"
+		got, err := filename("", res, fqa.Gav)
+		if err != nil || got != want {
+			t.Fatalf("Expected %s but got %s, %v\n", want, got, err)
+		}
+	}
+}
+
+func TestEndToEndFetchWithPom(t *testing.T) {
+	_, inst := newFakeNexus(t,
+		nexusfetchtest.Artifact{Repository: "releases", Group: "com.acme",
+			Artifact: "lib", Version: "1.0", Content: []byte("lib")},
+		pomArtifact("com.acme", "lib", "1.0", "<project/>"))
+	dir := t.TempDir()
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	ff := newFetchFlags(fs)
+	fs.Parse([]string{"-outputDir", dir, "-with-pom"})
+	failures := ff.fetchAll(context.Background(),
+		[]NexusRepository{{NexusInstance: inst, RepositoryID: "releases"}},
+		[]Gav{{Group: "com.acme", Artifact: "lib", Version: "1.0",
+			Packaging: "jar"}})
+	if len(failures) > 0 {
+		t.Fatal(failures)
+	}
+	for _, f := range []string{"lib-1.0.jar", "lib-1.0.pom"} {
+		if _, err := os.Stat(filepath.Join(dir, f)); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestEndToEndRepositoryPath(t *testing.T) {
+	_, inst := newFakeNexus(t,
+		nexusfetchtest.Artifact{Repository: "snapshots", Group: "com.acme",
+			Artifact: "app", Version: "1.0-20180312.173914-4",
+			Content: []byte("main")})
+	fqa := Fqa{NexusRepository: NexusRepository{NexusInstance: inst,
+		RepositoryID: "snapshots"}, Gav: Gav{Group: "com.acme",
+		Artifact: "app", Version: "1.0-SNAPSHOT", Packaging: "jar"}}
+	got, err := repositoryPath(context.Background(), fqa)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "/com/acme/app/1.0-SNAPSHOT/app-1.0-20180312.173914-4.jar"
+	if got != want {
+		t.Fatalf("Expected %s but got %s\n", want, got)
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+)
+
+// Exit codes, see package documentation.
+const (
+	exitError     = 1
+	exitUsage     = 2
+	exitNotFound  = 4
+	exitAuth      = 5
+	exitNetwork   = 6
+	exitIntegrity = 7
+	exitPartial   = 8
+	exitPolicy    = 9
+	// exitInterrupted follows the shell convention of 128 + SIGINT
+	exitInterrupted = 130
+)
+
+// exitNames describes exit codes and names them for the configuration
+// file.
+var exitNames = []struct {
+	code        int
+	name, usage string
+}{
+	{0, "success", "success"},
+	{exitError, "error", "unspecific error"},
+	{exitUsage, "usage", "wrong usage"},
+	{exitNotFound, "not-found", "nothing found or a download returns " +
+		"404, if abort on not found is enabled"},
+	{exitAuth, "auth", "authentication or authorization failure " +
+		"(HTTP 401/403)"},
+	{exitNetwork, "network", "network failure"},
+	{exitIntegrity, "integrity", "corrupt download"},
+	{exitPartial, "partial", "partial success, some artifacts failed " +
+		"(-keep-going)"},
+	{exitPolicy, "policy", "blocked by a repository firewall policy " +
+		"(quarantine)"},
+	{exitInterrupted, "interrupted", "interrupted by SIGINT or SIGTERM"},
+}
+
+// parseExitCode accepts an exit code by number or name.
+func parseExitCode(s string) (int, error) {
+	for _, e := range exitNames {
+		if e.name == s {
+			return e.code, nil
+		}
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 || n > 255 {
+		return 0, fmt.Errorf("illegal exit code %q", s)
+	}
+	return n, nil
+}
+
+// mapExitCode applies the [exit-codes] of the configuration file. A broken
+// configuration file maps nothing, it is reported where it matters.
+func mapExitCode(code int) int {
+	cfg, _ := loadUserConfig()
+	if to, ok := cfg.exitCodes[code]; ok {
+		return to
+	}
+	return code
+}
+
+// exit completes the HAR file and terminates the process with the mapped
+// exit code.
+func exit(code int) {
+	har.close()
+	os.Exit(mapExitCode(code))
+}
+
+// explainExitCodes documents the exit codes and their active mapping.
+func explainExitCodes(w io.Writer) error {
+	cfg, err := loadUserConfig()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "exit codes, mapped by [exit-codes] in %s:\n",
+		configFile())
+	for _, e := range exitNames {
+		mapped := ""
+		if to, ok := cfg.exitCodes[e.code]; ok {
+			mapped = fmt.Sprintf(" -> %d", to)
+		}
+		fmt.Fprintf(w, "%5d%-7s %-12s %s\n", e.code, mapped, e.name,
+			e.usage)
+	}
+	return nil
+}
+
+// integrityError marks downloads that arrived but are corrupt.
+type integrityError struct {
+	err error
+}
+
+func (a *integrityError) Error() string {
+	return a.err.Error()
+}
+
+func (a *integrityError) Unwrap() error {
+	return a.err
+}
+
+// exitCode classifies an error. Not found is a plain error unless the user
+// asked for abort on not found.
+func exitCode(err error) int {
+	var se *StatusError
+	var ne net.Error
+	var ie *integrityError
+	var qe *quarantineError
+	switch {
+	case errors.Is(err, context.Canceled):
+		return exitInterrupted
+	case errors.As(err, &qe):
+		return exitPolicy
+	case errors.As(err, &se) && (se.StatusCode == http.StatusUnauthorized ||
+		se.StatusCode == http.StatusForbidden):
+		return exitAuth
+	case errors.As(err, &ie):
+		return exitIntegrity
+	case errors.As(err, &ne):
+		return exitNetwork
+	}
+	return exitError
+}
+
+// fail logs err and exits with its exit code.
+func fail(err error) {
+	log.Println(err)
+	exit(exitCode(err))
+}
+
+// summaryExitCode returns the exit code after processing n items with some
+// failures: partial success if anything worked, otherwise the common exit
+// code of all failures.
+func summaryExitCode(n int, failures []error) int {
+	if len(failures) == 0 {
+		return 0
+	}
+	if len(failures) < n {
+		return exitPartial
+	}
+	code := exitCode(failures[0])
+	for _, err := range failures[1:] {
+		if exitCode(err) != code {
+			return exitError
+		}
+	}
+	return code
+}
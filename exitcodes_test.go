@@ -0,0 +1,93 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExitCode(t *testing.T) {
+	for _, tt := range []struct {
+		err  error
+		want int
+	}{
+		{errors.New("boom"), exitError},
+		{&StatusError{URL: "u", StatusCode: 401}, exitAuth},
+		{fmt.Errorf("g:a:v: %w", &StatusError{URL: "u", StatusCode: 403}),
+			exitAuth},
+		{&StatusError{URL: "u", StatusCode: 404}, exitError},
+		{&net.OpError{Op: "dial", Err: errors.New("refused")}, exitNetwork},
+		{&integrityError{errors.New("crc")}, exitIntegrity},
+		{fmt.Errorf("g:a:v: %w", &quarantineError{"u", "policy"}),
+			exitPolicy},
+	} {
+		if got := exitCode(tt.err); tt.want != got {
+			t.Fatalf("%v: expected %d but got %d\n", tt.err, tt.want, got)
+		}
+	}
+}
+
+func TestSummaryExitCode(t *testing.T) {
+	auth := &StatusError{URL: "u", StatusCode: 401}
+	crc := &integrityError{errors.New("crc")}
+	for _, tt := range []struct {
+		n        int
+		failures []error
+		want     int
+	}{
+		{3, nil, 0},
+		{3, []error{auth}, exitPartial},
+		{2, []error{auth, auth}, exitAuth},
+		{2, []error{auth, crc}, exitError},
+	} {
+		if got := summaryExitCode(tt.n, tt.failures); tt.want != got {
+			t.Fatalf("%v: expected %d but got %d\n", tt.failures, tt.want,
+				got)
+		}
+	}
+}
+
+func TestExitCodeMapping(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "config")
+	cfg := "[exit-codes]\nnot-found = success\n8 = 1\n"
+	if err := os.WriteFile(fn, []byte(cfg), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c, err := loadConfig(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.profiles["exit-codes"]; ok {
+		t.Fatalf("Expected no profile exit-codes\n")
+	}
+	userConfigOnce.Do(func() {})
+	defer func(c config) { userConfig = c }(userConfig)
+	userConfig = c
+
+	for code, want := range map[int]int{exitNotFound: 0, exitPartial: 1,
+		exitAuth: exitAuth} {
+		if got := mapExitCode(code); want != got {
+			t.Fatalf("Expected %d but got %d\n", want, got)
+		}
+	}
+	var sb strings.Builder
+	if err := explainExitCodes(&sb); err != nil {
+		t.Fatal(err)
+	}
+	want := "    4 -> 0   not-found    nothing found"
+	if !strings.Contains(sb.String(), want) {
+		t.Fatalf("Expected %s but got\n%s\n", want, sb.String())
+	}
+
+	if err := os.WriteFile(fn, []byte("[exit-codes]\n4 = 300\n"),
+		0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadConfig(fn); err == nil {
+		t.Fatalf("Expected an error for exit code 300\n")
+	}
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// exporters write GAVs as dependency declarations of a build tool.
+var exporters = map[string]func(w io.Writer, gavs []Gav,
+	repos []NexusRepository) error{
+	"maven":  exportMaven,
+	"gradle": exportGradle,
+	"bazel":  exportBazel,
+}
+
+// exportMaven writes <dependency> snippets for a pom.xml.
+func exportMaven(w io.Writer, gavs []Gav, _ []NexusRepository) error {
+	for _, gav := range gavs {
+		fmt.Fprintf(w, "<dependency>\n")
+		fmt.Fprintf(w, "  <groupId>%s</groupId>\n", xmlEscape(gav.Group))
+		fmt.Fprintf(w, "  <artifactId>%s</artifactId>\n",
+			xmlEscape(gav.Artifact))
+		fmt.Fprintf(w, "  <version>%s</version>\n", xmlEscape(gav.Version))
+		if gav.Classifier != "" {
+			fmt.Fprintf(w, "  <classifier>%s</classifier>\n",
+				xmlEscape(gav.Classifier))
+		}
+		if gav.Packaging != "" && gav.Packaging != "jar" {
+			fmt.Fprintf(w, "  <type>%s</type>\n", xmlEscape(gav.Packaging))
+		}
+		fmt.Fprintf(w, "</dependency>\n")
+	}
+	return nil
+}
+
+func xmlEscape(s string) string {
+	var sb strings.Builder
+	if err := xml.EscapeText(&sb, []byte(s)); err != nil {
+		return s
+	}
+	return sb.String()
+}
+
+var catalogKey = regexp.MustCompile(`[^a-zA-Z0-9]+`)
+
+// exportGradle writes the [libraries] table of a Gradle version catalog.
+// Version catalogs cannot express classifiers and extensions, these
+// are dropped.
+func exportGradle(w io.Writer, gavs []Gav, _ []NexusRepository) error {
+	fmt.Fprintf(w, "[libraries]\n")
+	keys := make(map[string]bool)
+	for _, gav := range gavs {
+		if gav.Classifier != "" || gav.Packaging != "" &&
+			gav.Packaging != "jar" {
+			log.Printf("%s: version catalogs do not support classifier "+
+				"and extension\n", gav.ConciseNotation())
+		}
+		key := catalogKey.ReplaceAllString(gav.Artifact, "-")
+		// qualify clashing artifact IDs by group
+		if keys[key] {
+			key = catalogKey.ReplaceAllString(gav.Group+"-"+
+				gav.Artifact, "-")
+		}
+		for i := 2; keys[key]; i++ {
+			key = fmt.Sprintf("%s-%d", strings.TrimRight(key,
+				"-0123456789"), i)
+		}
+		keys[key] = true
+		fmt.Fprintf(w, "%s = { module = %q, version = %q }\n", key,
+			gav.Group+":"+gav.Artifact, gav.Version)
+	}
+	return nil
+}
+
+// exportBazel writes the attributes of a rules_jvm_external maven_install
+// as JSON.
+func exportBazel(w io.Writer, gavs []Gav, repos []NexusRepository) error {
+	var install struct {
+		Artifacts    []string `json:"artifacts"`
+		Repositories []string `json:"repositories"`
+	}
+	install.Artifacts = []string{}
+	install.Repositories = []string{}
+	for _, gav := range gavs {
+		// group:artifact[:packaging[:classifier]]:version
+		s := gav.Group + ":" + gav.Artifact
+		if gav.Classifier != "" {
+			p := gav.Packaging
+			if p == "" {
+				p = "jar"
+			}
+			s += ":" + p + ":" + gav.Classifier
+		} else if gav.Packaging != "" && gav.Packaging != "jar" {
+			s += ":" + gav.Packaging
+		}
+		install.Artifacts = append(install.Artifacts, s+":"+gav.Version)
+	}
+	for _, r := range repos {
+		if r.RepositoryID != "" {
+			install.Repositories = append(install.Repositories,
+				RepositoryFileURL(r, ""))
+		}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(install)
+}
+
+func exportCommand(args []string) {
+	fs := newCommand("export", "[GAV in concise notation...]")
+	nf := newNexusFlags(fs)
+	srCache.flags(fs)
+	format := fs.String("format", "maven",
+		"Declaration format: maven, gradle or bazel")
+	fs.Parse(args)
+	export, ok := exporters[*format]
+	if !ok {
+		log.Printf("unknown format %q\n", *format)
+		fs.Usage()
+	}
+
+	gavs := coordinates(fs.Args(), nf.repos())
+	if err := export(os.Stdout, gavs, nf.repos()); err != nil {
+		fail(err)
+	}
+}
+
+// coordinates parses args, or one coordinate per line on stdin as printed
+// by -format-template if there are none. Incomplete coordinates are
+// resolved by searching repos.
+func coordinates(args []string, repos []NexusRepository) []Gav {
+	coords := args
+	if len(coords) == 0 {
+		sc := bufio.NewScanner(os.Stdin)
+		for sc.Scan() {
+			if s := strings.TrimSpace(sc.Text()); s != "" {
+				coords = append(coords, s)
+			}
+		}
+		if err := sc.Err(); err != nil {
+			fail(err)
+		}
+	}
+	var gavs []Gav
+	for _, c := range coords {
+		gav, err := parseConcise(c)
+		if err != nil {
+			log.Printf("%v\n", err)
+			exit(exitUsage)
+		}
+		if gav.Version != "" && !strings.Contains(gav.Version, "*") {
+			gavs = append(gavs, gav)
+			continue
+		}
+		ls, err := searchAll(repos, gav)
+		if err != nil {
+			fail(err)
+		}
+		for _, a := range withoutPoms(ls) {
+			gavs = append(gavs, a.Gav)
+		}
+	}
+	return gavs
+}
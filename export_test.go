@@ -0,0 +1,63 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+var exportGavs = []Gav{
+	{Group: "org.acme", Artifact: "core", Version: "1.0"},
+	{Group: "org.acme", Artifact: "native", Version: "1.0", Classifier: "linux",
+		Packaging: "so"},
+	{Group: "com.other", Artifact: "core", Version: "2.0"},
+}
+
+func TestExportMaven(t *testing.T) {
+	var sb strings.Builder
+	exportMaven(&sb, exportGavs[1:2], nil)
+	want := `<dependency>
+  <groupId>org.acme</groupId>
+  <artifactId>native</artifactId>
+  <version>1.0</version>
+  <classifier>linux</classifier>
+  <type>so</type>
+</dependency>
+`
+	if sb.String() != want {
+		t.Fatalf("Expected %s but got %s\n", want, sb.String())
+	}
+}
+
+func TestExportGradle(t *testing.T) {
+	var sb strings.Builder
+	exportGradle(&sb, exportGavs, nil)
+	want := `[libraries]
+core = { module = "org.acme:core", version = "1.0" }
+native = { module = "org.acme:native", version = "1.0" }
+com-other-core = { module = "com.other:core", version = "2.0" }
+`
+	if sb.String() != want {
+		t.Fatalf("Expected %s but got %s\n", want, sb.String())
+	}
+}
+
+func TestExportBazel(t *testing.T) {
+	var sb strings.Builder
+	repo := NexusRepository{NexusInstance: NexusInstance{Protocol: "http",
+		Server: "nexus", Port: "8081", Contextroot: "nexus/"},
+		RepositoryID: "releases"}
+	exportBazel(&sb, exportGavs[:2], []NexusRepository{repo})
+	want := `{
+  "artifacts": [
+    "org.acme:core:1.0",
+    "org.acme:native:so:linux:1.0"
+  ],
+  "repositories": [
+    "http://nexus:8081/nexus/content/repositories/releases/"
+  ]
+}
+`
+	if sb.String() != want {
+		t.Fatalf("Expected %s but got %s\n", want, sb.String())
+	}
+}
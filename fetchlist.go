@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// fetchFlags holds the options of subcommands that fetch a list of declared
+// dependencies, such as from-gradle.
+type fetchFlags struct {
+	outputDir  *string
+	dryRun     *bool
+	keepGoing  *bool
+	withPom    *bool
+	sbom       *string
+	sbomFormat *string
+	vulns      *vulnFlags
+	attest     *attestation
+	// fetched collects the downloads for the SBOM
+	fetched []sbomComponent
+}
+
+func newFetchFlags(fs *flag.FlagSet) *fetchFlags {
+	return &fetchFlags{
+		outputDir: fs.String("outputDir", ".",
+			"Directory to put fetched artifacts into"),
+		dryRun: fs.Bool("dry-run", false,
+			"Print what would be fetched without downloading"),
+		keepGoing: fs.Bool("keep-going", false,
+			"Continue with remaining dependencies after a failure"),
+		withPom: fs.Bool("with-pom", false,
+			"Also download the POM of each fetched artifact"),
+		sbom: fs.String("sbom", "", "Write an SBOM of all fetched "+
+			"artifacts with purls and hashes to this file"),
+		sbomFormat: fs.String("sbom-format", "cyclonedx",
+			"SBOM format: cyclonedx or spdx"),
+		vulns:  newVulnFlags(fs),
+		attest: newAttestation(fs),
+	}
+}
+
+// fetchAll fetches all gavs, each from the first of repos that contains
+// it, and returns the failures.
+func (a *fetchFlags) fetchAll(ctx context.Context, repos []NexusRepository,
+	gavs []Gav) []error {
+	if err := a.attest.validate(); err != nil {
+		fail(err)
+	}
+	if err := a.vulns.check(ctx, os.Stderr, gavs); err != nil {
+		fail(err)
+	}
+	var failures []error
+	for _, gav := range gavs {
+		err := a.fetchOne(ctx, repos, gav)
+		if ctx.Err() != nil {
+			fail(ctx.Err())
+		}
+		if err == nil {
+			continue
+		}
+		err = fmt.Errorf("%s: %w", gav.ConciseNotation(), err)
+		if !*a.keepGoing {
+			fail(err)
+		}
+		log.Println(err)
+		failures = append(failures, err)
+	}
+	if *a.sbom != "" && !*a.dryRun {
+		if err := writeSBOM(*a.sbom, *a.sbomFormat, a.fetched); err != nil {
+			fail(err)
+		}
+	}
+	if !*a.dryRun {
+		if err := a.attest.write(); err != nil {
+			fail(err)
+		}
+	}
+	return failures
+}
+
+func (a *fetchFlags) fetchOne(ctx context.Context, repos []NexusRepository,
+	gav Gav) error {
+	fqa, found, err := locate(Fqa{Gav: gav}, repos)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return &StatusError{URL: contentURL(fqa),
+			StatusCode: http.StatusNotFound}
+	}
+	if err := a.fetchFile(ctx, fqa); err != nil {
+		return err
+	}
+	if pf, ok := pomOf(fqa); ok && *a.withPom {
+		return a.fetchFile(ctx, pf)
+	}
+	return nil
+}
+
+// fetchFile downloads a located artifact.
+func (a *fetchFlags) fetchFile(ctx context.Context, fqa Fqa) error {
+	gav := fqa.Gav
+	u := contentURL(fqa)
+	var inst NexusInstance
+	if strings.HasSuffix(gav.Version, "SNAPSHOT") {
+		u = mavenURL("redirect", fqa)
+		inst = fqa.NexusInstance
+	}
+	if *a.dryRun {
+		return planFetch(ctx, u, *a.outputDir, "", gav)
+	}
+	res, err := getAs(ctx, inst, u)
+	if err != nil {
+		return err
+	}
+	f, err := filename("", res, gav)
+	if err != nil {
+		res.Body.Close()
+		return err
+	}
+	p, err := persistBody(res, *a.outputDir, f, 0)
+	if err != nil {
+		return err
+	}
+	fmt.Println(p)
+	a.attest.add(fqa, u, p, nil)
+	if *a.sbom != "" && gav.Packaging != "pom" {
+		c, err := newSBOMComponent(gav, u, p)
+		if err != nil {
+			return err
+		}
+		a.fetched = append(a.fetched, c)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"time"
+)
+
+// dateValue is a flag value accepting a date (2006-01-02) or a RFC 3339
+// timestamp.
+type dateValue struct {
+	time.Time
+}
+
+func (a *dateValue) String() string {
+	if a.IsZero() {
+		return ""
+	}
+	return a.Format(time.RFC3339)
+}
+
+func (a *dateValue) Set(s string) error {
+	for _, layout := range []string{"2006-01-02", time.RFC3339} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			a.Time = t
+			return nil
+		}
+	}
+	return fmt.Errorf("illegal date %q, expected YYYY-MM-DD", s)
+}
+
+// filter restricts search results based on item metadata that the search
+// API does not return.
+type filter struct {
+	uploadedBefore dateValue
+	uploadedAfter  dateValue
+	minSize        byteSize
+	// maxSize is no filter but aborts downloads, as search results do not
+	// carry a size
+	maxSize byteSize
+}
+
+func newFilter(fs *flag.FlagSet) *filter {
+	var a filter
+	fs.Var(&a.uploadedBefore, "uploaded-before",
+		"Only consider artifacts uploaded before this date (YYYY-MM-DD)")
+	fs.Var(&a.uploadedAfter, "uploaded-after",
+		"Only consider artifacts uploaded after this date (YYYY-MM-DD)")
+	fs.Var(&a.minSize, "min-size",
+		"Only consider artifacts of at least this size (e.g. 10KB)")
+	fs.Var(&a.maxSize, "max-size",
+		"Abort downloads larger than this size (e.g. 500MB), 0 for no "+
+			"limit")
+	return &a
+}
+
+func (a *filter) active() bool {
+	return !a.uploadedBefore.IsZero() || !a.uploadedAfter.IsZero() ||
+		a.minSize > 0
+}
+
+// accept decides based on item metadata.
+func (a *filter) accept(info ItemInfo) bool {
+	uploaded := millis(info.Uploaded)
+	if !a.uploadedBefore.IsZero() && !uploaded.Before(a.uploadedBefore.Time) {
+		return false
+	}
+	if !a.uploadedAfter.IsZero() && !uploaded.After(a.uploadedAfter.Time) {
+		return false
+	}
+	if a.minSize > 0 && info.Size < int64(a.minSize) {
+		return false
+	}
+	return true
+}
+
+// apply returns all results accepted by the filter. Results without
+// metadata cannot be judged and are kept.
+func (a *filter) apply(ls []Fqa, infos infoCache) []Fqa {
+	if !a.active() {
+		return ls
+	}
+	var as []Fqa
+	for _, fqa := range ls {
+		info, err := infos.get(fqa)
+		if err != nil {
+			log.Printf("cannot filter %s, keeping it: %v\n",
+				fqa.Gav.ConciseNotation(), err)
+			as = append(as, fqa)
+			continue
+		}
+		if a.accept(info) {
+			as = append(as, fqa)
+		}
+	}
+	return as
+}
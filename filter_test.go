@@ -0,0 +1,71 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestFilterUploaded(t *testing.T) {
+	var f filter
+	if err := f.uploadedAfter.Set("2018-01-01"); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.uploadedBefore.Set("2019-01-01"); err != nil {
+		t.Fatal(err)
+	}
+	for date, want := range map[string]bool{
+		"2017-12-31": false,
+		"2018-06-30": true,
+		"2019-01-02": false,
+	} {
+		d, err := time.ParseInLocation("2006-01-02", date, time.Local)
+		if err != nil {
+			t.Fatal(err)
+		}
+		info := ItemInfo{Uploaded: d.UnixNano() / int64(time.Millisecond)}
+		if got := f.accept(info); want != got {
+			t.Fatalf("%s: expected %v but got %v\n", date, want, got)
+		}
+	}
+}
+
+func TestFilterInactive(t *testing.T) {
+	var f filter
+	ls := []Fqa{{Gav: Gav{Group: "g"}}}
+	if got := f.apply(ls, nil); len(got) != 1 {
+		t.Fatalf("Expected unfiltered results but got %+v\n", got)
+	}
+}
+
+func TestFilterSize(t *testing.T) {
+	f := filter{minSize: 10, maxSize: 100}
+	for size, want := range map[int64]bool{
+		9:   false,
+		10:  true,
+		100: true,
+		// -max-size only limits downloads
+		101: true,
+	} {
+		if got := f.accept(ItemInfo{Size: size}); want != got {
+			t.Fatalf("%d: expected %v but got %v\n", size, want, got)
+		}
+	}
+}
+
+func TestFilterKeepsResultsWithoutInfo(t *testing.T) {
+	inst := fakeNexus(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	f := filter{minSize: 10}
+	ls := []Fqa{{NexusRepository: NexusRepository{NexusInstance: inst,
+		RepositoryID: "releases"}, Gav: Gav{Group: "g", Artifact: "a",
+		Version: "1.0"}}}
+	if got := f.apply(ls, make(infoCache)); len(got) != 1 {
+		t.Fatalf("Expected result without info to be kept but got %+v\n",
+			got)
+	}
+	if (&filter{maxSize: 100}).active() {
+		t.Fatalf("Expected -max-size not to filter search results\n")
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"syscall"
+)
+
+var (
+	// implementation 'g:a:v:c@ext', api("g:a:v"), ...
+	gradleString = regexp.MustCompile(
+		`^\s*\w+\s*\(?\s*['"]([^'"\s]+:[^'"\s]+:[^'"\s]+)['"]`)
+	// implementation group: 'g', name: 'a', version: 'v'
+	gradleMapEntry = regexp.MustCompile(`(\w+)\s*[:=]\s*['"]([^'"]*)['"]`)
+	// key = "value" or key = { module = "g:a", version.ref = "v" }
+	tomlEntry = regexp.MustCompile(`([\w.-]+)\s*=\s*"([^"]*)"`)
+)
+
+// gradleDependencies returns the dependencies declared in a build.gradle,
+// build.gradle.kts or a version catalog such as libs.versions.toml.
+func gradleDependencies(name string, r io.Reader) ([]Gav, error) {
+	if strings.HasSuffix(name, ".toml") {
+		return versionCatalog(r)
+	}
+	return buildScript(r)
+}
+
+func buildScript(r io.Reader) ([]Gav, error) {
+	var gavs []Gav
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := sc.Text()
+		if ms := gradleString.FindStringSubmatch(line); ms != nil {
+			if strings.Contains(ms[1], "$") {
+				log.Printf("skipping interpolated dependency %s\n", ms[1])
+				continue
+			}
+			gav, err := parseConcise(ms[1])
+			if err != nil {
+				return nil, err
+			}
+			gavs = append(gavs, gav)
+			continue
+		}
+		m := make(map[string]string)
+		for _, ms := range gradleMapEntry.FindAllStringSubmatch(line, -1) {
+			m[ms[1]] = ms[2]
+		}
+		if m["group"] != "" && m["name"] != "" && m["version"] != "" {
+			gavs = append(gavs, Gav{Group: m["group"],
+				Artifact: m["name"], Version: m["version"],
+				Classifier: m["classifier"], Packaging: m["ext"]})
+		}
+	}
+	return gavs, sc.Err()
+}
+
+func versionCatalog(r io.Reader) ([]Gav, error) {
+	versions := make(map[string]string)
+	var libraries []map[string]string
+	var section string
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			section = strings.Trim(line, "[] ")
+			continue
+		}
+		ms := tomlEntry.FindAllStringSubmatch(line, -1)
+		if len(ms) == 0 {
+			continue
+		}
+		switch section {
+		case "versions":
+			versions[strings.TrimSpace(strings.SplitN(line, "=", 2)[0])] =
+				ms[0][2]
+		case "libraries":
+			m := make(map[string]string)
+			// short form: key = "g:a:v"
+			if !strings.Contains(line, "{") {
+				m["module"] = ms[0][2]
+			}
+			for _, kv := range ms {
+				m[kv[1]] = kv[2]
+			}
+			libraries = append(libraries, m)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	var gavs []Gav
+	for _, m := range libraries {
+		version := m["version"]
+		if ref, ok := m["version.ref"]; ok {
+			version, ok = versions[ref]
+			if !ok {
+				return nil, fmt.Errorf("undefined version %q", ref)
+			}
+		}
+		module := m["module"]
+		if module == "" {
+			module = m["group"] + ":" + m["name"]
+		}
+		if version != "" && strings.Count(module, ":") == 1 {
+			module += ":" + version
+		}
+		gav, err := parseConcise(module)
+		if err != nil {
+			return nil, err
+		}
+		if gav.Version == "" {
+			log.Printf("skipping %s without version\n", module)
+			continue
+		}
+		gavs = append(gavs, gav)
+	}
+	return gavs, nil
+}
+
+func fromGradleCommand(args []string) {
+	fs := newCommand("from-gradle",
+		"<build.gradle, build.gradle.kts or libs.versions.toml>")
+	nf := newNexusFlags(fs)
+	ff := newFetchFlags(fs)
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		fail(err)
+	}
+	gavs, err := gradleDependencies(filepath.Base(fs.Arg(0)), f)
+	f.Close()
+	if err != nil {
+		fail(fmt.Errorf("%s: %w", fs.Arg(0), err))
+	}
+	log.Printf("%s declares %d dependencies\n", fs.Arg(0), len(gavs))
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt,
+		syscall.SIGTERM)
+	defer stop()
+	failures := ff.fetchAll(ctx, nf.repos(), gavs)
+	exit(summaryExitCode(len(gavs), failures))
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBuildGradle(t *testing.T) {
+	script := `
+plugins {
+    id 'java'
+}
+dependencies {
+    implementation 'org.slf4j:slf4j-api:2.0.9'
+    testImplementation("junit:junit:4.13.2")
+    runtimeOnly 'com.example:native:1.0:linux-x86_64@so'
+    compileOnly group: 'javax.servlet', name: 'servlet-api', version: '2.5'
+    implementation "com.example:lib:$libVersion"
+}
+`
+	got, err := gradleDependencies("build.gradle", strings.NewReader(script))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Gav{
+		{Group: "org.slf4j", Artifact: "slf4j-api", Version: "2.0.9"},
+		{Group: "junit", Artifact: "junit", Version: "4.13.2"},
+		{Group: "com.example", Artifact: "native", Version: "1.0",
+			Classifier: "linux-x86_64", Packaging: "so"},
+		{Group: "javax.servlet", Artifact: "servlet-api", Version: "2.5"},
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("Expected %+v but got %+v\n", want, got)
+	}
+}
+
+func TestVersionCatalog(t *testing.T) {
+	catalog := `
+[versions]
+groovy = "3.0.5"
+
+[libraries]
+groovy-core = { module = "org.codehaus.groovy:groovy", version.ref = "groovy" }
+commons-lang3 = { group = "org.apache.commons", name = "commons-lang3", version = "3.12.0" }
+guava = "com.google.guava:guava:32.1.2-jre"
+bom = { module = "com.example:bom" }
+
+[plugins]
+versions = { id = "com.github.ben-manes.versions", version = "0.45.0" }
+`
+	got, err := gradleDependencies("libs.versions.toml",
+		strings.NewReader(catalog))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Gav{
+		{Group: "org.codehaus.groovy", Artifact: "groovy", Version: "3.0.5"},
+		{Group: "org.apache.commons", Artifact: "commons-lang3",
+			Version: "3.12.0"},
+		{Group: "com.google.guava", Artifact: "guava", Version: "32.1.2-jre"},
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("Expected %+v but got %+v\n", want, got)
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxHistory limits the number of remembered fetches.
+const maxHistory = 1000
+
+// secretFlags are not written to the history file.
+var secretFlags = map[string]bool{"password": true, "sign-hmac": true}
+
+// historyEntry is a fetch as given on the command line.
+type historyEntry struct {
+	Time        time.Time `json:"time"`
+	Flags       []string  `json:"flags"`
+	Coordinates []string  `json:"coordinates,omitempty"`
+}
+
+// historyFile returns the location of the fetch history, or an empty
+// string if there is no user cache directory.
+func historyFile() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "nexus-fetch", "history")
+}
+
+// newHistoryEntry splits the arguments of a fetch into flags without
+// secrets and the trailing coordinates.
+func newHistoryEntry(now time.Time, args []string, narg int) historyEntry {
+	e := historyEntry{Time: now, Coordinates: args[len(args)-narg:]}
+	flags := args[:len(args)-narg]
+	for i := 0; i < len(flags); i++ {
+		name := strings.SplitN(strings.TrimLeft(flags[i], "-"), "=", 2)
+		if !secretFlags[name[0]] {
+			e.Flags = append(e.Flags, flags[i])
+			continue
+		}
+		if len(name) == 1 {
+			// skip the value as well
+			i++
+		}
+	}
+	return e
+}
+
+// args returns the command line of e with extra flags, which override
+// those recorded.
+func (a historyEntry) args(extra []string) []string {
+	var as []string
+	as = append(as, a.Flags...)
+	as = append(as, extra...)
+	return append(as, a.Coordinates...)
+}
+
+func (a historyEntry) String() string {
+	var ss []string
+	for _, arg := range a.args(nil) {
+		if arg == "" || strings.ContainsAny(arg, " \t\"'\\$") {
+			arg = strconv.Quote(arg)
+		}
+		ss = append(ss, arg)
+	}
+	return strings.Join(ss, " ")
+}
+
+// readHistory returns the entries of fn, most recent first.
+func readHistory(fn string) ([]historyEntry, error) {
+	f, err := os.Open(fn)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var es []historyEntry
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var e historyEntry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("%s: %v", fn, err)
+		}
+		es = append([]historyEntry{e}, es...)
+	}
+	return es, sc.Err()
+}
+
+// appendHistory adds e to the history in fn, dropping the oldest entries
+// beyond maxHistory.
+func appendHistory(fn string, e historyEntry) error {
+	es, err := readHistory(fn)
+	if err != nil {
+		return err
+	}
+	es = append([]historyEntry{e}, es...)
+	if len(es) > maxHistory {
+		es = es[:maxHistory]
+	}
+	var sb strings.Builder
+	for i := len(es) - 1; i >= 0; i-- {
+		buf, err := json.Marshal(es[i])
+		if err != nil {
+			return err
+		}
+		sb.Write(buf)
+		sb.WriteByte('\n')
+	}
+	if err := os.MkdirAll(filepath.Dir(fn), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(fn, []byte(sb.String()), 0644)
+}
+
+// record adds a fetch to the history. This is a convenience only, so
+// errors are logged and ignored.
+func record(args []string, narg int) {
+	fn := historyFile()
+	if fn == "" {
+		return
+	}
+	if err := appendHistory(fn, newHistoryEntry(time.Now(), args,
+		narg)); err != nil {
+		log.Printf("cannot record history: %v\n", err)
+	}
+}
+
+func historyCommand(args []string) {
+	fs := newCommand("history", "")
+	limit := fs.Int("limit", 20, "List at most N fetches, 0 for all")
+	fs.Parse(args)
+	if fs.NArg() != 0 {
+		fs.Usage()
+	}
+	es, err := readHistory(historyFile())
+	if err != nil {
+		fail(err)
+	}
+	if *limit > 0 && len(es) > *limit {
+		es = es[:*limit]
+	}
+	for i, e := range es {
+		fmt.Printf("%4d  %s  %s\n", i+1, e.Time.Local().Format(
+			"2006-01-02 15:04"), e)
+	}
+}
+
+// againCommand repeats a fetch of the history, 1 being the most recent.
+func againCommand(args []string) {
+	fs := newCommand("again", "[n] [flags of the fetch]")
+	fs.Parse(args)
+	n := 1
+	extra := fs.Args()
+	if len(extra) > 0 && !strings.HasPrefix(extra[0], "-") {
+		var err error
+		if n, err = strconv.Atoi(extra[0]); err != nil || n < 1 {
+			fs.Usage()
+		}
+		extra = extra[1:]
+	}
+	es, err := readHistory(historyFile())
+	if err != nil {
+		fail(err)
+	}
+	if n > len(es) {
+		log.Printf("history has %d fetches, not %d\n", len(es), n)
+		exit(exitUsage)
+	}
+	e := es[n-1]
+	log.Printf("again: %s\n", e)
+	os.Args = append(os.Args[:1], e.args(extra)...)
+	main()
+}
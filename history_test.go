@@ -0,0 +1,40 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestHistory(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "history")
+	now := time.Date(2026, 10, 15, 7, 0, 0, 0, time.UTC)
+	for i, args := range [][]string{
+		{"-repository", "releases", "-password", "secret",
+			"com.acme:app:1.0"},
+		{"-password=secret", "-outputDir", "out dir", "com.acme:app:2.0"},
+	} {
+		e := newHistoryEntry(now.Add(time.Duration(i)*time.Minute), args, 1)
+		if err := appendHistory(fn, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	es, err := readHistory(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(es) != 2 {
+		t.Fatalf("Expected 2 entries but got %d\n", len(es))
+	}
+	want := `-outputDir "out dir" com.acme:app:2.0`
+	if got := es[0].String(); want != got {
+		t.Fatalf("Expected %s but got %s\n", want, got)
+	}
+	wantArgs := []string{"-repository", "releases", "-dry-run",
+		"com.acme:app:1.0"}
+	if got := es[1].args([]string{"-dry-run"}); !reflect.DeepEqual(wantArgs,
+		got) {
+		t.Fatalf("Expected %v but got %v\n", wantArgs, got)
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/jhinrichsen/nexus-fetch/nexus"
+)
+
+// ItemInfo holds storage metadata of a single repository item.
+type ItemInfo struct {
+	RepositoryID   string `xml:"data>repositoryId" json:"repositoryId"`
+	RepositoryPath string `xml:"data>repositoryPath" json:"repositoryPath"`
+	MimeType       string `xml:"data>mimeType" json:"mimeType"`
+	Size           int64  `xml:"data>size" json:"size"`
+	Uploader       string `xml:"data>uploader" json:"uploader"`
+	// Uploaded and LastChanged are milliseconds since epoch
+	Uploaded    int64  `xml:"data>uploaded" json:"uploaded"`
+	LastChanged int64  `xml:"data>lastChanged" json:"lastChanged"`
+	Sha1        string `xml:"data>sha1Hash" json:"sha1,omitempty"`
+	Md5         string `xml:"data>md5Hash" json:"md5,omitempty"`
+}
+
+// infoURL returns the REST URL describing a repository item.
+func infoURL(a Fqa) string {
+	s := nexus.BaseURL(a.NexusRepository).String()
+	s += fmt.Sprintf("service/local/repositories/%s/content/%s"+
+		"?describe=info", a.RepositoryID, a.DefaultLayout())
+	return s
+}
+
+func itemInfo(fqa Fqa) (ItemInfo, error) {
+	var info ItemInfo
+	if err := unsupported("item metadata"); err != nil {
+		return info, err
+	}
+	u := infoURL(fqa)
+	log.Printf("getting %s\n", u)
+	res, err := httpClient.Get(u)
+	if err != nil {
+		return info, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return info, fmt.Errorf("%s returns HTTP status code %d",
+			u, res.StatusCode)
+	}
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return info, err
+	}
+	err = xml.Unmarshal(body, &info)
+	return info, err
+}
+
+func millis(ms int64) time.Time {
+	return time.Unix(0, ms*int64(time.Millisecond))
+}
+
+func infoCommand(args []string) {
+	fs := newCommand("info", "<GAV in concise notation>")
+	nf := newNexusFlags(fs)
+	asJSON := fs.Bool("json", false, "Print metadata as JSON")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+	}
+
+	gav, err := parseConcise(fs.Arg(0))
+	if err != nil {
+		log.Printf("%v\n", err)
+		exit(2)
+	}
+	fqa := Fqa{NexusRepository: nf.repo(), Gav: gav}
+	if !fullySpecified(fqa) {
+		log.Printf("info requires repository, group, artifact and "+
+			"version: %q\n", fs.Arg(0))
+		exit(exitUsage)
+	}
+	info, err := itemInfo(fqa)
+	if err != nil {
+		fail(err)
+	}
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(info); err != nil {
+			fail(err)
+		}
+		return
+	}
+	fmt.Printf("repository:    %s\n", info.RepositoryID)
+	fmt.Printf("path:          %s\n", info.RepositoryPath)
+	fmt.Printf("size:          %d (%s)\n", info.Size, humanSize(info.Size))
+	fmt.Printf("uploaded:      %s\n",
+		millis(info.Uploaded).Format(time.RFC3339))
+	fmt.Printf("last modified: %s\n",
+		millis(info.LastChanged).Format(time.RFC3339))
+	fmt.Printf("uploaded by:   %s\n", info.Uploader)
+	fmt.Printf("mime type:     %s\n", info.MimeType)
+}
+
+// infoCache remembers item metadata so that filtering and sorting query
+// each artifact once only.
+type infoCache map[Fqa]ItemInfo
+
+func (a infoCache) get(fqa Fqa) (ItemInfo, error) {
+	if info, ok := a[fqa]; ok {
+		return info, nil
+	}
+	info, err := itemInfo(fqa)
+	if err == nil {
+		a[fqa] = info
+	}
+	return info, err
+}
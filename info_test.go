@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/xml"
+	"testing"
+)
+
+const itemInfoResponse = `<org.sonatype.nexus.rest.model.ResourceResponse>
+  <data class="storageFileItem">
+    <presentLocally>true</presentLocally>
+    <repositoryId>releases</repositoryId>
+    <repositoryPath>/g/a/v/a-v.jar</repositoryPath>
+    <mimeType>application/java-archive</mimeType>
+    <uploader>admin</uploader>
+    <uploaded>1520876354000</uploaded>
+    <lastChanged>1520876354000</lastChanged>
+    <size>2048</size>
+    <sha1Hash>0123456789abcdef0123456789abcdef01234567</sha1Hash>
+  </data>
+</org.sonatype.nexus.rest.model.ResourceResponse>`
+
+func TestItemInfo(t *testing.T) {
+	var got ItemInfo
+	if err := xml.Unmarshal([]byte(itemInfoResponse), &got); err != nil {
+		t.Fatal(err)
+	}
+	want := ItemInfo{
+		RepositoryID:   "releases",
+		RepositoryPath: "/g/a/v/a-v.jar",
+		MimeType:       "application/java-archive",
+		Size:           2048,
+		Uploader:       "admin",
+		Uploaded:       1520876354000,
+		LastChanged:    1520876354000,
+		Sha1:           "0123456789abcdef0123456789abcdef01234567",
+	}
+	if want != got {
+		t.Fatalf("Expected %+v but got %+v\n", want, got)
+	}
+}
+
+func TestInfoURL(t *testing.T) {
+	inst := NexusInstance{Protocol: "http", Server: "nexus", Port: "8081",
+		Contextroot: "nexus/"}
+	fqa := Fqa{
+		NexusRepository: NexusRepository{NexusInstance: inst,
+			RepositoryID: "releases"},
+		Gav: Gav{Group: "g.h", Artifact: "a", Version: "v"},
+	}
+	want := "http://nexus:8081/nexus/service/local/repositories/releases/" +
+		"content/g/h/a/v/a-v.jar?describe=info"
+	got := infoURL(fqa)
+	if want != got {
+		t.Fatalf("Expected %s but got %s\n", want, got)
+	}
+}
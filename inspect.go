@@ -0,0 +1,197 @@
+package main
+
+import (
+	"archive/zip"
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+)
+
+// manifestAttribute is a name: value line of META-INF/MANIFEST.MF.
+type manifestAttribute struct {
+	Name, Value string
+}
+
+// parseManifest returns the main section of a jar manifest in order,
+// joining continuation lines.
+func parseManifest(r io.Reader) ([]manifestAttribute, error) {
+	var as []manifestAttribute
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), "\r")
+		if line == "" {
+			// per entry sections follow
+			break
+		}
+		if line[0] == ' ' && len(as) > 0 {
+			as[len(as)-1].Value += line[1:]
+			continue
+		}
+		i := strings.Index(line, ":")
+		if i <= 0 {
+			return nil, fmt.Errorf("bad manifest line %q", line)
+		}
+		as = append(as, manifestAttribute{line[:i],
+			strings.TrimSpace(line[i+1:])})
+	}
+	return as, sc.Err()
+}
+
+// jarInfo summarizes an archive.
+type jarInfo struct {
+	Manifest             []manifestAttribute
+	Entries              int
+	Size, CompressedSize uint64
+}
+
+func inspectArchive(filename string) (jarInfo, error) {
+	var info jarInfo
+	r, err := zip.OpenReader(filename)
+	if err != nil {
+		return info, err
+	}
+	defer r.Close()
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		info.Entries++
+		info.Size += f.UncompressedSize64
+		info.CompressedSize += f.CompressedSize64
+		if f.Name != "META-INF/MANIFEST.MF" {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return info, err
+		}
+		info.Manifest, err = parseManifest(rc)
+		rc.Close()
+		if err != nil {
+			return info, fmt.Errorf("%s: %v", filename, err)
+		}
+	}
+	return info, nil
+}
+
+func (a jarInfo) write(w io.Writer) {
+	width := 0
+	for _, m := range a.Manifest {
+		if len(m.Name) > width {
+			width = len(m.Name)
+		}
+	}
+	for _, m := range a.Manifest {
+		fmt.Fprintf(w, "%-*s %s\n", width+1, m.Name+":", m.Value)
+	}
+	if len(a.Manifest) == 0 {
+		fmt.Fprintln(w, "no manifest")
+	}
+	fmt.Fprintf(w, "%d entries, %s uncompressed, %s compressed\n",
+		a.Entries, humanSize(int64(a.Size)),
+		humanSize(int64(a.CompressedSize)))
+}
+
+// inspectFile returns the archive of fqa from the cache shared with serve,
+// downloading it first if necessary. Without cache, the file is temporary
+// and removed by the returned cleanup.
+func inspectFile(ctx context.Context, fqa Fqa, cacheDir string) (string,
+	func(), error) {
+	s := &server{cacheDir: cacheDir}
+	key := s.cacheKey(fqa)
+	if key != "" {
+		if f, err := s.cache().open(key); err == nil {
+			log.Printf("using cached %s\n", key)
+			f.Close()
+			return f.Name(), func() {}, nil
+		}
+	}
+	res, err := getAs(ctx, fqa.NexusInstance, mavenURL("content", fqa))
+	if err != nil {
+		return "", nil, err
+	}
+	defer res.Body.Close()
+	if key != "" {
+		cw, err := s.cache().create(key)
+		if err != nil {
+			return "", nil, err
+		}
+		_, err = io.Copy(cw, res.Body)
+		if err = cw.commit(err); err != nil {
+			return "", nil, err
+		}
+		f, err := s.cache().open(key)
+		if err != nil {
+			return "", nil, err
+		}
+		f.Close()
+		return f.Name(), func() {}, nil
+	}
+	tmp, err := os.CreateTemp("", "inspect-*"+fqa.Filename())
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup := func() { os.Remove(tmp.Name()) }
+	_, err = io.Copy(tmp, res.Body)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	return tmp.Name(), cleanup, nil
+}
+
+func inspectCommand(args []string) {
+	fs := newCommand("inspect", "<g:a:v>")
+	nf := newNexusFlags(fs)
+	cacheDir := fs.String("cache-dir", "", "Read and store archives in "+
+		"this cache, which may be shared with serve")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+	}
+	gav, err := parseConcise(fs.Arg(0))
+	if err != nil || gav.Group == "" || gav.Artifact == "" ||
+		gav.Version == "" {
+		log.Printf("expected group:artifact:version: %q\n", fs.Arg(0))
+		exit(exitUsage)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt,
+		syscall.SIGTERM)
+	defer stop()
+	var fqa Fqa
+	var found bool
+	for _, repo := range nf.repos() {
+		fqa = Fqa{NexusRepository: repo, Gav: gav}
+		if found, err = exists(ctx, fqa); err != nil {
+			fail(err)
+		}
+		if found {
+			break
+		}
+	}
+	if !found {
+		log.Printf("%s not found\n", gav.ConciseNotation())
+		exit(exitNotFound)
+	}
+	f, cleanup, err := inspectFile(ctx, fqa, *cacheDir)
+	if err != nil {
+		fail(err)
+	}
+	defer cleanup()
+	info, err := inspectArchive(f)
+	if err != nil {
+		cleanup()
+		fail(&integrityError{err})
+	}
+	info.write(os.Stdout)
+}
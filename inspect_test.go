@@ -0,0 +1,76 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jhinrichsen/nexus-fetch/nexusfetchtest"
+)
+
+func TestParseManifest(t *testing.T) {
+	got, err := parseManifest(strings.NewReader("Manifest-Version: 1.0\r\n" +
+		"Class-Path: lib/a.jar lib/b\r\n .jar\r\n" +
+		"Build-Jdk: 17\r\n\r\nName: x\r\nSealed: true\r\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []manifestAttribute{{"Manifest-Version", "1.0"},
+		{"Class-Path", "lib/a.jar lib/b.jar"}, {"Build-Jdk", "17"}}
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("Expected %+v but got %+v\n", want, got)
+	}
+}
+
+func TestInspect(t *testing.T) {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, content := range map[string]string{
+		"META-INF/MANIFEST.MF": "Manifest-Version: 1.0\r\n" +
+			"Created-By: Maven\r\n\r\n",
+		"com/acme/App.class": "cafebabe",
+	} {
+		e, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		e.Write([]byte(content))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	srv, inst := newFakeNexus(t, nexusfetchtest.Artifact{
+		Repository: "releases", Group: "com.acme", Artifact: "app",
+		Version: "1.0", Content: buf.Bytes()})
+	fqa := Fqa{NexusRepository: NexusRepository{NexusInstance: inst,
+		RepositoryID: "releases"},
+		Gav: Gav{Group: "com.acme", Artifact: "app", Version: "1.0"}}
+	cacheDir := t.TempDir()
+	ctx := context.Background()
+
+	f, cleanup, err := inspectFile(ctx, fqa, cacheDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cleanup()
+	info, err := inspectArchive(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Entries != 2 || len(info.Manifest) != 2 ||
+		info.Manifest[1].Value != "Maven" {
+		t.Fatalf("Expected 2 entries and Created-By but got %+v\n", info)
+	}
+
+	// served from cache
+	n := len(srv.Requests())
+	if _, _, err := inspectFile(ctx, fqa, cacheDir); err != nil {
+		t.Fatal(err)
+	}
+	if len(srv.Requests()) != n {
+		t.Fatalf("Expected no request but got %v\n", srv.Requests()[n:])
+	}
+}
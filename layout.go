@@ -0,0 +1,152 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/jhinrichsen/nexus-fetch/nexus"
+)
+
+// Repository layouts as templates. Parts in parentheses are dropped if any
+// of their tokens is empty.
+const (
+	mavenLayout = "[orgPath]/[module]/[revision]/" +
+		"[artifact]-[revision](-[classifier]).[ext]"
+	ivyLayout = "[organisation]/[module]/[revision]/[type]s/" +
+		"[artifact]-[revision](-[classifier]).[ext]"
+)
+
+var (
+	layoutOptional = regexp.MustCompile(`\(([^()]*)\)`)
+	layoutToken    = regexp.MustCompile(`\[(\w+)\]`)
+)
+
+// layouts holds the layout of the remote repository and of the local
+// output tree.
+type layouts struct {
+	remote    *string
+	local     *string
+	groupDirs *bool
+}
+
+func newLayouts(fs *flag.FlagSet) *layouts {
+	return &layouts{
+		remote: fs.String("layout", "maven", "Repository layout: maven, "+
+			"ivy or a template such as "+
+			"[organisation]/[module]/[revision]/[artifact]-[revision].[ext]"),
+		local: fs.String("output-layout", "", "Write downloads into a "+
+			"tree below -outputDir: maven, ivy or a template, "+
+			"default is flat. A maven tree gets maven-metadata.xml "+
+			"files and can be served as a repository"),
+		groupDirs: fs.Bool("group-dirs", false, "Write downloads into "+
+			"<group>/<artifact>/ below -outputDir"),
+	}
+}
+
+func (a *layouts) validate() error {
+	if *a.groupDirs && *a.local != "" {
+		return fmt.Errorf("-group-dirs and -output-layout are exclusive")
+	}
+	for _, l := range []string{*a.remote, *a.local} {
+		t := layoutTemplate(l)
+		if t == "" {
+			continue
+		}
+		for _, m := range layoutToken.FindAllStringSubmatch(t, -1) {
+			if _, ok := layoutTokens(Gav{})[m[1]]; !ok {
+				return fmt.Errorf("unknown layout token [%s] in %q",
+					m[1], l)
+			}
+		}
+	}
+	return nil
+}
+
+// layoutTemplate expands the names of predefined layouts.
+func layoutTemplate(l string) string {
+	switch l {
+	case "maven":
+		return mavenLayout
+	case "ivy":
+		return ivyLayout
+	}
+	return l
+}
+
+func layoutTokens(gav Gav) map[string]string {
+	ext := gav.Extension()
+	return map[string]string{
+		"organisation": gav.Group,
+		"organization": gav.Group,
+		"orgPath":      strings.Replace(gav.Group, ".", "/", -1),
+		"module":       gav.Artifact,
+		"artifact":     gav.Artifact,
+		"revision":     gav.Version,
+		"classifier":   gav.Classifier,
+		"ext":          ext,
+		"type":         ext,
+	}
+}
+
+// layoutPath returns the path of gav in layout l without leading /.
+func layoutPath(l string, gav Gav) string {
+	tokens := layoutTokens(gav)
+	expand := func(s string) string {
+		return layoutToken.ReplaceAllStringFunc(s, func(m string) string {
+			return tokens[m[1:len(m)-1]]
+		})
+	}
+	s := layoutOptional.ReplaceAllStringFunc(layoutTemplate(l),
+		func(m string) string {
+			for _, t := range layoutToken.FindAllStringSubmatch(m, -1) {
+				if tokens[t[1]] == "" {
+					return ""
+				}
+			}
+			return expand(m[1 : len(m)-1])
+		})
+	return expand(s)
+}
+
+// url returns the content URL in a custom remote layout, or "" for the
+// maven layout which is served by the Nexus REST API.
+func (a *layouts) url(fqa Fqa) string {
+	if *a.remote == "maven" {
+		return ""
+	}
+	return nexus.BaseURL(fqa.NexusRepository).String() +
+		repositoryPrefix(fqa.RepositoryID) +
+		layoutPath(*a.remote, fqa.Gav)
+}
+
+// outputName returns the name relative to the output directory. A user
+// supplied name replaces the basename of the output layout.
+func (a *layouts) outputName(name, userSupplied string, gav Gav) string {
+	if *a.groupDirs {
+		return filepath.Join(gav.Group, gav.Artifact, name)
+	}
+	if *a.local == "" {
+		return name
+	}
+	p := layoutPath(*a.local, gav)
+	if userSupplied != "" {
+		p = path.Join(path.Dir(p), userSupplied)
+	}
+	return filepath.FromSlash(p)
+}
+
+// writeMetadata regenerates maven-metadata.xml files of an output tree in
+// maven layout. Failures are logged only, the downloads are complete.
+func (a *layouts) writeMetadata(dir string) {
+	if *a.local != "maven" {
+		return
+	}
+	if err := writeMirrorMetadata(dir); err != nil {
+		log.Printf("cannot write maven-metadata.xml: %v\n", err)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestLayoutPath(t *testing.T) {
+	gav := Gav{Group: "org.acme", Artifact: "app", Version: "1.0"}
+	classified := Gav{Group: "org.acme", Artifact: "app", Version: "1.0",
+		Classifier: "sources", Packaging: "zip"}
+	for _, tt := range []struct {
+		layout string
+		gav    Gav
+		want   string
+	}{
+		{"maven", gav, gav.DefaultLayout()},
+		{"maven", classified, classified.DefaultLayout()},
+		{"ivy", gav, "org.acme/app/1.0/jars/app-1.0.jar"},
+		{"ivy", classified, "org.acme/app/1.0/zips/app-1.0-sources.zip"},
+		{"[organisation]/[module]/[revision]/[artifact]-[revision].[ext]",
+			gav, "org.acme/app/1.0/app-1.0.jar"},
+	} {
+		if got := layoutPath(tt.layout, tt.gav); got != tt.want {
+			t.Fatalf("Expected %s but got %s\n", tt.want, got)
+		}
+	}
+}
+
+func TestLayoutValidate(t *testing.T) {
+	remote, local, groupDirs := "[organisation]/[nonsense]", "", false
+	l := layouts{&remote, &local, &groupDirs}
+	if err := l.validate(); err == nil {
+		t.Fatalf("Expected error for unknown token\n")
+	}
+	remote = "ivy"
+	if err := l.validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGroupDirs(t *testing.T) {
+	remote, local, groupDirs := "maven", "", true
+	l := layouts{&remote, &local, &groupDirs}
+	gav := Gav{Group: "org.acme", Artifact: "app", Version: "1.0"}
+	got := l.outputName("app-1.0.jar", "", gav)
+	want := filepath.Join("org.acme", "app", "app-1.0.jar")
+	if got != want {
+		t.Fatalf("Expected %s but got %s\n", want, got)
+	}
+	local = "ivy"
+	if err := l.validate(); err == nil {
+		t.Fatalf("Expected -group-dirs and -output-layout to conflict\n")
+	}
+}
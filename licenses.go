@@ -0,0 +1,201 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jhinrichsen/nexus-fetch/nexus"
+)
+
+// pomLicense is a <license> of a POM.
+type pomLicense struct {
+	Name string `xml:"name" json:"name"`
+	URL  string `xml:"url" json:"url,omitempty"`
+	// SPDX is the identifier guessed from name and URL
+	SPDX string `xml:"-" json:"spdx,omitempty"`
+}
+
+// spdxNames maps spellings found in POMs to SPDX identifiers. More specific
+// spellings come first so that LGPL is not taken for GPL.
+var spdxNames = []struct{ id, match string }{
+	{"AGPL-3.0", "affero general public license"},
+	{"LGPL-3.0", "lesser general public license, version 3"},
+	{"LGPL-3.0", "lgpl-3"},
+	{"LGPL-3.0", "lesser general public license v3"},
+	{"LGPL-2.1", "lesser general public license, version 2.1"},
+	{"LGPL-2.1", "lgpl-2.1"},
+	{"LGPL-2.1", "lesser general public license v2.1"},
+	{"GPL-3.0", "general public license, version 3"},
+	{"GPL-3.0", "general public license v3"},
+	{"GPL-3.0", "gpl-3"},
+	{"GPL-2.0", "general public license, version 2"},
+	{"GPL-2.0", "general public license v2"},
+	{"GPL-2.0", "gpl-2"},
+	{"Apache-2.0", "apache license, version 2.0"},
+	{"Apache-2.0", "apache-2.0"},
+	{"Apache-2.0", "apache license 2.0"},
+	{"Apache-2.0", "apache software license - version 2.0"},
+	{"Apache-2.0", "apache software license, version 2.0"},
+	{"Apache-2.0", "apache.org/licenses/license-2.0"},
+	{"EPL-2.0", "eclipse public license - v 2.0"},
+	{"EPL-2.0", "epl-2.0"},
+	{"EPL-1.0", "eclipse public license - v 1.0"},
+	{"EPL-1.0", "epl-1.0"},
+	{"MPL-2.0", "mozilla public license, version 2.0"},
+	{"MPL-2.0", "mpl-2.0"},
+	{"BSD-3-Clause", "bsd-3-clause"},
+	{"BSD-3-Clause", "new bsd license"},
+	{"BSD-2-Clause", "bsd-2-clause"},
+	{"MIT", "mit license"},
+	{"MIT", "opensource.org/licenses/mit"},
+}
+
+// spdx guesses the SPDX identifier of a license, or returns "".
+func (a pomLicense) spdx() string {
+	for _, s := range []string{a.Name, a.URL} {
+		s = strings.ToLower(s)
+		for _, n := range spdxNames {
+			if strings.Contains(s, n.match) {
+				return n.id
+			}
+		}
+		if strings.EqualFold(strings.TrimSpace(s), "mit") {
+			return "MIT"
+		}
+	}
+	return ""
+}
+
+// is reports whether the license is id, an SPDX identifier or name.
+func (a pomLicense) is(id string) bool {
+	return strings.EqualFold(a.SPDX, id) ||
+		strings.EqualFold(strings.TrimSpace(a.Name), id)
+}
+
+// licenseEntry lists the licenses of a resolved artifact, none if unknown.
+type licenseEntry struct {
+	Coordinates string       `json:"coordinates"`
+	Licenses    []pomLicense `json:"licenses"`
+}
+
+// licenseFlags collect the licenses of dependencies.
+type licenseFlags struct {
+	report *string
+	format *string
+	forbid []string
+}
+
+func newLicenseFlags(fs *flag.FlagSet) *licenseFlags {
+	a := &licenseFlags{
+		report: fs.String("license-report", "", "Write the licenses "+
+			"of all dependencies to this file"),
+		format: fs.String("license-format", "", "Format of "+
+			"-license-report: csv or json, default by file extension"),
+	}
+	fs.Func("forbid", "Fail if a dependency has this license, SPDX "+
+		"identifier or name, may be repeated", func(s string) error {
+		a.forbid = append(a.forbid, s)
+		return nil
+	})
+	return a
+}
+
+func (a *licenseFlags) active() bool {
+	return *a.report != "" || len(a.forbid) > 0
+}
+
+// licenses returns the licenses of gavs from their POMs.
+func licenses(pl *pomLoader, gavs []Gav) ([]licenseEntry, error) {
+	var es []licenseEntry
+	for _, gav := range gavs {
+		e := licenseEntry{Coordinates: gav.ConciseNotation(),
+			Licenses: []pomLicense{}}
+		p, err := pl.fetch(gav)
+		if nexus.IsNotFound(err) {
+			log.Printf("%s: no POM, license unknown\n", e.Coordinates)
+		} else if err != nil {
+			return nil, err
+		} else {
+			for _, l := range p.Licenses {
+				l.SPDX = l.spdx()
+				e.Licenses = append(e.Licenses, l)
+			}
+		}
+		es = append(es, e)
+	}
+	return es, nil
+}
+
+func writeLicenses(w io.Writer, es []licenseEntry, format string) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(es)
+	case "csv":
+		cw := csv.NewWriter(w)
+		cw.Write([]string{"coordinates", "spdx", "name", "url"})
+		for _, e := range es {
+			if len(e.Licenses) == 0 {
+				cw.Write([]string{e.Coordinates, "", "", ""})
+			}
+			for _, l := range e.Licenses {
+				cw.Write([]string{e.Coordinates, l.SPDX, l.Name, l.URL})
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+	return fmt.Errorf("unknown license report format %q", format)
+}
+
+// check writes the report and fails on forbidden licenses.
+func (a *licenseFlags) check(pl *pomLoader, gavs []Gav) error {
+	if !a.active() {
+		return nil
+	}
+	es, err := licenses(pl, gavs)
+	if err != nil {
+		return err
+	}
+	if *a.report != "" {
+		format := *a.format
+		if format == "" {
+			format = strings.TrimPrefix(filepath.Ext(*a.report), ".")
+		}
+		f, err := os.Create(*a.report)
+		if err != nil {
+			return err
+		}
+		err = writeLicenses(f, es, format)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return err
+		}
+	}
+	var violations []string
+	for _, e := range es {
+		for _, l := range e.Licenses {
+			for _, id := range a.forbid {
+				if l.is(id) {
+					violations = append(violations,
+						e.Coordinates+" ("+l.Name+")")
+				}
+			}
+		}
+	}
+	if len(violations) > 0 {
+		return fmt.Errorf("forbidden licenses: %s",
+			strings.Join(violations, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"testing"
+)
+
+func TestSPDX(t *testing.T) {
+	for _, tt := range []struct{ name, url, want string }{
+		{"The Apache Software License, Version 2.0",
+			"https://www.apache.org/licenses/LICENSE-2.0.txt", "Apache-2.0"},
+		{"GNU Lesser General Public License v3.0", "", "LGPL-3.0"},
+		{"GNU General Public License v3.0", "", "GPL-3.0"},
+		{"MIT", "", "MIT"},
+		{"Custom", "https://opensource.org/licenses/MIT", "MIT"},
+		{"Proprietary", "", ""},
+	} {
+		if got := (pomLicense{Name: tt.name, URL: tt.url}).spdx(); got !=
+			tt.want {
+			t.Fatalf("%s: Expected %q but got %q\n", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestLicenses(t *testing.T) {
+	_, inst := newFakeNexus(t,
+		pomArtifact("com.acme", "parent", "1", `<project>
+  <licenses><license><name>Apache License, Version 2.0</name></license>
+  </licenses></project>`),
+		pomArtifact("com.acme", "lib", "1.0", `<project>
+  <parent><groupId>com.acme</groupId><artifactId>parent</artifactId>
+    <version>1</version></parent>
+  <artifactId>lib</artifactId></project>`),
+		pomArtifact("org.gnu", "gpl", "1.0", `<project>
+  <licenses><license><name>GPL-3.0</name></license></licenses>
+</project>`))
+	pl := newPomLoader(context.Background(),
+		[]NexusRepository{{NexusInstance: inst, RepositoryID: "releases"}})
+	gavs := []Gav{{Group: "com.acme", Artifact: "lib", Version: "1.0"},
+		{Group: "org.gnu", Artifact: "gpl", Version: "1.0"},
+		{Group: "com.acme", Artifact: "nopom", Version: "1.0"}}
+	es, err := licenses(pl, gavs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := writeLicenses(&buf, es, "csv"); err != nil {
+		t.Fatal(err)
+	}
+	want := "coordinates,spdx,name,url\n" +
+		"com.acme:lib:1.0,Apache-2.0,\"Apache License, Version 2.0\",\n" +
+		"org.gnu:gpl:1.0,GPL-3.0,GPL-3.0,\n" +
+		"com.acme:nopom:1.0,,,\n"
+	if buf.String() != want {
+		t.Fatalf("Expected %q but got %q\n", want, buf.String())
+	}
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	lf := newLicenseFlags(fs)
+	fs.Parse([]string{"-forbid", "gpl-3.0"})
+	if err := lf.check(pl, gavs); err == nil {
+		t.Fatalf("Expected forbidden license but got nil\n")
+	}
+}
@@ -0,0 +1,29 @@
+//go:build windows
+
+package main
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// maxPath is MAX_PATH minus the room Windows reserves for a file name in a
+// directory path.
+const maxPath = 248
+
+// longPath returns p in the \\?\ form that lifts the MAX_PATH limit if its
+// absolute form is too long. Deep groupIds in output trees exceed it
+// easily.
+func longPath(p string) string {
+	if strings.HasPrefix(p, `\\?\`) {
+		return p
+	}
+	abs, err := filepath.Abs(p)
+	if err != nil || len(abs) < maxPath {
+		return p
+	}
+	if strings.HasPrefix(abs, `\\`) {
+		return `\\?\UNC\` + abs[2:]
+	}
+	return `\\?\` + abs
+}
@@ -0,0 +1,24 @@
+//go:build windows
+
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLongPath(t *testing.T) {
+	short := `out\a.jar`
+	if got := longPath(short); got != short {
+		t.Fatalf("Expected %s but got %s\n", short, got)
+	}
+	long := `C:\out\` + strings.Repeat(`com\acme\`, 30) + "a.jar"
+	if want, got := `\\?\`+long, longPath(long); want != got {
+		t.Fatalf("Expected %s but got %s\n", want, got)
+	}
+	unc := `\\server\share\` + strings.Repeat(`com\acme\`, 30) + "a.jar"
+	want := `\\?\UNC\server\share\` + unc[15:]
+	if got := longPath(unc); want != got {
+		t.Fatalf("Expected %s but got %s\n", want, got)
+	}
+}
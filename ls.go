@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+)
+
+// ls writes the entries of a repository directory with size and date,
+// descending into subdirectories if recursive.
+func ls(w io.Writer, repo NexusRepository, dir string, recursive bool) error {
+	items, err := listContent(repo, dir)
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		name := strings.TrimLeft(item.RelativePath, "/")
+		if !recursive {
+			name = item.Name
+		}
+		size := "-"
+		if item.Leaf {
+			size = humanSize(item.Size)
+		} else {
+			name += "/"
+		}
+		fmt.Fprintf(w, "%10s  %-27s  %s\n", size, item.LastModified, name)
+		if recursive && !item.Leaf {
+			if err := ls(w, repo, path.Join(dir, item.Name),
+				recursive); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func lsCommand(args []string) {
+	fs := newCommand("ls", "<repository>/[path]")
+	nf := newNexusFlags(fs)
+	recursive := fs.Bool("R", false, "List subdirectories recursively")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+	}
+
+	// the repository is part of the path
+	p := strings.TrimLeft(fs.Arg(0), "/")
+	repo := nf.repo()
+	repo.RepositoryID = p
+	var dir string
+	if i := strings.Index(p, "/"); i >= 0 {
+		repo.RepositoryID, dir = p[:i], p[i+1:]
+	}
+	if err := ls(os.Stdout, repo, dir, *recursive); err != nil {
+		fail(err)
+	}
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jhinrichsen/nexus-fetch/nexusfetchtest"
+)
+
+func TestLsRecursive(t *testing.T) {
+	uploaded := time.Date(2018, 3, 12, 17, 39, 14, 0, time.UTC)
+	_, inst := newFakeNexus(t,
+		nexusfetchtest.Artifact{Repository: "releases", Group: "com.acme",
+			Artifact: "app", Version: "1.0", Content: []byte("12345"),
+			Uploaded: uploaded})
+	var sb strings.Builder
+	err := ls(&sb, NexusRepository{NexusInstance: inst,
+		RepositoryID: "releases"}, "com/acme", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "         -  2018-03-12 17:39:14.0 UTC    com/acme/app/\n" +
+		"         -  2018-03-12 17:39:14.0 UTC    com/acme/app/1.0/\n" +
+		"        5B  2018-03-12 17:39:14.0 UTC    " +
+		"com/acme/app/1.0/app-1.0.jar\n"
+	if sb.String() != want {
+		t.Fatalf("Expected\n%s but got\n%s\n", want, sb.String())
+	}
+}
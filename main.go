@@ -5,22 +5,39 @@
 // return codes:
 //  1: unspecific error
 //  2: wrong usage
-//  4: nothing found if abort on empty search result enabled (mimicking 404)
+//  4: nothing found or a download returns 404, if abort on not found is
+//     enabled
+//  5: authentication or authorization failure (HTTP 401/403)
+//  6: network failure
+//  7: corrupt download
+//  8: partial success, some artifacts failed (-keep-going)
+//  9: blocked by a repository firewall policy (quarantine)
+//  130: interrupted by SIGINT or SIGTERM
+//
+// The [exit-codes] section of the configuration file remaps them, see
+// -explain-exit-codes.
 
 package main
 
 import (
+	"context"
 	"encoding/xml"
 	"flag"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"net/http"
-	"net/url"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
+	"syscall"
+	"time"
+
+	"github.com/jhinrichsen/nexus-fetch/nexus"
 )
 
 const (
@@ -32,236 +49,97 @@ const (
 	defaultRepository = "releases"
 )
 
-// NexusInstance holds coordinates of a Nexus installation
-type NexusInstance struct {
-	Protocol    string
-	Server      string
-	Port        string
-	Contextroot string
-	Username    string
-	Password    string
-}
-
-// NexusRepository holds coordinates of a Nexus repository
-type NexusRepository struct {
-	NexusInstance
-	RepositoryID string
-}
-
-type searchNGResponse struct {
-	// Count is just a copy of the 'count' request value
-	Count int `xml:"count"`
-	// From is just a copy of the 'from' request value
-	From           int  `xml:"from"`
-	TotalCount     int  `xml:"totalCount"`
-	TooManyResults bool `xml:"tooManyResults"`
-	Artifacts      []struct {
-		Group        string `xml:"groupId"`
-		Artifact     string `xml:"artifactId"`
-		Version      string `xml:"version"`
-		ArtifactHits []struct {
-			RepositoryID  string `xml:"repositoryId"`
-			ArtifactLinks []struct {
-				Packaging  string `xml:"extension"`
-				Classifier string `xml:"classifier"`
-			} `xml:"artifactLinks>artifactLink"`
-		} `xml:"artifactHits>artifactHit"`
-	} `xml:"data>artifact"`
-}
-
-// Gav are the standard Maven coordinates
-type Gav struct {
-	Group      string `xml:"groupId"`
-	Artifact   string `xml:"artifactId"`
-	Version    string `xml:"version"`
-	Classifier string `xml:"classifier"`
-	Packaging  string `xml:"packaging"`
-}
-
-// Fqa holds coordinates to a fully qualified artifact
-type Fqa struct {
-	NexusRepository
-	Gav
-}
-
-// ContentURL return a fetchable URL
-func (a Fqa) ContentURL() string {
-	s := baseUrl(a.NexusRepository).String()
-	s += fmt.Sprintf("content/repositories/%s/%s",
-		a.RepositoryID, a.DefaultLayout())
-	return s
-}
-
-// RedirectURL returns a REST URL that will redirect to the specific version
-// such as LATEST, SNAPSHOT, ...
-func (a Fqa) RedirectURL() string {
-	s := baseUrl(a.NexusRepository).String()
-	s += fmt.Sprintf("service/local/artifact/maven/redirect"+
-		"?r=%s&g=%s&a=%s&v=%s&p=%s",
-		a.RepositoryID,
-		a.Group, a.Artifact, a.Version, a.Packaging)
-	return s
-}
-
-// Concise converts a coordinate in GAV notation into concise notation.
-func (a Gav) ConciseNotation() string {
-	var sb strings.Builder
-	if len(a.Group) > 0 {
-		sb.WriteString(a.Group)
-	}
-	if len(a.Artifact) > 0 || len(a.Version) > 0 || len(a.Classifier) > 0 {
-		sb.WriteString(":")
-	}
-	if len(a.Artifact) > 0 {
-		sb.WriteString(a.Artifact)
-	}
-	if len(a.Version) > 0 || len(a.Classifier) > 0 {
-		sb.WriteString(":")
-	}
-	if len(a.Version) > 0 {
-		sb.WriteString(a.Version)
-	}
-	if len(a.Classifier) > 0 {
-		sb.WriteString(":")
-		sb.WriteString(a.Classifier)
-	}
-	if len(a.Packaging) > 0 {
-		sb.WriteString("@")
-		sb.WriteString(a.Packaging)
-	}
-	return sb.String()
-}
-
-// Concise converts a Maven coordinate in concise notation into a GAV
-func Concise(c string) Gav {
-	var gav Gav
-	cs := strings.Split(c, "@")
-	if len(cs) > 1 {
-		gav.Packaging = cs[1]
-		c = cs[0]
+// withoutPoms ignores POMs
+func withoutPoms(ls []Fqa) []Fqa {
+	var as []Fqa
+	for _, a := range ls {
+		if a.Gav.Packaging != "pom" {
+			as = append(as, a)
+		}
 	}
-	cs = strings.Split(c, ":")
-	switch len(cs) {
-	case 1:
-		gav.Group = cs[0]
-	case 2:
-		gav.Group = cs[0]
-		gav.Artifact = cs[1]
-	case 3:
-		gav.Group = cs[0]
-		gav.Artifact = cs[1]
-		gav.Version = cs[2]
-	case 4:
-		gav.Group = cs[0]
-		gav.Artifact = cs[1]
-		gav.Version = cs[2]
-		gav.Classifier = cs[3]
-	}
-	return gav
-}
-
-// DefaultLayout translates a Gav into a file system hierarchy without leading /
-func (a Gav) DefaultLayout() string {
-	return fmt.Sprintf("%s/%s/%s/%s",
-		strings.Replace(a.Group, ".", "/", -1),
-		a.Artifact,
-		a.Version,
-		a.Filename())
+	return as
 }
 
-// Filename returns the basename part of a GAV default layout
-func (a Gav) Filename() string {
-	filename := fmt.Sprintf("%s-%s", a.Artifact, a.Version)
-	if a.Classifier != "" {
-		filename = fmt.Sprintf("%s-%s", filename, a.Classifier)
-	}
-	if a.Packaging == "" {
-		a.Packaging = "jar"
+// dedup removes repeated hits of the same GAV, classifier and packaging,
+// keeping the first. With perRepository, hits in different repositories are
+// kept apart.
+func dedup(ls []Fqa, perRepository bool) ([]Fqa, error) {
+	seen := make(map[Fqa]bool)
+	var as []Fqa
+	for _, a := range ls {
+		key := Fqa{Gav: a.Gav}
+		if perRepository {
+			key.RepositoryID = a.RepositoryID
+		}
+		if seen[key] {
+			if err := warn("%s found again in %s, skipping it",
+				a.Gav.ConciseNotation(), a.RepositoryID); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		seen[key] = true
+		as = append(as, a)
 	}
-	return fmt.Sprintf("%s.%s", filename, a.Packaging)
+	return as, nil
 }
 
-// LuceneSearch builds a request path for given GAV
-func (a Gav) LuceneSearch() string {
-	url := ""
-	if a.Group != "" {
-		url += fmt.Sprintf("g=%s", a.Group)
+// searchAll collects search results from all repositories, a repository
+// without ID searches globally.
+func searchAll(repos []NexusRepository, gav Gav, opts ...Option) ([]Fqa,
+	error) {
+	if err := unsupported("search"); err != nil {
+		return nil, err
 	}
-	if a.Artifact != "" {
-		url += fmt.Sprintf("&a=%s", a.Artifact)
-	}
-	if a.Version != "" {
-		url += fmt.Sprintf("&v=%s", a.Version)
-	}
-	if a.Packaging != "" {
-		url += fmt.Sprintf("&p=%s", a.Packaging)
-	}
-	if a.Classifier != "" {
-		url += fmt.Sprintf("&c=%s", a.Classifier)
+	var ls []Fqa
+	for _, r := range repos {
+		it, err := newClient(r, opts...).SearchIter(gav)
+		if err != nil {
+			return nil, err
+		}
+		for it.Next() {
+			ls = append(ls, it.Fqa())
+		}
+		if err := it.Err(); err != nil {
+			return nil, err
+		}
 	}
-	return url
+	return ls, nil
 }
 
-// search executes Nexus REST search, optionally multiple times to find
-// every match
-// returns a boolean to indicate if the search has been complete, or if too many
-// wildcards have been used that confuse Nexus
-func search(repo NexusRepository, gav Gav) searchNGResponse {
-	params := gav.LuceneSearch()
-	s := baseUrl(repo).String()
-	s += fmt.Sprintf("service/local/lucene/search?%s", params)
-	if repo.RepositoryID != "" {
-		s += fmt.Sprintf("&repositoryId=%s", repo.RepositoryID)
-	}
-	response, err := http.Get(s)
-	if err != nil {
-		log.Fatalf("Cannot read url %v: %v\n", s, err)
+// without ignores hits from excluded repositories, such as proxies of
+// Maven Central.
+func without(ls []Fqa, repositoryIDs []string) []Fqa {
+	if len(repositoryIDs) == 0 {
+		return ls
 	}
-	log.Printf("%v returns HTTP status code %v\n",
-		s, response.StatusCode)
-	if response.StatusCode != 200 {
-		log.Fatalf("Expected status 200 but got %v\n",
-			response.StatusCode)
-	}
-	log.Printf("Header: %+v\n", response.Header)
-	defer response.Body.Close()
-	body, err := ioutil.ReadAll(response.Body)
-	log.Println(string(body))
-	if err != nil {
-		log.Fatal(err)
+	excluded := make(map[string]bool)
+	for _, id := range repositoryIDs {
+		excluded[id] = true
 	}
-	var found searchNGResponse
-	err = xml.Unmarshal(body, &found)
-	if err != nil {
-		log.Fatal(err)
+	var as []Fqa
+	for _, a := range ls {
+		if !excluded[a.RepositoryID] {
+			as = append(as, a)
+		}
 	}
-	log.Printf("search returns count=%d, total count=%d, "+
-		"overflow=%v, artifacts=%d\n",
-		found.Count, found.TotalCount, found.TooManyResults,
-		len(found.Artifacts))
-
-	return found
+	return as
 }
 
-func locations(res searchNGResponse, inst NexusInstance) []Fqa {
-	var ls []Fqa
-	for _, a := range res.Artifacts {
-		fmt.Printf("%+v\n", a)
-		for _, hit := range a.ArtifactHits {
-			for _, link := range hit.ArtifactLinks {
-				gav := Gav{a.Group, a.Artifact, a.Version,
-					link.Classifier, link.Packaging,
-				}
-				ls = append(ls, Fqa{
-					NexusRepository: NexusRepository{
-						inst,
-						hit.RepositoryID},
-					Gav: gav,
-				})
-			}
+// locate returns coordinates in the first repository that contains them.
+func locate(fqa Fqa, repos []NexusRepository) (Fqa, bool, error) {
+	for _, r := range repos {
+		fqa.NexusRepository = r
+		res, err := resolve(fqa)
+		if err != nil {
+			return fqa, false, err
+		}
+		res.Body.Close()
+		if res.StatusCode == http.StatusOK {
+			return fqa, true, nil
 		}
 	}
-	return ls
+	return fqa, false, nil
 }
 
 func fullySpecified(fqa Fqa) bool {
@@ -273,147 +151,270 @@ func fullySpecified(fqa Fqa) bool {
 	return complete
 }
 
-func baseUrl(repo NexusRepository) *url.URL {
-	s := fmt.Sprintf("%s://%s:%s/%s",
-		repo.Protocol, repo.Server, repo.Port, repo.Contextroot)
-	log.Printf("base URL: %s\n", s)
-	u, err := url.Parse(s)
-	if err != nil {
-		log.Fatalf("cannot parse URL %s: %v\n", s, err)
-	}
-	return u
+// mavenURL returns the URL of a Maven REST endpoint such as resolve or
+// content for given coordinates.
+func mavenURL(endpoint string, coords Fqa) string {
+	return nexus.MavenURL(serverType, endpoint, coords)
 }
 
-// return HTTP status code
-func resolve(coords Fqa) *http.Response {
-	u := baseUrl(coords.NexusRepository)
-	u2, err := u.Parse("service/local/artifact/maven/resolve")
-	q := u2.Query()
-	q.Add("r", coords.NexusRepository.RepositoryID)
-	gav := coords.Gav
-	if len(gav.Group) > 0 {
-		q.Set("g", gav.Group)
-	}
-	if len(gav.Artifact) > 0 {
-		q.Set("a", gav.Artifact)
+// contentURL returns the URL of coords in the Maven layout of their
+// repository.
+func contentURL(coords Fqa) string {
+	return nexus.ContentURL(serverType, coords)
+}
+
+// artifactResolution is the answer of the resolve API.
+type artifactResolution struct {
+	Version        string `xml:"data>version"`
+	RepositoryPath string `xml:"data>repositoryPath"`
+}
+
+// repositoryPath returns the storage location of an artifact inside its
+// repository as reported by the resolve API. Without REST API, the path of
+// the default layout is assumed.
+func repositoryPath(ctx context.Context, fqa Fqa) (string, error) {
+	if layoutOnly() {
+		return "/" + fqa.DefaultLayout(), nil
 	}
-	if len(gav.Version) > 0 {
-		q.Set("v", gav.Version)
+	res, err := getAs(ctx, fqa.NexusInstance, mavenURL("resolve", fqa))
+	if err != nil {
+		return "", err
 	}
-	if len(gav.Classifier) > 0 {
-		q.Set("c", gav.Classifier)
+	defer res.Body.Close()
+	var r artifactResolution
+	if err := xml.NewDecoder(res.Body).Decode(&r); err != nil {
+		return "", err
 	}
-	if len(gav.Packaging) > 0 {
-		q.Set("p", gav.Packaging)
+	return r.RepositoryPath, nil
+}
+
+// resolve requests the resolve API for coords and returns the response
+// whatever its status code, the caller checks it.
+func resolve(coords Fqa) (*http.Response, error) {
+	if err := unsupported("resolve"); err != nil {
+		return nil, err
 	}
-	u2.RawQuery = q.Encode()
-	log.Printf("getting %s\n", u2.String())
-	res, err := http.Get(u2.String())
+	u := mavenURL("resolve", coords)
+	log.Printf("getting %s\n", u)
+	res, err := httpClient.Get(u)
 	if err != nil {
-		log.Fatalf("Cannot read url %v: %v\n", u2, err)
+		return nil, fmt.Errorf("cannot read url %v: %v", u, err)
 	}
 	log.Printf("%v returns HTTP status code %v\n",
-		u2, res.StatusCode)
-	return res
+		u, res.StatusCode)
+	return res, nil
 }
 
-// return HTTP status code
-func content(coords Fqa) *http.Response {
-	u := baseUrl(coords.NexusRepository)
-	u2, err := u.Parse("service/local/artifact/maven/content")
-	q := u2.Query()
-	q.Add("r", coords.NexusRepository.RepositoryID)
-	gav := coords.Gav
-	if len(gav.Group) > 0 {
-		q.Set("g", gav.Group)
-	}
-	if len(gav.Artifact) > 0 {
-		q.Set("a", gav.Artifact)
-	}
-	if len(gav.Version) > 0 {
-		q.Set("v", gav.Version)
-	}
-	if len(gav.Classifier) > 0 {
-		q.Set("c", gav.Classifier)
+// get requests u and fails for any status but 200. Cancelling ctx aborts
+// the request including reading its body.
+func get(ctx context.Context, u string) (*http.Response, error) {
+	return getAs(ctx, NexusInstance{}, u)
+}
+
+// getAs is get using the credentials of inst, if any. Credentials are kept
+// when following redirects within the same host, such as the snapshot
+// redirect to the timestamped file.
+func getAs(ctx context.Context, inst NexusInstance, u string) (
+	*http.Response, error) {
+	log.Printf("getting %s\n", u)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
 	}
-	if len(gav.Packaging) > 0 {
-		q.Set("p", gav.Packaging)
+	if inst.Username != "" {
+		req.SetBasicAuth(inst.Username, inst.Password)
 	}
-	u2.RawQuery = q.Encode()
-	log.Printf("getting %s\n", u2.String())
-	res, err := http.Get(u2.String())
+	res, err := httpClient.Do(req)
 	if err != nil {
-		log.Fatalf("Cannot read url %v: %v\n", u2, err)
+		return nil, err
 	}
 	log.Printf("%v returns HTTP status code %v\n",
-		u2, res.StatusCode)
+		u, res.StatusCode)
 	if res.StatusCode != 200 {
-		log.Fatalf("Expected status 200 but got %v\n",
-			res.StatusCode)
+		defer res.Body.Close()
+		if err := quarantined(u, res); err != nil {
+			return nil, err
+		}
+		return nil, &StatusError{URL: u, StatusCode: res.StatusCode}
 	}
-	return res
+	return res, nil
+}
+
+func content(ctx context.Context, coords Fqa) (*http.Response, error) {
+	return get(ctx, mavenURL("content", coords))
 }
 
 func print(res *http.Response) {
 	defer res.Body.Close()
 	body, err := ioutil.ReadAll(res.Body)
 	if err != nil {
-		log.Fatal(err)
+		fail(err)
 	}
 	fmt.Println(string(body))
 }
 
-func persistBody(res *http.Response, outputDirectory, outputFilename string) {
-	defer res.Body.Close()
-	buf, err := ioutil.ReadAll(res.Body)
+// checkSize aborts early if an announced download would exceed either the
+// user supplied maximum or the space available in the output directory.
+// An unknown size (-1) cannot be checked upfront.
+func checkSize(size, maxSize int64, outputDirectory string) error {
+	if size < 0 {
+		return nil
+	}
+	if maxSize > 0 && size > maxSize {
+		return fmt.Errorf("download size %s exceeds maximum size %s",
+			humanSize(size), humanSize(maxSize))
+	}
+	free, err := freeSpace(outputDirectory)
 	if err != nil {
-		log.Fatal(err)
+		return fmt.Errorf("cannot determine free space in %s: %v",
+			outputDirectory, err)
+	}
+	if free >= 0 && size > free {
+		return fmt.Errorf("download size %s exceeds available space %s "+
+			"in %s", humanSize(size), humanSize(free), outputDirectory)
 	}
+	return nil
+}
+
+// persistBody writes the response body and returns the resulting path.
+func persistBody(res *http.Response, outputDirectory, outputFilename string,
+	maxSize int64) (string, error) {
+	defer res.Body.Close()
 	f := filepath.Join(outputDirectory, outputFilename)
+	// create missing output directories and trees of output layouts
+	if err := os.MkdirAll(longPath(filepath.Dir(f)), 0755); err != nil {
+		return "", err
+	}
+	if err := checkSize(res.ContentLength, maxSize,
+		outputDirectory); err != nil {
+		return "", err
+	}
 	log.Printf("writing %s\n", f)
-	if err := ioutil.WriteFile(f, buf, 0644); err != nil {
-		log.Fatal(err)
+	out, err := os.Create(longPath(f))
+	if err != nil {
+		return "", err
+	}
+	var r io.Reader = res.Body
+	if maxSize > 0 {
+		// Content-Length may be missing, enforce limit while streaming
+		r = io.LimitReader(res.Body, maxSize+1)
 	}
+	n, err := io.Copy(out, r)
+	if err == nil {
+		err = out.Close()
+	} else {
+		out.Close()
+	}
+	if err == nil && maxSize > 0 && n > maxSize {
+		err = fmt.Errorf("download exceeds maximum size %s",
+			humanSize(maxSize))
+	}
+	if err != nil {
+		os.Remove(longPath(f))
+		return "", err
+	}
+	return f, nil
+}
+
+// validate optionally checks integrity of zip based downloads.
+func validate(f string, enabled bool) error {
+	if !enabled || !isArchive(f) {
+		return nil
+	}
+	log.Printf("validating archive %s\n", f)
+	if err := validateArchive(f); err != nil {
+		return &integrityError{fmt.Errorf("corrupt archive: %v", err)}
+	}
+	return nil
+}
+
+// pomOf returns the POM belonging to an artifact, or false if a is a POM
+// itself.
+func pomOf(a Fqa) (Fqa, bool) {
+	if a.Packaging == "pom" && a.Classifier == "" {
+		return a, false
+	}
+	a.Packaging = "pom"
+	a.Classifier = ""
+	return a, true
+}
+
+// expandName replaces placeholders in a user supplied filename so that
+// fetching multiple artifacts yields multiple files. n counts from 1.
+func expandName(pattern string, n int, gav Gav) string {
+	return strings.NewReplacer(
+		"{n}", strconv.Itoa(n),
+		"{group}", gav.Group,
+		"{artifact}", gav.Artifact,
+		"{version}", gav.Version,
+		"{classifier}", gav.Classifier,
+		"{ext}", gav.Extension()).Replace(pattern)
 }
 
-// extract filename from Content-Disposition header, format:
-// attachment; filename="helloworld-1.0.0-20180312.173914-4.jar"
-func contentDisposition(res *http.Response) string {
-	v := res.Header.Get("Content-Disposition")
-	r := regexp.MustCompile(`attachment; filename="(.*)"`)
-	ss := r.FindStringSubmatch(v)
-	return ss[1]
+// uniqueName reports whether a user supplied filename is different for
+// each artifact.
+func uniqueName(pattern string) bool {
+	return strings.Contains(pattern, "{n}") ||
+		strings.Contains(pattern, "{artifact}") &&
+			strings.Contains(pattern, "{version}")
 }
 
-// Pick an output filename: user supplied > response > gav
-func filename(userSupplied string, res *http.Response, gav Gav) string {
-	f := userSupplied
-	if len(f) > 0 {
-		return f
+// Pick an output filename: user supplied > response > redirect target > gav
+func filename(userSupplied string, res *http.Response, gav Gav) (string,
+	error) {
+	if len(userSupplied) > 0 {
+		return userSupplied, nil
+	}
+	// plain repository paths name the file, the REST API should say it
+	if nexus.ContentDisposition(res) == "" && res.Request != nil &&
+		res.Request.Response == nil &&
+		strings.Contains(res.Request.URL.Path,
+			"service/local/artifact/maven/") {
+		if err := warn("%s sent no Content-Disposition, saving as %s",
+			res.Request.URL, gav.Filename()); err != nil {
+			return "", err
+		}
+	}
+	return nexus.ResponseFilename(res, gav), nil
+}
+
+var timestampedVersion = regexp.MustCompile(`^\d{8}\.\d{6}-\d+`)
+
+// resolvedVersion returns the timestamped version of a snapshot as found
+// in the name of a fetched file, or "" if it cannot be determined.
+func resolvedVersion(gav Gav, file string) string {
+	if !strings.HasSuffix(gav.Version, "SNAPSHOT") || file == "" {
+		return ""
+	}
+	prefix := gav.Artifact + "-" + strings.TrimSuffix(gav.Version,
+		"SNAPSHOT")
+	base := filepath.Base(file)
+	if !strings.HasPrefix(base, prefix) {
+		return ""
 	}
-	f = contentDisposition(res)
-	if len(f) > 0 {
-		return f
+	ts := timestampedVersion.FindString(strings.TrimPrefix(base, prefix))
+	if ts == "" {
+		return ""
 	}
-	return gav.Filename()
+	return strings.TrimSuffix(gav.Version, "SNAPSHOT") + ts
 }
 
 func main() {
-	var (
-		// Nexus coordinates
-		protocol = flag.String("protocol", "http", "Nexus protocol")
-		server   = flag.String("server", defaultServer,
-			"Nexus server name")
-		port        = flag.String("port", defaultPort, "Nexus port")
-		contextroot = flag.String("contextroot", "nexus/",
-			"Nexus context root")
-		username = flag.String("username", defaultUsername,
-			"Nexus user")
-		password = flag.String("password", defaultPassword,
-			"Nexus password")
-		repository = flag.String("repository", defaultRepository,
-			"Nexus repository ID, empty for global search")
+	defer har.close()
+	if sched, health, args := scheduleArgs(os.Args[1:]); sched != "" {
+		runScheduled(sched, health, args)
+		return
+	}
+	if len(os.Args) > 1 {
+		if cmd, ok := commands[os.Args[1]]; ok {
+			cmd(os.Args[2:])
+			return
+		}
+	}
 
+	nf := newNexusFlags(flag.CommandLine)
+	srCache.flags(flag.CommandLine)
+	var (
 		// Search coordinates
 		group      = flag.String("group", "", "Maven group")
 		artifact   = flag.String("artifact", "", "Maven artifact")
@@ -423,78 +424,420 @@ func main() {
 
 		abortOnNotFound = flag.Bool(
 			"abortOnNotFound", false,
-			"Return 4 if nothing found")
+			"Return 4 if nothing is found or a download returns 404")
 		fetch          = flag.Bool("fetch", true, "Download files found")
 		outputDir      = flag.String("outputDir", ".", "Download directory")
 		outputFilename = flag.String("outputFilename", "",
-			"Download filename, defaults to original artifact name")
+			"Download filename, defaults to original artifact name, "+
+				"may contain {n}, {group}, {artifact}, {version}, "+
+				"{classifier} and {ext}")
+		validateArchives = flag.Bool("validate-archive", false,
+			"Verify central directory and CRCs of jar/war/ear/zip downloads")
+		validateCoords = flag.Bool("validate-coordinates", false,
+			"Verify that downloaded POMs and the pom.properties of "+
+				"archives declare the requested coordinates")
+		interactive = flag.Bool("interactive", false,
+			"Choose which artifacts to fetch if a search has "+
+				"multiple results")
+		dryRun = flag.Bool("dry-run", false,
+			"Print what would be fetched without downloading")
+		nt      = newNotifier(flag.CommandLine)
+		prog    = newProgress(flag.CommandLine)
+		att     = newAttestation(flag.CommandLine)
+		out     = newPrinter(flag.CommandLine)
+		filters = newFilter(flag.CommandLine)
+		order   = newSorter(flag.CommandLine)
+		lay     = newLayouts(flag.CommandLine)
+		exclude repositoryIDs
+		policy  = flag.Bool("auto-repository", false,
+			"Switch to a repository matching the version's release or "+
+				"snapshot policy instead of just warning")
+		keepGoing = flag.Bool("keep-going", false,
+			"Continue after failed downloads and summarize failures, "+
+				"default is to stop at the first failure")
+		reportFile = flag.String("report", "",
+			"Write a JSON report of all downloads to this file")
+		resume = flag.String("resume-report", "",
+			"Re-attempt only failed downloads of a previous JSON report")
+		chunks = flag.Int("chunks", 1, "Download each artifact using "+
+			"this many parallel range requests, if the server supports it")
+		perRepository = flag.Bool("per-repository", false,
+			"Keep hits of the same artifact in different repositories "+
+				"instead of fetching it once")
+		stats = flag.Bool("stats", false, "Print size, elapsed time "+
+			"and throughput per download and in total to stderr")
+		withPom = flag.Bool("with-pom", false,
+			"Also download the POM of each fetched artifact")
+		tag = flag.String("tag", "", "Only search components with this "+
+			"Nexus 3 Pro tag, e.g. a build identifier")
+		runDir = flag.String("run-dir", "", "Create a timestamped "+
+			"directory per run below this one, holding downloads, "+
+			"report, log and run info")
+		defaultClassifier = flag.String("default-classifier", "",
+			"Classifier of coordinates without one, e.g. linux-x86_64")
+		explain = flag.Bool("explain-exit-codes", false, "Describe exit "+
+			"codes and their mapping in the configuration file")
+		noRelocation = flag.Bool("no-relocation", false,
+			"Fail on relocated coordinates instead of following them")
 	)
+	report := newRunReport()
+	flag.Var(&exclude, "exclude-repository",
+		"Ignore hits from these repository IDs in global searches, "+
+			"comma separated or repeated")
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage: %s <GAV in concise notation>\n",
 			os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s <command> -h, commands: %s\n",
+			os.Args[0], commandNames())
+		fmt.Fprintf(os.Stderr, "  -schedule '0 3 * * *' [-health-listen "+
+			"addr] runs any command on a cron schedule\n")
 		flag.PrintDefaults()
-		os.Exit(2)
+		exit(2)
 	}
 	flag.Parse()
+	if *explain {
+		if err := explainExitCodes(os.Stdout); err != nil {
+			fail(err)
+		}
+		return
+	}
+	// cancel in-flight downloads on Ctrl-C or termination, which removes
+	// partial files
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt,
+		syscall.SIGTERM)
+	defer stop()
+	for _, v := range []interface{ validate() error }{out, order, lay,
+		prog, att, nt} {
+		if err := v.validate(); err != nil {
+			log.Println(err)
+			flag.Usage()
+		}
+	}
+	if *runDir != "" {
+		if _, err := startRun(*runDir, outputDir, reportFile); err != nil {
+			fail(err)
+		}
+	}
+	if err := prog.open(); err != nil {
+		fail(err)
+	}
 
-	inst := NexusInstance{*protocol, *server, *port, *contextroot,
-		*username, *password}
-	repo := NexusRepository{inst, *repository}
+	repo := nf.repo()
+	repos := nf.repos()
 
 	// Either GAV from commandline or via parameters, no mixing
 	var gav Gav
 	switch flag.NArg() {
 	case 0:
-		gav = Gav{*group, *artifact, *version, *classifier, *packaging}
+		gav = Gav{Group: *group, Artifact: *artifact, Version: *version,
+			Classifier: *classifier, Packaging: *packaging}
+		gav = gav.WithPackaging(defaultPackaging)
 	case 1:
-		gav = Concise(flag.Arg(0))
+		var err error
+		gav, err = parseConcise(flag.Arg(0))
+		if err != nil {
+			log.Printf("%v\n", err)
+			exit(2)
+		}
 	default:
 		flag.Usage()
-		os.Exit(2)
+		exit(2)
+	}
+	if gav.Classifier == "" {
+		gav.Classifier = *defaultClassifier
 	}
 
-	fqa := Fqa{repo, gav}
+	// download fetches url into the output directory, in chunks if
+	// requested and supported
+	download := func(inst NexusInstance, url, name string, gav Gav) (string,
+		error) {
+		outputName := func(res *http.Response) (string, error) {
+			f, err := filename(name, res, gav)
+			return lay.outputName(f, name, gav), err
+		}
+		if *chunks > 1 {
+			p, err := fetchChunked(ctx, inst, url, *chunks, *outputDir,
+				outputName, int64(filters.maxSize))
+			if err != errNoRanges {
+				return p, err
+			}
+			log.Printf("%v, downloading in one piece\n", err)
+		}
+		res, err := getAs(ctx, inst, url)
+		if err != nil {
+			return "", err
+		}
+		prog.started(gav, url, res)
+		f, err := outputName(res)
+		if err != nil {
+			res.Body.Close()
+			return "", err
+		}
+		return persistBody(res, *outputDir, f, int64(filters.maxSize))
+	}
+
+	// storagePath looks up repository paths for the report and templates
+	// once per artifact
+	paths := make(map[Fqa]string)
+	storagePath := func(a Fqa) string {
+		if *reportFile == "" && !out.uses("RepositoryPath") {
+			return ""
+		}
+		if p, ok := paths[a]; ok {
+			return p
+		}
+		p, err := repositoryPath(ctx, a)
+		if err != nil {
+			log.Printf("%s: no repository path: %v\n",
+				a.Gav.ConciseNotation(), err)
+		}
+		paths[a] = p
+		return p
+	}
+	report.repositoryPath = storagePath
+	result := func(a Fqa, url, file string) Result {
+		r := newResult(a, url, file)
+		if file != "" || !*fetch {
+			r.RepositoryPath = storagePath(a)
+		}
+		return r
+	}
+
+	// fetchPom downloads the POM next to an artifact if requested
+	fetchPom := func(a Fqa) error {
+		pf, ok := pomOf(a)
+		if !*withPom || !ok {
+			return nil
+		}
+		inst := NexusInstance{}
+		url := contentURL(pf)
+		if strings.HasSuffix(pf.Version, "SNAPSHOT") {
+			inst = pf.NexusInstance
+			url = mavenURL("redirect", pf)
+		}
+		if lu := lay.url(pf); lu != "" {
+			url = lu
+		}
+		if *dryRun {
+			return planFetch(ctx, url, *outputDir,
+				lay.outputName("", "", pf.Gav), pf.Gav)
+		}
+		prog.queued(pf)
+		start := time.Now()
+		p, err := download(inst, url, "", pf.Gav)
+		if err == nil {
+			err = checkCoordinates(p, pf.Gav, *validateCoords)
+		}
+		report.add(pf, url, p, time.Since(start), err)
+		prog.finished(pf, url, p, err)
+		att.add(pf, url, p, err)
+		if err != nil {
+			return err
+		}
+		nt.notify(newNotification("fetched", pf, p))
+		out.print(result(pf, url, p))
+		return nil
+	}
+
+	remember(gav)
+	record(os.Args[1:], flag.NArg())
+	fqa := Fqa{NexusRepository: repo, Gav: gav}
 	// Nexus has all kind of index up-to-date issues w/ searches, so if we
 	// have the required minimum info to fetch an artefact, don't search,
 	// just get it
-	if fullySpecified(fqa) {
+	if fullySpecified(fqa) && *tag == "" {
+		// follow POM relocations like Maven instead of fetching a stub
+		if lay.url(fqa) == "" {
+			rs := repos
+			if len(rs) == 0 {
+				rs = []NexusRepository{repo}
+			}
+			pl := newPomLoader(ctx, rs)
+			pl.noRelocation = *noRelocation
+			var err error
+			if gav, err = pl.relocateGav(gav); err != nil {
+				fail(err)
+			}
+			fqa.Gav = gav
+		}
+		// locating needs the REST API which only knows the maven layout
+		if len(repos) > 1 && lay.url(fqa) == "" {
+			var found bool
+			var err error
+			if fqa, found, err = locate(fqa, repos); err != nil {
+				fail(err)
+			}
+			if !found && *abortOnNotFound {
+				exit(exitNotFound)
+			}
+			if !found {
+				log.Printf("%s not found in any repository\n",
+					gav.ConciseNotation())
+				exit(exitError)
+			}
+		} else {
+			var err error
+			if fqa, err = checkPolicy(fqa, *policy); err != nil {
+				fail(err)
+			}
+		}
 		var res *http.Response
+		u := mavenURL("content", fqa)
+		if lu := lay.url(fqa); lu != "" {
+			u = lu
+		}
+		if *dryRun {
+			name := expandName(*outputFilename, 1, gav)
+			err := planFetch(ctx, u, *outputDir,
+				lay.outputName(name, name, gav), gav)
+			fetchPom(fqa)
+			if err != nil {
+				exit(exitCode(err))
+			}
+			exit(0)
+		}
 		if *fetch {
 			log.Println("coordinates fully specified, fetching " +
 				"content...")
-			res = content(fqa)
-			f := filename(*outputFilename, res, gav)
-			persistBody(res, *outputDir, f)
+			prog.queued(fqa)
+			start := time.Now()
+			p, err := download(NexusInstance{}, u,
+				expandName(*outputFilename, 1, gav), gav)
+			if err == nil {
+				err = validate(p, *validateArchives)
+			}
+			if err == nil {
+				err = checkCoordinates(p, gav, *validateCoords)
+			}
+			if err == nil {
+				err = checkSidecar(fqa, p)
+			}
+			report.add(fqa, u, p, time.Since(start), err)
+			prog.finished(fqa, u, p, err)
+			att.add(fqa, u, p, err)
+			if err == nil {
+				err = fetchPom(fqa)
+			}
+			report.write(*reportFile)
+			if *stats {
+				report.summary(os.Stderr)
+			}
+			if nexus.IsNotFound(err) && *abortOnNotFound {
+				exit(exitNotFound)
+			}
+			if err != nil {
+				fail(err)
+			}
+			if err := att.write(); err != nil {
+				fail(err)
+			}
+			lay.writeMetadata(*outputDir)
+			nt.notify(newNotification("fetched", fqa, p))
+			out.print(result(fqa, u, p))
+			exit(0)
 		} else {
 			log.Println("coordinates fully specified, resolving...")
-			res = resolve(fqa)
+			var err error
+			if res, err = resolve(fqa); err != nil {
+				fail(err)
+			}
 			print(res)
 		}
 		if res.StatusCode == http.StatusNotFound &&
 			*abortOnNotFound {
-			os.Exit(4)
+			exit(exitNotFound)
 		}
 		if res.StatusCode != 200 {
-			log.Fatalf("Expected status 200 but got %v\n",
-				res.StatusCode)
+			fail(&StatusError{URL: res.Request.URL.String(),
+				StatusCode: res.StatusCode})
 		}
-		os.Exit(0)
+		exit(0)
 	}
 
-	log.Printf("searching %+v\n", gav)
-	res := search(repo, gav)
-	log.Printf("Found %v artifacts\n", len(res.Artifacts))
-
-	ls := locations(res, inst)
-	if *abortOnNotFound && len(ls) == 0 {
-		log.Printf("search returns nothing, aborting")
-		os.Exit(4)
+	var ls []Fqa
+	if *resume != "" {
+		prev, err := readReport(*resume)
+		if err != nil {
+			fail(err)
+		}
+		ls, report = prev.failed(nf.instance())
+		report.repositoryPath = storagePath
+		if *reportFile == "" {
+			// update in place
+			*reportFile = *resume
+		}
+		log.Printf("re-attempting %d failed downloads\n", len(ls))
+	} else {
+		log.Printf("searching %+v\n", gav)
+		if len(repos) == 0 {
+			// global search
+			repos = append(repos, repo)
+		}
+		var err error
+		ls, err = searchAll(repos, gav, nexus.WithTag(*tag))
+		if err != nil {
+			fail(err)
+		}
+		if *abortOnNotFound && len(ls) == 0 {
+			log.Printf("search returns nothing, aborting")
+			exit(exitNotFound)
+		}
+		ls = withoutPoms(ls)
+		ls = without(ls, exclude.ids)
+		if ls, err = dedup(ls, *perRepository); err != nil {
+			fail(err)
+		}
+		infos := make(infoCache)
+		ls = filters.apply(ls, infos)
+		order.sort(ls, infos)
+		ls = page(ls, *order.skip, *order.limit)
+		if *interactive && len(ls) > 1 {
+			ls, err = pick(ls, os.Stdin, os.Stderr)
+			if err != nil {
+				fail(err)
+			}
+		}
 	}
-	for _, a := range ls {
-		// Ignore POMs
-		if a.Gav.Packaging == "pom" {
-			continue
+	if *fetch && !*dryRun {
+		for _, a := range ls {
+			prog.queued(a)
+		}
+	}
+	var completed []string
+	fetchResult := func(a Fqa, url, name string) (string, error) {
+		// snapshots are fetched through the authenticated redirect
+		inst := NexusInstance{}
+		if strings.HasSuffix(a.Version, "SNAPSHOT") {
+			inst = a.NexusInstance
+		}
+		p, err := download(inst, url, name, a.Gav)
+		if err != nil {
+			return "", err
 		}
+		if err := validate(p, *validateArchives); err != nil {
+			return p, err
+		}
+		if err := checkCoordinates(p, a.Gav, *validateCoords); err != nil {
+			return p, err
+		}
+		if err := checkSidecar(a, p); err != nil {
+			return p, err
+		}
+		completed = append(completed, p)
+		nt.notify(newNotification("fetched", a, p))
+		out.print(result(a, url, p))
+		return p, fetchPom(a)
+	}
+	if *fetch && len(ls) > 1 && *outputFilename != "" &&
+		!uniqueName(*outputFilename) {
+		log.Printf("%d artifacts would overwrite %s, use {n} or "+
+			"{artifact} and {version} in -outputFilename\n", len(ls),
+			*outputFilename)
+		exit(exitUsage)
+	}
+	var failures []error
+	for i, a := range ls {
+		name := expandName(*outputFilename, i+1, a.Gav)
 		log.Printf("artifact: %+v [%s]\n",
 			a.Gav.ConciseNotation(), a.NexusRepository.RepositoryID)
 		log.Printf("default layout: %s\n", a.DefaultLayout())
@@ -502,19 +845,75 @@ func main() {
 		// Optionally resolve Maven SNAPSHOTS
 		log.Printf("Version: %s\n", a.Gav.Version)
 		if strings.HasSuffix(a.Gav.Version, "SNAPSHOT") {
-			url = a.RedirectURL()
+			url = mavenURL("redirect", a)
 		} else {
-			url = a.ContentURL()
+			url = contentURL(a)
+		}
+		if lu := lay.url(a); lu != "" {
+			url = lu
 		}
 
-		if *fetch {
-			log.Printf("fetching %s\n", url)
-			res, err := http.Get(url)
+		if *fetch && *dryRun {
+			err := planFetch(ctx, url, *outputDir,
+				lay.outputName(name, name, a.Gav), a.Gav)
 			if err != nil {
-				log.Fatal(err)
+				failures = append(failures, fmt.Errorf("%s: %w",
+					a.Gav.ConciseNotation(), err))
+			}
+			fetchPom(a)
+			continue
+		}
+		if !*fetch {
+			out.print(result(a, url, ""))
+			continue
+		}
+		log.Printf("fetching %s\n", url)
+		start := time.Now()
+		p, err := fetchResult(a, url, name)
+		report.add(a, url, p, time.Since(start), err)
+		prog.finished(a, url, p, err)
+		att.add(a, url, p, err)
+		if ctx.Err() != nil {
+			report.write(*reportFile)
+			log.Printf("interrupted, %d of %d downloads completed:\n",
+				len(completed), len(ls))
+			for _, f := range completed {
+				log.Printf("  %s\n", f)
+			}
+			exit(exitInterrupted)
+		}
+		if nexus.IsNotFound(err) && *abortOnNotFound {
+			report.write(*reportFile)
+			log.Printf("%s: %v\n", a.Gav.ConciseNotation(), err)
+			exit(exitNotFound)
+		}
+		// search indexes may be stale, so artifacts that vanished in the
+		// meantime do not stop the run
+		if err != nil {
+			if !*keepGoing && !nexus.IsNotFound(err) {
+				report.write(*reportFile)
+				fail(err)
 			}
-			f := filename(*outputFilename, res, gav)
-			persistBody(res, *outputDir, f)
+			log.Printf("%s: %v\n", a.Gav.ConciseNotation(), err)
+			failures = append(failures, fmt.Errorf("%s: %w",
+				a.Gav.ConciseNotation(), err))
+		}
+	}
+	if *fetch && !*dryRun {
+		lay.writeMetadata(*outputDir)
+		if err := att.write(); err != nil {
+			fail(err)
+		}
+	}
+	report.write(*reportFile)
+	if *stats {
+		report.summary(os.Stderr)
+	}
+	if len(failures) > 0 {
+		log.Printf("%d of %d downloads failed:\n", len(failures), len(ls))
+		for _, err := range failures {
+			log.Printf("  %v\n", err)
 		}
+		exit(summaryExitCode(len(ls), failures))
 	}
 }
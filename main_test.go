@@ -1,6 +1,12 @@
 package main
 
-import "testing"
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/jhinrichsen/nexus-fetch/nexus"
+)
 
 func TestDefaultLayout(t *testing.T) {
 	want := "g/a/v/a-v.jar"
@@ -18,3 +24,140 @@ func TestDefaultLayoutClassifier(t *testing.T) {
 		t.Fatalf("Expected %s but got %s\n", want, got)
 	}
 }
+
+func TestFilenameWithoutContentDisposition(t *testing.T) {
+	want := "a-v.jar"
+	res := &http.Response{Header: http.Header{}}
+	got, err := filename("", res, Gav{Group: "g", Artifact: "a",
+		Version: "v"})
+	if err != nil || want != got {
+		t.Fatalf("Expected %s but got %s, %v\n", want, got, err)
+	}
+}
+
+func TestFilenameFromSnapshotRedirect(t *testing.T) {
+	gav := Gav{Group: "g", Artifact: "a", Version: "1.0-SNAPSHOT"}
+	want := "a-1.0-20180312.173914-4.jar"
+	target, _ := url.Parse("http://nexus/content/repositories/snapshots/" +
+		"g/a/1.0-SNAPSHOT/" + want)
+	res := &http.Response{Header: http.Header{}, Request: &http.Request{
+		URL: target, Response: &http.Response{StatusCode: 307}}}
+	got, err := filename("", res, gav)
+	if err != nil || want != got {
+		t.Fatalf("Expected %s but got %s, %v\n", want, got, err)
+	}
+	if v := resolvedVersion(gav, got); v != "1.0-20180312.173914-4" {
+		t.Fatalf("Expected timestamped version but got %s\n", v)
+	}
+	if v := resolvedVersion(gav, "renamed.jar"); v != "" {
+		t.Fatalf("Expected no version but got %s\n", v)
+	}
+}
+
+func TestLocate(t *testing.T) {
+	inst := fakeNexus(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("r") != "thirdparty" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	repos := []NexusRepository{{NexusInstance: inst, RepositoryID: "releases"},
+		{NexusInstance: inst, RepositoryID: "thirdparty"}}
+	fqa := Fqa{Gav: Gav{Group: "g", Artifact: "a", Version: "v"}}
+	got, found, err := locate(fqa, repos)
+	if err != nil || !found || got.RepositoryID != "thirdparty" {
+		t.Fatalf("Expected thirdparty but got %+v, %v\n", got, err)
+	}
+	if _, found, err := locate(fqa, repos[:1]); err != nil || found {
+		t.Fatalf("Expected not found but got %v\n", err)
+	}
+}
+
+func TestWithoutExcludedRepositories(t *testing.T) {
+	ls := []Fqa{
+		{NexusRepository: NexusRepository{RepositoryID: "central"}},
+		{NexusRepository: NexusRepository{RepositoryID: "releases"}},
+		{NexusRepository: NexusRepository{RepositoryID: "apache"}},
+	}
+	got := without(ls, []string{"central", "apache"})
+	if len(got) != 1 || got[0].RepositoryID != "releases" {
+		t.Fatalf("Expected releases only but got %+v\n", got)
+	}
+}
+
+func TestDedup(t *testing.T) {
+	app := Gav{Group: "g", Artifact: "app", Version: "1.0"}
+	sources := Gav{Group: "g", Artifact: "app", Version: "1.0",
+		Classifier: "sources", Packaging: "jar"}
+	ls := []Fqa{
+		{NexusRepository: NexusRepository{RepositoryID: "releases"}, Gav: app},
+		{NexusRepository: NexusRepository{RepositoryID: "releases"}, Gav: app},
+		{NexusRepository: NexusRepository{RepositoryID: "releases"},
+			Gav: sources},
+		{NexusRepository: NexusRepository{RepositoryID: "mirror"}, Gav: app},
+	}
+	if got, err := dedup(ls, false); err != nil || len(got) != 2 {
+		t.Fatalf("Expected 2 results but got %+v, %v\n", got, err)
+	}
+	if got, err := dedup(ls, true); err != nil || len(got) != 3 {
+		t.Fatalf("Expected 3 results but got %+v, %v\n", got, err)
+	}
+	defer func(s bool) { strict = s }(strict)
+	strict = true
+	if _, err := dedup(ls, false); err == nil {
+		t.Fatalf("Expected an error for duplicates with -strict\n")
+	}
+}
+
+func TestExpandName(t *testing.T) {
+	gav := Gav{Group: "g", Artifact: "app", Version: "1.0",
+		Classifier: "sources"}
+	got := expandName("{n}-{artifact}-{version}-{classifier}.{ext}", 3, gav)
+	if want := "3-app-1.0-sources.jar"; got != want {
+		t.Fatalf("Expected %s but got %s\n", want, got)
+	}
+	if uniqueName("app.jar") || uniqueName("{artifact}.jar") {
+		t.Fatalf("Expected clashing names to be detected\n")
+	}
+	if !uniqueName("{n}.jar") || !uniqueName("{artifact}-{version}.jar") {
+		t.Fatalf("Expected unique names to be accepted\n")
+	}
+}
+
+func TestBaseUrl(t *testing.T) {
+	for _, tt := range []struct {
+		inst NexusInstance
+		want string
+	}{
+		{NexusInstance{Protocol: "http", Server: "nexus", Port: "8081",
+			Contextroot: "nexus/"}, "http://nexus:8081/nexus/"},
+		{NexusInstance{Protocol: "http", Server: "nexus", Port: "8081",
+			Contextroot: "/nexus"}, "http://nexus:8081/nexus/"},
+		{NexusInstance{Protocol: "http", Server: "nexus", Port: "8081",
+			Contextroot: "nexus"}, "http://nexus:8081/nexus/"},
+		// Nexus at the root path
+		{NexusInstance{Protocol: "http", Server: "nexus", Port: "8081"},
+			"http://nexus:8081/"},
+		{NexusInstance{Protocol: "https", Server: "repo.example.com",
+			Contextroot: "/"}, "https://repo.example.com/"},
+		// reverse proxy below a path
+		{NexusInstance{Protocol: "https", Server: "tools.example.com",
+			Contextroot: "nexus/", BasePath: "/tools/"},
+			"https://tools.example.com/tools/nexus/"},
+		{NexusInstance{Protocol: "http", Server: "::1", Port: "8081",
+			Contextroot: "nexus/"}, "http://[::1]:8081/nexus/"},
+	} {
+		got := nexus.BaseURL(NexusRepository{NexusInstance: tt.inst,
+			RepositoryID: "releases"}).String()
+		if got != tt.want {
+			t.Fatalf("Expected %s but got %s\n", tt.want, got)
+		}
+	}
+	repo := NexusRepository{NexusInstance: NexusInstance{Protocol: "http",
+		Server: "nexus"}, RepositoryID: "releases"}
+	want := "http://nexus/content/repositories/releases/g/a/1/a-1.jar"
+	got := contentURL(Fqa{NexusRepository: repo,
+		Gav: Gav{Group: "g", Artifact: "a", Version: "1"}})
+	if got != want {
+		t.Fatalf("Expected %s but got %s\n", want, got)
+	}
+}
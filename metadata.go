@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+
+	"github.com/jhinrichsen/nexus-fetch/nexus"
+)
+
+// mavenMetadata is the maven-metadata.xml document on artifact level
+// (list of versions) or on snapshot version level (list of builds).
+type mavenMetadata struct {
+	XMLName    xml.Name   `xml:"metadata"`
+	Group      string     `xml:"groupId"`
+	Artifact   string     `xml:"artifactId"`
+	Version    string     `xml:"version,omitempty"`
+	Versioning versioning `xml:"versioning"`
+}
+
+type versioning struct {
+	Latest           string            `xml:"latest,omitempty"`
+	Release          string            `xml:"release,omitempty"`
+	Snapshot         *snapshot         `xml:"snapshot,omitempty"`
+	Versions         []string          `xml:"versions>version,omitempty"`
+	LastUpdated      string            `xml:"lastUpdated,omitempty"`
+	SnapshotVersions []snapshotVersion `xml:"snapshotVersions>snapshotVersion,omitempty"`
+}
+
+type snapshot struct {
+	Timestamp   string `xml:"timestamp"`
+	BuildNumber int    `xml:"buildNumber"`
+}
+
+type snapshotVersion struct {
+	Classifier string `xml:"classifier,omitempty"`
+	Extension  string `xml:"extension"`
+	Value      string `xml:"value"`
+	Updated    string `xml:"updated"`
+}
+
+// metadataURL returns the URL of the maven-metadata.xml for an artifact, or
+// for a specific snapshot version if the version is set.
+func metadataURL(a Fqa) string {
+	s := nexus.BaseURL(a.NexusRepository).String()
+	s += repositoryPrefix(a.RepositoryID) +
+		strings.Replace(a.Group, ".", "/", -1) + "/" + a.Artifact + "/"
+	if a.Version != "" {
+		s += a.Version + "/"
+	}
+	return s + "maven-metadata.xml"
+}
+
+// fetchMetadata returns the maven-metadata.xml of fqa, cached as
+// configured by mdCache.
+func fetchMetadata(fqa Fqa) (mavenMetadata, error) {
+	var md mavenMetadata
+	u := metadataURL(fqa)
+	body, err := mdCache.fetch(u, fqa.Version != "")
+	if err != nil {
+		return md, err
+	}
+	if err = xml.Unmarshal(body, &md); err != nil {
+		return md, err
+	}
+	return md, checkMetadata(u, fqa, md)
+}
+
+// build identifies a snapshot build, i.e. its timestamp and build number.
+func (a mavenMetadata) build() string {
+	s := a.Versioning.Snapshot
+	if s == nil {
+		return a.Versioning.LastUpdated
+	}
+	return fmt.Sprintf("%s-%d", s.Timestamp, s.BuildNumber)
+}
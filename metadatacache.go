@@ -0,0 +1,178 @@
+package main
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// mdCache is used by fetchMetadata. Its zero value does not cache, commands
+// opt in by registering its flags.
+var mdCache = &metadataCache{}
+
+// metadataCache keeps maven-metadata.xml documents on disk. Entries younger
+// than their maximum age are used without asking Nexus, older entries are
+// revalidated using conditional requests.
+type metadataCache struct {
+	dir      string
+	disabled bool
+	// ttl applies to artifact level metadata, i.e. the list of versions
+	ttl time.Duration
+	// snapshots applies to snapshot version level metadata
+	snapshots updatePolicy
+}
+
+// flags enables the cache in the user's cache directory.
+func (a *metadataCache) flags(fs *flag.FlagSet) {
+	if dir, err := os.UserCacheDir(); err == nil {
+		a.dir = filepath.Join(dir, "nexus-fetch", "metadata")
+	}
+	fs.BoolVar(&a.disabled, "no-cache", false,
+		"Always fetch maven-metadata.xml from Nexus")
+	fs.DurationVar(&a.ttl, "metadata-ttl", 0, "Use cached "+
+		"maven-metadata.xml of artifacts for this long without "+
+		"revalidating, e.g. 10m")
+	fs.Var(&a.snapshots, "snapshot-update-policy", "Revalidate cached "+
+		"snapshot metadata: always, daily, interval:<minutes> or never")
+}
+
+// updatePolicy mimics Maven's repository updatePolicy, its zero value is
+// always.
+type updatePolicy struct {
+	s      string
+	maxAge time.Duration
+}
+
+const forever = time.Duration(1<<63 - 1)
+
+func (a *updatePolicy) String() string {
+	if a.s == "" {
+		return "always"
+	}
+	return a.s
+}
+
+func (a *updatePolicy) Set(s string) error {
+	switch {
+	case s == "always":
+		a.maxAge = 0
+	case s == "daily":
+		a.maxAge = 24 * time.Hour
+	case s == "never":
+		a.maxAge = forever
+	case strings.HasPrefix(s, "interval:"):
+		n, err := strconv.Atoi(strings.TrimPrefix(s, "interval:"))
+		if err != nil || n < 0 {
+			return fmt.Errorf("bad interval in %q", s)
+		}
+		a.maxAge = time.Duration(n) * time.Minute
+	default:
+		return fmt.Errorf("unknown update policy %q", s)
+	}
+	a.s = s
+	return nil
+}
+
+// cachedMetadata is a cache entry including HTTP validators.
+type cachedMetadata struct {
+	URL          string
+	ETag         string
+	LastModified string
+	Fetched      time.Time
+	Body         []byte
+}
+
+func (a *metadataCache) enabled() bool {
+	return a.dir != "" && !a.disabled
+}
+
+func (a *metadataCache) file(u string) string {
+	h := sha1.Sum([]byte(u))
+	return filepath.Join(a.dir, hex.EncodeToString(h[:])+".json")
+}
+
+func (a *metadataCache) load(u string) (cachedMetadata, bool) {
+	var e cachedMetadata
+	buf, err := ioutil.ReadFile(a.file(u))
+	if err != nil {
+		return e, false
+	}
+	if err := json.Unmarshal(buf, &e); err != nil || e.URL != u {
+		return e, false
+	}
+	return e, true
+}
+
+// store is best effort, a failing cache must not fail the command.
+func (a *metadataCache) store(e cachedMetadata) {
+	buf, err := json.Marshal(e)
+	if err == nil {
+		err = os.MkdirAll(a.dir, 0755)
+	}
+	if err == nil {
+		err = ioutil.WriteFile(a.file(e.URL), buf, 0644)
+	}
+	if err != nil {
+		log.Printf("cannot cache %s: %v\n", e.URL, err)
+	}
+}
+
+// fetch returns the document at u, from cache if possible.
+func (a *metadataCache) fetch(u string, snapshot bool) ([]byte, error) {
+	maxAge := a.ttl
+	if snapshot {
+		maxAge = a.snapshots.maxAge
+	}
+	e, cached := cachedMetadata{}, false
+	if a.enabled() {
+		e, cached = a.load(u)
+	}
+	if cached && time.Since(e.Fetched) < maxAge {
+		log.Printf("using cached %s\n", u)
+		return e.Body, nil
+	}
+	log.Printf("getting %s\n", u)
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	if cached && e.ETag != "" {
+		req.Header.Set("If-None-Match", e.ETag)
+	}
+	if cached && e.LastModified != "" {
+		req.Header.Set("If-Modified-Since", e.LastModified)
+	}
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	switch {
+	case res.StatusCode == http.StatusNotModified && cached:
+		log.Printf("%s not modified\n", u)
+	case res.StatusCode == http.StatusOK:
+		body, err := ioutil.ReadAll(res.Body)
+		if err != nil {
+			return nil, err
+		}
+		e = cachedMetadata{URL: u, ETag: res.Header.Get("ETag"),
+			LastModified: res.Header.Get("Last-Modified"), Body: body}
+	default:
+		return nil, &StatusError{URL: u, StatusCode: res.StatusCode}
+	}
+	if a.enabled() {
+		e.Fetched = time.Now()
+		a.store(e)
+	}
+	return e.Body, nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jhinrichsen/nexus-fetch/nexus"
+)
+
+func TestMetadataCacheRevalidates(t *testing.T) {
+	var requests, notModified int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter,
+		r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(artifactMetadata))
+	}))
+	defer ts.Close()
+
+	c := &metadataCache{dir: t.TempDir()}
+	u := ts.URL + "/maven-metadata.xml"
+	for i := 0; i < 2; i++ {
+		body, err := c.fetch(u, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(body) != artifactMetadata {
+			t.Fatalf("Expected metadata but got %q\n", body)
+		}
+	}
+	if requests != 2 || notModified != 1 {
+		t.Fatalf("Expected 2 requests, 1 not modified, but got %d, %d\n",
+			requests, notModified)
+	}
+
+	c.ttl = time.Hour
+	if _, err := c.fetch(u, false); err != nil {
+		t.Fatal(err)
+	}
+	if requests != 2 {
+		t.Fatalf("Expected cached metadata within TTL but got %d "+
+			"requests\n", requests)
+	}
+	c.disabled = true
+	if _, err := c.fetch(u, false); err != nil {
+		t.Fatal(err)
+	}
+	if requests != 3 || notModified != 1 {
+		t.Fatalf("Expected unconditional request for -no-cache\n")
+	}
+}
+
+func TestMetadataCacheNotFound(t *testing.T) {
+	ts := httptest.NewServer(http.NotFoundHandler())
+	defer ts.Close()
+	c := &metadataCache{dir: t.TempDir()}
+	_, err := c.fetch(ts.URL+"/maven-metadata.xml", false)
+	if !nexus.IsNotFound(err) {
+		t.Fatalf("Expected not found but got %v\n", err)
+	}
+}
+
+func TestUpdatePolicy(t *testing.T) {
+	var p updatePolicy
+	for s, want := range map[string]time.Duration{
+		"always":      0,
+		"daily":       24 * time.Hour,
+		"interval:30": 30 * time.Minute,
+		"never":       forever,
+	} {
+		if err := p.Set(s); err != nil {
+			t.Fatal(err)
+		}
+		if p.maxAge != want {
+			t.Fatalf("%s: Expected %v but got %v\n", s, want, p.maxAge)
+		}
+	}
+	if err := p.Set("hourly"); err == nil {
+		t.Fatalf("Expected error for unknown policy\n")
+	}
+}
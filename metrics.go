@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// latencyBuckets are upper bounds in seconds, as in Prometheus' defaults.
+var latencyBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5,
+	10}
+
+// metrics collects counters for long running modes and exposes them in
+// Prometheus text format.
+type metrics struct {
+	mu             sync.Mutex
+	fetches        int64
+	bytes          int64
+	cacheHits      int64
+	upstreamErrors int64
+	latencyCounts  []int64
+	latencySum     float64
+	latencyCount   int64
+}
+
+func newMetrics() *metrics {
+	return &metrics{latencyCounts: make([]int64, len(latencyBuckets))}
+}
+
+func (a *metrics) fetched(bytes int64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.fetches++
+	a.bytes += bytes
+}
+
+func (a *metrics) cacheHit() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.cacheHits++
+}
+
+func (a *metrics) upstreamError() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.upstreamErrors++
+}
+
+// observe records the latency of an upstream request.
+func (a *metrics) observe(d time.Duration) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	s := d.Seconds()
+	for i, le := range latencyBuckets {
+		if s <= le {
+			a.latencyCounts[i]++
+		}
+	}
+	a.latencySum += s
+	a.latencyCount++
+}
+
+func (a *metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	counter := func(name, help string, v int64) {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n%s %d\n",
+			name, help, name, name, v)
+	}
+	counter("nexus_fetch_fetches_total", "Number of artifacts fetched.",
+		a.fetches)
+	counter("nexus_fetch_bytes_total", "Number of bytes transferred.",
+		a.bytes)
+	counter("nexus_fetch_cache_hits_total",
+		"Number of artifacts served from cache.", a.cacheHits)
+	counter("nexus_fetch_upstream_errors_total",
+		"Number of failed requests against Nexus.", a.upstreamErrors)
+
+	const h = "nexus_fetch_upstream_request_duration_seconds"
+	fmt.Fprintf(w, "# HELP %s Latency of requests against Nexus.\n", h)
+	fmt.Fprintf(w, "# TYPE %s histogram\n", h)
+	for i, le := range latencyBuckets {
+		fmt.Fprintf(w, "%s_bucket{le=\"%g\"} %d\n", h, le,
+			a.latencyCounts[i])
+	}
+	fmt.Fprintf(w, "%s_bucket{le=\"+Inf\"} %d\n", h, a.latencyCount)
+	fmt.Fprintf(w, "%s_sum %g\n", h, a.latencySum)
+	fmt.Fprintf(w, "%s_count %d\n", h, a.latencyCount)
+}
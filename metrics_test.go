@@ -0,0 +1,33 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetricsExposition(t *testing.T) {
+	m := newMetrics()
+	m.fetched(100)
+	m.cacheHit()
+	m.observe(30 * time.Millisecond)
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := ioutil.ReadAll(rec.Body)
+	for _, want := range []string{
+		"nexus_fetch_fetches_total 1\n",
+		"nexus_fetch_bytes_total 100\n",
+		"nexus_fetch_cache_hits_total 1\n",
+		"nexus_fetch_upstream_errors_total 0\n",
+		`nexus_fetch_upstream_request_duration_seconds_bucket{le="0.025"} 0`,
+		`nexus_fetch_upstream_request_duration_seconds_bucket{le="0.05"} 1`,
+		"nexus_fetch_upstream_request_duration_seconds_count 1\n",
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Fatalf("Expected %q in\n%s", want, body)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"encoding/xml"
+	"hash"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jhinrichsen/nexus-fetch/nexus"
+)
+
+// mirroredFile is an artifact file found in a local tree in Maven layout.
+type mirroredFile struct {
+	version    string
+	timestamp  string // snapshot build such as 20200101.120000-3, if any
+	classifier string
+	ext        string
+	modified   time.Time
+}
+
+// parseMirrored splits the name of a file in directory
+// <artifact>/<version>/, it returns false for files that do not belong to
+// the version.
+func parseMirrored(artifact, version, name string) (mirroredFile, bool) {
+	f := mirroredFile{version: version}
+	rest := strings.TrimPrefix(name, artifact+"-")
+	if rest == name {
+		return f, false
+	}
+	base := strings.TrimSuffix(version, "SNAPSHOT")
+	switch {
+	case strings.HasPrefix(rest, version):
+		rest = rest[len(version):]
+	case base != version && strings.HasPrefix(rest, base) &&
+		timestampedVersion.MatchString(rest[len(base):]):
+		f.timestamp = timestampedVersion.FindString(rest[len(base):])
+		rest = rest[len(base)+len(f.timestamp):]
+	default:
+		return f, false
+	}
+	if strings.HasPrefix(rest, "-") {
+		i := strings.Index(rest, ".")
+		if i < 0 {
+			return f, false
+		}
+		f.classifier, rest = rest[1:i], rest[i:]
+	}
+	if !strings.HasPrefix(rest, ".") || len(rest) == 1 {
+		return f, false
+	}
+	f.ext = rest[1:]
+	return f, true
+}
+
+// metadataTimestamp formats t as used in maven-metadata.xml.
+func metadataTimestamp(t time.Time) string {
+	return t.UTC().Format("20060102150405")
+}
+
+// writeMirrorMetadata regenerates artifact and snapshot level
+// maven-metadata.xml files for all artifacts below root, so that the tree
+// can be served as a Maven repository by a plain web server.
+func writeMirrorMetadata(root string) error {
+	// artifact directory -> version -> files
+	artifacts := make(map[string]map[string][]mirroredFile)
+	// absolute paths let the os package lift MAX_PATH on Windows
+	if abs, err := filepath.Abs(root); err == nil {
+		root = abs
+	}
+	err := filepath.Walk(root, func(p string, fi os.FileInfo, err error) error {
+		if err != nil || fi.IsDir() || nexus.Ignored(fi.Name()) {
+			return err
+		}
+		versionDir := filepath.Dir(p)
+		artifactDir := filepath.Dir(versionDir)
+		if rel, err := filepath.Rel(root, artifactDir); err != nil ||
+			filepath.Dir(rel) == "." {
+			// no group directory
+			return err
+		}
+		f, ok := parseMirrored(filepath.Base(artifactDir),
+			filepath.Base(versionDir), fi.Name())
+		if !ok {
+			return nil
+		}
+		f.modified = fi.ModTime()
+		if artifacts[artifactDir] == nil {
+			artifacts[artifactDir] = make(map[string][]mirroredFile)
+		}
+		artifacts[artifactDir][f.version] = append(
+			artifacts[artifactDir][f.version], f)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for dir, versions := range artifacts {
+		rel, err := filepath.Rel(root, dir)
+		if err != nil {
+			return err
+		}
+		group := strings.Replace(filepath.ToSlash(filepath.Dir(rel)), "/",
+			".", -1)
+		md := versionsMetadata(group, filepath.Base(dir), versions)
+		if err := writeMetadataFile(dir, md); err != nil {
+			return err
+		}
+		for v, fs := range versions {
+			if !strings.HasSuffix(v, "SNAPSHOT") {
+				continue
+			}
+			md := snapshotMetadata(group, filepath.Base(dir), v, fs)
+			if err := writeMetadataFile(filepath.Join(dir, v),
+				md); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// versionsMetadata lists all versions of an artifact.
+func versionsMetadata(group, artifact string,
+	versions map[string][]mirroredFile) mavenMetadata {
+	md := mavenMetadata{Group: group, Artifact: artifact}
+	var updated time.Time
+	for v, fs := range versions {
+		md.Versioning.Versions = append(md.Versioning.Versions, v)
+		for _, f := range fs {
+			if f.modified.After(updated) {
+				updated = f.modified
+			}
+		}
+	}
+	sort.Slice(md.Versioning.Versions, func(i, j int) bool {
+		return compareVersions(md.Versioning.Versions[i],
+			md.Versioning.Versions[j]) < 0
+	})
+	for _, v := range md.Versioning.Versions {
+		md.Versioning.Latest = v
+		if !strings.HasSuffix(v, "SNAPSHOT") {
+			md.Versioning.Release = v
+		}
+	}
+	md.Versioning.LastUpdated = metadataTimestamp(updated)
+	return md
+}
+
+// snapshotMetadata lists the newest build of each file of a snapshot
+// version. Files stored without timestamp resolve to the plain version.
+func snapshotMetadata(group, artifact, version string,
+	fs []mirroredFile) mavenMetadata {
+	md := mavenMetadata{Group: group, Artifact: artifact, Version: version}
+	// newest build first
+	sort.Slice(fs, func(i, j int) bool {
+		return fs[i].timestamp > fs[j].timestamp
+	})
+	var updated time.Time
+	seen := make(map[string]bool)
+	for _, f := range fs {
+		if f.modified.After(updated) {
+			updated = f.modified
+		}
+		key := f.classifier + ":" + f.ext
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		value := version
+		if f.timestamp != "" {
+			value = strings.TrimSuffix(version, "SNAPSHOT") + f.timestamp
+		}
+		md.Versioning.SnapshotVersions = append(
+			md.Versioning.SnapshotVersions, snapshotVersion{
+				Classifier: f.classifier,
+				Extension:  f.ext,
+				Value:      value,
+				Updated:    metadataTimestamp(f.modified),
+			})
+	}
+	if ts := fs[0].timestamp; ts != "" {
+		i := strings.LastIndex(ts, "-")
+		n, _ := strconv.Atoi(ts[i+1:])
+		md.Versioning.Snapshot = &snapshot{ts[:i], n}
+	}
+	md.Versioning.LastUpdated = metadataTimestamp(updated)
+	return md
+}
+
+// writeMetadataFile writes maven-metadata.xml and its checksums into dir.
+func writeMetadataFile(dir string, md mavenMetadata) error {
+	buf, err := xml.MarshalIndent(md, "", "  ")
+	if err != nil {
+		return err
+	}
+	buf = append([]byte(xml.Header), append(buf, '\n')...)
+	filename := longPath(filepath.Join(dir, "maven-metadata.xml"))
+	if err := os.WriteFile(filename, buf, 0644); err != nil {
+		return err
+	}
+	for ext, h := range map[string]hash.Hash{
+		".sha1": sha1.New(),
+		".md5":  md5.New(),
+	} {
+		sum, err := digestFile(filename, h)
+		if err == nil {
+			err = os.WriteFile(filename+ext, []byte(sum+"\n"), 0644)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseMirrored(t *testing.T) {
+	for _, tt := range []struct {
+		version, name string
+		want          mirroredFile
+		ok            bool
+	}{
+		{"1.0", "app-1.0.jar", mirroredFile{version: "1.0", ext: "jar"},
+			true},
+		{"1.0", "app-1.0-sources.tar.gz", mirroredFile{version: "1.0",
+			classifier: "sources", ext: "tar.gz"}, true},
+		{"1.0-SNAPSHOT", "app-1.0-20200101.120000-3.pom",
+			mirroredFile{version: "1.0-SNAPSHOT",
+				timestamp: "20200101.120000-3", ext: "pom"}, true},
+		{"1.0", "other-1.0.jar", mirroredFile{}, false},
+		{"1.0", "app-1.1.jar", mirroredFile{}, false},
+	} {
+		got, ok := parseMirrored("app", tt.version, tt.name)
+		if ok != tt.ok || (ok && got != tt.want) {
+			t.Fatalf("%s: expected %+v but got %+v\n", tt.name, tt.want,
+				got)
+		}
+	}
+}
+
+func TestWriteMirrorMetadata(t *testing.T) {
+	root := t.TempDir()
+	for _, f := range []string{
+		"org/acme/app/1.0/app-1.0.jar",
+		"org/acme/app/1.10/app-1.10.jar",
+		"org/acme/app/2.0-SNAPSHOT/app-2.0-20200101.120000-1.jar",
+		"org/acme/app/2.0-SNAPSHOT/app-2.0-20200102.120000-2.jar",
+		"org/acme/app/2.0-SNAPSHOT/app-2.0-20200102.120000-2-sources.jar",
+	} {
+		p := filepath.Join(root, filepath.FromSlash(f))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writeMirrorMetadata(root); err != nil {
+		t.Fatal(err)
+	}
+	read := func(dir string) mavenMetadata {
+		buf, err := os.ReadFile(filepath.Join(root, dir,
+			"maven-metadata.xml"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var md mavenMetadata
+		if err := xml.Unmarshal(buf, &md); err != nil {
+			t.Fatal(err)
+		}
+		return md
+	}
+
+	md := read("org/acme/app")
+	want := []string{"1.0", "1.10", "2.0-SNAPSHOT"}
+	if md.Group != "org.acme" ||
+		!reflect.DeepEqual(md.Versioning.Versions, want) ||
+		md.Versioning.Release != "1.10" ||
+		md.Versioning.Latest != "2.0-SNAPSHOT" {
+		t.Fatalf("Expected versions %v but got %+v\n", want, md)
+	}
+
+	md = read("org/acme/app/2.0-SNAPSHOT")
+	if md.build() != "20200102.120000-2" {
+		t.Fatalf("Expected build %s but got %s\n", "20200102.120000-2",
+			md.build())
+	}
+	if n := len(md.Versioning.SnapshotVersions); n != 2 {
+		t.Fatalf("Expected 2 snapshot versions but got %d\n", n)
+	}
+	for _, sv := range md.Versioning.SnapshotVersions {
+		if sv.Value != "2.0-20200102.120000-2" {
+			t.Fatalf("Expected newest build but got %s\n", sv.Value)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(root, "org/acme/app/1.0",
+		"maven-metadata.xml")); err == nil {
+		t.Fatalf("Expected no metadata for release version\n")
+	}
+}
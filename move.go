@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+
+	"github.com/jhinrichsen/nexus-fetch/nexus"
+)
+
+// movedComponents lists the components of a search in the order of the
+// search.
+func movedComponents(c *Client, gav Gav) ([]string, error) {
+	it, err := c.SearchIter(gav)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	var cs []string
+	for it.Next() {
+		a := it.Fqa()
+		s := a.Group + ":" + a.Artifact + ":" + a.Version
+		if !seen[s] {
+			seen[s] = true
+			cs = append(cs, s)
+		}
+	}
+	return cs, it.Err()
+}
+
+// moveComponents moves all components matching gav and the tag of c from
+// the repository of c to dst using the Nexus 3 Pro staging API, and returns
+// how many were moved.
+func moveComponents(ctx context.Context, c *Client, dst string, gav Gav) (
+	int, error) {
+	var res struct {
+		Data struct {
+			Components []interface{} `json:"components moved"`
+		} `json:"data"`
+	}
+	err := nexus3(ctx, http.MethodPost, c.NexusInstance,
+		"v1/staging/move/"+url.PathEscape(dst)+"?"+
+			c.ComponentQuery(gav).Encode(), nil, &res)
+	return len(res.Data.Components), err
+}
+
+func moveCommand(args []string) {
+	fs := newCommand("move", "-repository <from> -to <to> [-tag <tag>] "+
+		"[g:a[:v]]...")
+	nf := newNexusFlags(fs)
+	conf := newConfirmation(fs)
+	audit := newAuditLog(fs)
+	var (
+		to     = fs.String("to", "", "Move components to this repository")
+		tag    = fs.String("tag", "", "Move the components with this tag")
+		dryRun = fs.Bool("dry-run", false,
+			"Print the components to move without moving them")
+	)
+	fs.Parse(args)
+	src := nf.repo()
+	if *to == "" || src.RepositoryID == "" ||
+		(*tag == "" && fs.NArg() == 0) {
+		fs.Usage()
+	}
+	var gavs []Gav
+	for _, arg := range fs.Args() {
+		gav, err := parseConcise(arg)
+		if err != nil || gav.Group == "" || gav.Artifact == "" {
+			log.Printf("expected group:artifact[:version]: %q\n", arg)
+			exit(exitUsage)
+		}
+		gavs = append(gavs, gav)
+	}
+	// the tag alone selects
+	if len(gavs) == 0 {
+		gavs = []Gav{{}}
+	}
+
+	requireNexus3("moving components")
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt,
+		syscall.SIGTERM)
+	defer stop()
+	c := newClient(src, nexus.WithTag(*tag))
+	var all []string
+	for _, gav := range gavs {
+		cs, err := movedComponents(c, gav)
+		if err != nil {
+			fail(err)
+		}
+		all = append(all, cs...)
+	}
+	if len(all) == 0 {
+		log.Printf("nothing to move in %s\n", src.RepositoryID)
+		exit(exitNotFound)
+	}
+	sort.Strings(all)
+	if *dryRun {
+		for _, s := range all {
+			fmt.Printf("%s/%s -> %s\n", src.RepositoryID, s, *to)
+		}
+		return
+	}
+	if err := conf.confirm("move", all); err != nil {
+		fail(err)
+	}
+	dst := src
+	dst.RepositoryID = *to
+	var failures []error
+	for _, gav := range gavs {
+		n, err := moveComponents(ctx, c, *to, gav)
+		if aerr := audit.record(newAuditRecord("move from "+
+			src.RepositoryID, dst, gav, "", err)); aerr != nil {
+			fail(aerr)
+		}
+		if err != nil {
+			log.Printf("%s: %v\n", gav.ConciseNotation(), err)
+			failures = append(failures, err)
+			continue
+		}
+		log.Printf("moved %d components to %s\n", n, *to)
+	}
+	exit(summaryExitCode(len(gavs), failures))
+}
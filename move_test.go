@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/jhinrichsen/nexus-fetch/nexus"
+)
+
+func TestMoveComponents(t *testing.T) {
+	defer func(k serverKind) { serverType = k }(serverType)
+	serverType = serverNexus3
+	var moved string
+	inst := fakeNexus(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/nexus/service/rest/v1/search":
+			if r.URL.Query().Get("tag") != "build-1234" {
+				t.Errorf("Expected tag in %s\n", r.URL.RawQuery)
+			}
+			w.Write([]byte(`{"items": [
+  {"repository": "dev", "group": "com.acme", "name": "app",
+   "version": "1.0", "assets": [
+    {"path": "com/acme/app/1.0/app-1.0.jar", "maven2": {"extension": "jar"}},
+    {"path": "com/acme/app/1.0/app-1.0.pom", "maven2": {"extension": "pom"}}
+  ]}]}`))
+		case "/nexus/service/rest/v1/staging/move/qa":
+			moved = r.URL.RawQuery
+			w.Write([]byte(`{"status": 200, "data": {"destination": "qa",
+  "components moved": [{"name": "app"}]}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	c := newClient(NexusRepository{NexusInstance: inst, RepositoryID: "dev"},
+		nexus.WithTag("build-1234"))
+	cs, err := movedComponents(c, Gav{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"com.acme:app:1.0"}; !reflect.DeepEqual(want, cs) {
+		t.Fatalf("Expected %v but got %v\n", want, cs)
+	}
+	n, err := moveComponents(context.Background(), c, "qa", Gav{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Fatalf("Expected 1 component but got %d\n", n)
+	}
+	if want := "format=maven2&repository=dev&tag=build-1234"; moved != want {
+		t.Fatalf("Expected %s but got %s\n", want, moved)
+	}
+}
@@ -0,0 +1,428 @@
+package nexus
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// searchPageSize is the number of artifacts requested per search page,
+// Nexus caps larger values.
+const searchPageSize = 200
+
+// Client executes requests against a Nexus repository, or against all
+// repositories if the repository ID is empty.
+type Client struct {
+	NexusRepository
+	HTTPClient *http.Client
+	// Server selects the API dialect, anything but Nexus3 searches using
+	// the Nexus 2 API
+	Server ServerKind
+	// Tag restricts Nexus 3 searches, see WithTag
+	Tag string
+	// Search returns the body of a successful search response, see
+	// WithSearchFunc
+	Search func(c *http.Client, req *http.Request) ([]byte, error)
+	// DefaultPackaging applies to searches for coordinates without
+	// packaging. If empty, the default, searches match any packaging.
+	DefaultPackaging string
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithHTTPClient uses c for all requests, e.g. a corporate instrumented
+// client.
+func WithHTTPClient(c *http.Client) Option {
+	return func(a *Client) {
+		a.HTTPClient = c
+	}
+}
+
+// WithTransport uses rt for all requests, e.g. a record/replay transport.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(a *Client) {
+		c := *a.HTTPClient
+		c.Transport = rt
+		a.HTTPClient = &c
+	}
+}
+
+// WithServer selects the API dialect of the repository manager.
+func WithServer(k ServerKind) Option {
+	return func(a *Client) {
+		a.Server = k
+	}
+}
+
+// WithTag restricts Nexus 3 searches to components carrying tag, a Nexus 3
+// Pro feature.
+func WithTag(tag string) Option {
+	return func(a *Client) {
+		a.Tag = tag
+	}
+}
+
+// WithSearchFunc executes searches using f, e.g. to answer repeated
+// searches from a cache.
+func WithSearchFunc(f func(*http.Client, *http.Request) ([]byte,
+	error)) Option {
+	return func(a *Client) {
+		a.Search = f
+	}
+}
+
+// WithDefaultPackaging searches for coordinates without packaging as if
+// they had packaging p.
+func WithDefaultPackaging(p string) Option {
+	return func(a *Client) {
+		a.DefaultPackaging = p
+	}
+}
+
+// NewClient returns a client for repo, by default using
+// http.DefaultClient and the Nexus 2 API.
+func NewClient(repo NexusRepository, opts ...Option) *Client {
+	a := &Client{NexusRepository: repo, HTTPClient: http.DefaultClient,
+		Server: Nexus2, Search: Search}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+// Search executes req using c and returns the body of a successful
+// response.
+func Search(c *http.Client, req *http.Request) ([]byte, error) {
+	u := req.URL.String()
+	res, err := c.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	log.Printf("%v returns HTTP status code %v\n", u, res.StatusCode)
+	if res.StatusCode != http.StatusOK {
+		return nil, &StatusError{u, res.StatusCode}
+	}
+	return io.ReadAll(res.Body)
+}
+
+type searchNGResponse struct {
+	// Count is just a copy of the 'count' request value
+	Count int `xml:"count"`
+	// From is just a copy of the 'from' request value
+	From           int  `xml:"from"`
+	TotalCount     int  `xml:"totalCount"`
+	TooManyResults bool `xml:"tooManyResults"`
+	Artifacts      []struct {
+		Group        string `xml:"groupId"`
+		Artifact     string `xml:"artifactId"`
+		Version      string `xml:"version"`
+		ArtifactHits []struct {
+			RepositoryID  string `xml:"repositoryId"`
+			ArtifactLinks []struct {
+				Packaging  string `xml:"extension"`
+				Classifier string `xml:"classifier"`
+			} `xml:"artifactLinks>artifactLink"`
+		} `xml:"artifactHits>artifactHit"`
+	} `xml:"data>artifact"`
+}
+
+func locations(res searchNGResponse, inst NexusInstance) []Fqa {
+	var ls []Fqa
+	for _, a := range res.Artifacts {
+		log.Printf("%+v\n", a)
+		for _, hit := range a.ArtifactHits {
+			for _, link := range hit.ArtifactLinks {
+				gav := Gav{a.Group, a.Artifact, a.Version,
+					link.Classifier, link.Packaging,
+				}
+				ls = append(ls, Fqa{
+					NexusRepository: NexusRepository{
+						inst,
+						hit.RepositoryID},
+					Gav: gav,
+				})
+			}
+		}
+	}
+	return ls
+}
+
+// searchPage executes a single Nexus REST search starting at result from.
+func (a *Client) searchPage(gav Gav, from, count int) (searchNGResponse,
+	error) {
+	var found searchNGResponse
+	if a.Tag != "" {
+		return found, fmt.Errorf("searching by tag needs Nexus 3 Pro")
+	}
+	gav = gav.WithPackaging(a.DefaultPackaging)
+	s := BaseURL(a.NexusRepository).String()
+	s += fmt.Sprintf("service/local/lucene/search?%s&from=%d&count=%d",
+		gav.LuceneSearch(), from, count)
+	if a.RepositoryID != "" {
+		s += fmt.Sprintf("&repositoryId=%s", a.RepositoryID)
+	}
+	req, err := http.NewRequest(http.MethodGet, s, nil)
+	if err != nil {
+		return found, err
+	}
+	body, err := a.Search(a.HTTPClient, req)
+	if err != nil {
+		return found, fmt.Errorf("cannot search: %w", err)
+	}
+	if err := xml.Unmarshal(body, &found); err != nil {
+		return found, err
+	}
+	log.Printf("search returns count=%d, total count=%d, "+
+		"overflow=%v, artifacts=%d\n",
+		found.Count, found.TotalCount, found.TooManyResults,
+		len(found.Artifacts))
+	return found, nil
+}
+
+// ComponentQuery returns the Nexus 3 search parameters selecting whole
+// components, so the default packaging does not restrict it.
+func (a *Client) ComponentQuery(gav Gav) url.Values {
+	q := a.nexus3Query(gav)
+	if gav.Packaging == "" {
+		q.Del("maven.extension")
+	}
+	return q
+}
+
+// nexus3Query returns the Nexus 3 search parameters for gav.
+func (a *Client) nexus3Query(gav Gav) url.Values {
+	gav = gav.Normalize().WithPackaging(a.DefaultPackaging)
+	q := url.Values{"format": {"maven2"}}
+	set := func(k, v string) {
+		if v != "" {
+			q.Set(k, v)
+		}
+	}
+	set("repository", a.RepositoryID)
+	set("maven.groupId", gav.Group)
+	set("maven.artifactId", gav.Artifact)
+	if strings.HasSuffix(gav.Version, "SNAPSHOT") {
+		set("maven.baseVersion", gav.Version)
+	} else {
+		set("version", gav.Version)
+	}
+	set("maven.extension", gav.Packaging)
+	set("maven.classifier", gav.Classifier)
+	set("tag", a.Tag)
+	return q
+}
+
+// searchNexus3Page executes a single Nexus 3 search, token continues a
+// previous page. It returns the results and the token of the next page,
+// which is empty for the last one.
+func (a *Client) searchNexus3Page(q url.Values, token string) ([]Fqa,
+	string, error) {
+	if token != "" {
+		q.Set("continuationToken", token)
+	}
+	s := BaseURL(a.NexusRepository).String() + "service/rest/v1/search?" +
+		q.Encode()
+	req, err := http.NewRequest(http.MethodGet, s, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("Accept", "application/json")
+	if a.Username != "" {
+		req.SetBasicAuth(a.Username, a.Password)
+	}
+	body, err := a.Search(a.HTTPClient, req)
+	if err != nil {
+		return nil, "", fmt.Errorf("cannot search: %w", err)
+	}
+	var found struct {
+		Items []struct {
+			Repository string `json:"repository"`
+			Group      string `json:"group"`
+			Name       string `json:"name"`
+			Version    string `json:"version"`
+			Assets     []struct {
+				Path   string `json:"path"`
+				Maven2 struct {
+					Extension  string `json:"extension"`
+					Classifier string `json:"classifier"`
+					// BaseVersion is the version of a snapshot
+					BaseVersion string `json:"baseVersion"`
+				} `json:"maven2"`
+			} `json:"assets"`
+		} `json:"items"`
+		ContinuationToken string `json:"continuationToken"`
+	}
+	if err := json.Unmarshal(body, &found); err != nil {
+		return nil, "", err
+	}
+	var ls []Fqa
+	for _, c := range found.Items {
+		for _, as := range c.Assets {
+			if Ignored(path.Base(as.Path)) {
+				continue
+			}
+			v := c.Version
+			if as.Maven2.BaseVersion != "" {
+				v = as.Maven2.BaseVersion
+			}
+			ls = append(ls, Fqa{NexusRepository{a.NexusInstance,
+				c.Repository}, Gav{c.Group, c.Name, v,
+				as.Maven2.Classifier, as.Maven2.Extension}})
+		}
+	}
+	log.Printf("search returns %d components, more=%v\n",
+		len(found.Items), found.ContinuationToken != "")
+	return ls, found.ContinuationToken, nil
+}
+
+// ResultIterator yields search results one at a time, transparently
+// requesting further pages as needed. Nexus 2 pages by offset, Nexus 3 by
+// continuation token.
+type ResultIterator struct {
+	client *Client
+	gav    Gav
+	from   int
+	token  string
+	done   bool
+	buf    []Fqa
+	idx    int
+	cur    Fqa
+	err    error
+}
+
+// SearchIter starts a paged search. The first page is requested
+// immediately so that connection and authentication problems surface here.
+func (a *Client) SearchIter(gav Gav) (*ResultIterator, error) {
+	it := &ResultIterator{client: a, gav: gav}
+	it.fetch()
+	return it, it.err
+}
+
+func (a *ResultIterator) fetch() {
+	if a.client.Server == Nexus3 {
+		ls, token, err := a.client.searchNexus3Page(
+			a.client.nexus3Query(a.gav), a.token)
+		if err != nil {
+			a.err = err
+			return
+		}
+		a.buf, a.idx, a.token = ls, 0, token
+		a.done = token == ""
+		return
+	}
+	res, err := a.client.searchPage(a.gav, a.from, searchPageSize)
+	if err != nil {
+		a.err = err
+		return
+	}
+	a.buf = locations(res, a.client.NexusInstance)
+	a.idx = 0
+	a.from += len(res.Artifacts)
+	if len(res.Artifacts) == 0 || a.from >= res.TotalCount {
+		a.done = true
+	}
+}
+
+// Next advances to the next result, returning false when all results have
+// been consumed or an error occurred.
+func (a *ResultIterator) Next() bool {
+	for a.idx >= len(a.buf) {
+		if a.done || a.err != nil {
+			return false
+		}
+		a.fetch()
+	}
+	a.cur = a.buf[a.idx]
+	a.idx++
+	return true
+}
+
+// Fqa returns the current result.
+func (a *ResultIterator) Fqa() Fqa {
+	return a.cur
+}
+
+// Err returns the error that stopped iteration, if any.
+func (a *ResultIterator) Err() error {
+	return a.err
+}
+
+// StatusError reports an unexpected HTTP status code.
+type StatusError struct {
+	URL        string
+	StatusCode int
+}
+
+func (a *StatusError) Error() string {
+	return fmt.Sprintf("%s returns HTTP status code %d", a.URL, a.StatusCode)
+}
+
+// IsNotFound reports whether err is a 404 from Nexus.
+func IsNotFound(err error) bool {
+	var se *StatusError
+	return errors.As(err, &se) && se.StatusCode == http.StatusNotFound
+}
+
+// FetchInfo describes a fetched artifact.
+type FetchInfo struct {
+	URL string
+	// Filename as announced by Nexus, or derived from the coordinates
+	Filename     string
+	ContentType  string
+	LastModified string
+	// Size is the announced size, -1 if unknown, or the number of bytes
+	// written for FetchTo
+	Size int64
+}
+
+func (a *Client) get(ctx context.Context, u string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("getting %s\n", u)
+	return a.HTTPClient.Do(req)
+}
+
+// Open streams the content of an artifact, the caller must close the
+// returned reader.
+func (a *Client) Open(ctx context.Context, fqa Fqa) (io.ReadCloser,
+	FetchInfo, error) {
+	u := MavenURL(a.Server, "content", fqa)
+	info := FetchInfo{URL: u, Size: -1}
+	res, err := a.get(ctx, u)
+	if err != nil {
+		return nil, info, err
+	}
+	if res.StatusCode != http.StatusOK {
+		res.Body.Close()
+		return nil, info, &StatusError{u, res.StatusCode}
+	}
+	info.Filename = ResponseFilename(res, fqa.Gav)
+	info.ContentType = res.Header.Get("Content-Type")
+	info.LastModified = res.Header.Get("Last-Modified")
+	info.Size = res.ContentLength
+	return res.Body, info, nil
+}
+
+// FetchTo copies the content of an artifact to w.
+func (a *Client) FetchTo(ctx context.Context, fqa Fqa, w io.Writer) (
+	FetchInfo, error) {
+	rc, info, err := a.Open(ctx, fqa)
+	if err != nil {
+		return info, err
+	}
+	defer rc.Close()
+	info.Size, err = io.Copy(w, rc)
+	return info, err
+}
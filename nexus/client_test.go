@@ -0,0 +1,186 @@
+package nexus
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// fakeNexus returns a Nexus instance backed by handler.
+func fakeNexus(t *testing.T, handler http.HandlerFunc) NexusInstance {
+	ts := httptest.NewServer(handler)
+	t.Cleanup(ts.Close)
+	u, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NexusInstance{Protocol: "http", Server: u.Hostname(),
+		Port: u.Port(), Contextroot: "nexus/"}
+}
+
+// pagedSearch serves total artifacts, at most two per page.
+func pagedSearch(total int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		from, _ := strconv.Atoi(r.URL.Query().Get("from"))
+		fmt.Fprintf(w, "<searchNGResponse><totalCount>%d</totalCount>"+
+			"<from>%d</from><data>", total, from)
+		for i := from; i < total && i < from+2; i++ {
+			fmt.Fprintf(w, "<artifact><groupId>g</groupId>"+
+				"<artifactId>a</artifactId><version>%d</version>"+
+				"<artifactHits><artifactHit>"+
+				"<repositoryId>releases</repositoryId>"+
+				"<artifactLinks><artifactLink><extension>jar"+
+				"</extension></artifactLink></artifactLinks>"+
+				"</artifactHit></artifactHits></artifact>", i)
+		}
+		fmt.Fprint(w, "</data></searchNGResponse>")
+	}
+}
+
+func TestSearchIterPages(t *testing.T) {
+	inst := fakeNexus(t, pagedSearch(5))
+	it, err := NewClient(NexusRepository{inst, ""}).SearchIter(Gav{Group: "g"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got string
+	for it.Next() {
+		got += it.Fqa().Version
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if want := "01234"; want != got {
+		t.Fatalf("Expected versions %s but got %s\n", want, got)
+	}
+}
+
+// pagedNexus3Search serves total components, at most two per page, each
+// with a jar and its checksum.
+func pagedNexus3Search(total int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		from, _ := strconv.Atoi(r.URL.Query().Get("continuationToken"))
+		fmt.Fprint(w, `{"items": [`)
+		for i := from; i < total && i < from+2; i++ {
+			if i > from {
+				fmt.Fprint(w, ",")
+			}
+			fmt.Fprintf(w, `{"repository": "releases", "group": "g",
+  "name": "a", "version": "%d", "assets": [
+  {"path": "g/a/%d/a-%d.jar", "maven2": {"extension": "jar"}},
+  {"path": "g/a/%d/a-%d.jar.sha1", "maven2": {"extension": "jar.sha1"}}]}`,
+				i, i, i, i, i)
+		}
+		token := ""
+		if from+2 < total {
+			token = strconv.Itoa(from + 2)
+		}
+		fmt.Fprintf(w, `], "continuationToken": %q}`, token)
+	}
+}
+
+func TestSearchIterNexus3Pages(t *testing.T) {
+	inst := fakeNexus(t, pagedNexus3Search(5))
+	it, err := NewClient(NexusRepository{inst, ""}, WithServer(Nexus3)).
+		SearchIter(Gav{Group: "g"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got string
+	for it.Next() {
+		got += it.Fqa().Version + it.Fqa().Packaging
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if want := "0jar1jar2jar3jar4jar"; want != got {
+		t.Fatalf("Expected %s but got %s\n", want, got)
+	}
+}
+
+func TestSearchIterError(t *testing.T) {
+	inst := fakeNexus(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	_, err := NewClient(NexusRepository{inst, ""}).SearchIter(Gav{Group: "g"})
+	if err == nil {
+		t.Fatalf("Expected error\n")
+	}
+}
+
+func TestFetchTo(t *testing.T) {
+	inst := fakeNexus(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("v") != "1.0" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Disposition",
+			`attachment; filename="a-1.0.jar"`)
+		fmt.Fprint(w, "content")
+	})
+	c := NewClient(NexusRepository{inst, "releases"})
+	fqa := Fqa{c.NexusRepository, Gav{Group: "g", Artifact: "a",
+		Version: "1.0"}}
+	var sb strings.Builder
+	info, err := c.FetchTo(context.Background(), fqa, &sb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sb.String() != "content" || info.Size != 7 ||
+		info.Filename != "a-1.0.jar" {
+		t.Fatalf("unexpected fetch %q %+v\n", sb.String(), info)
+	}
+
+	fqa.Version = "2.0"
+	if _, err := c.FetchTo(context.Background(), fqa, &sb); !IsNotFound(err) {
+		t.Fatalf("Expected not found but got %v\n", err)
+	}
+}
+
+// roundTripFunc adapts a function to http.RoundTripper.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (a roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return a(r)
+}
+
+func TestWithTransport(t *testing.T) {
+	var got string
+	rt := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		got = r.URL.Path
+		return &http.Response{StatusCode: http.StatusOK,
+			Body:   ioutil.NopCloser(strings.NewReader("content")),
+			Header: http.Header{}, Request: r}, nil
+	})
+	repo := NexusRepository{NexusInstance{Protocol: "http",
+		Server: "nexus", Port: "8081", Contextroot: "nexus/"}, "releases"}
+	c := NewClient(repo, WithTransport(rt))
+	if c.HTTPClient == http.DefaultClient {
+		t.Fatalf("Expected transport not to modify the shared client\n")
+	}
+	fqa := Fqa{repo, Gav{Group: "g", Artifact: "a", Version: "1.0"}}
+	if _, err := c.FetchTo(context.Background(), fqa,
+		ioutil.Discard); err != nil {
+		t.Fatal(err)
+	}
+	if want := "/nexus/service/local/artifact/maven/content"; want != got {
+		t.Fatalf("Expected %s but got %s\n", want, got)
+	}
+}
+
+func TestWithDefaultPackaging(t *testing.T) {
+	c := NewClient(NexusRepository{}, WithDefaultPackaging("zip"))
+	gav := Gav{Group: "g", Artifact: "a"}
+	if got := c.nexus3Query(gav).Get("maven.extension"); got != "zip" {
+		t.Fatalf("Expected %s but got %s\n", "zip", got)
+	}
+	if got := c.ComponentQuery(gav).Get("maven.extension"); got != "" {
+		t.Fatalf("Expected any extension but got %s\n", got)
+	}
+}
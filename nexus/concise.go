@@ -0,0 +1,137 @@
+package nexus
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Characters allowed in group, artifact and packaging; '*' is a search
+// wildcard.
+const coordinateChars = "abcdefghijklmnopqrstuvwxyz" +
+	"ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789._-*"
+
+// Versions may additionally carry ranges such as [1.0,2.0).
+const versionChars = coordinateChars + "+[](),"
+
+// ParseConcise converts a Maven coordinate in concise notation
+// group[:artifact[:version[:classifier]]][@packaging] into a GAV. This is
+// also Gradle's dependency notation group:name:version:classifier@ext.
+// A ':' or '@' inside a classifier must be escaped using '\' or the
+// classifier must be enclosed in double quotes.
+func ParseConcise(c string) (Gav, error) {
+	return ParseConciseFunc(c, nil)
+}
+
+// ParseConciseFunc is ParseConcise with the segments and the packaging, nil
+// if absent, passed through expand before validation, e.g. to replace
+// aliases. A nil expand changes nothing.
+func ParseConciseFunc(c string, expand func(segments []string,
+	packaging *string) ([]string, *string, error)) (Gav, error) {
+	var gav Gav
+	segments, packaging, err := SplitConcise(c)
+	if err != nil {
+		return gav, err
+	}
+	if expand != nil {
+		segments, packaging, err = expand(segments, packaging)
+		if err != nil {
+			return gav, err
+		}
+	}
+	if len(segments) > 4 {
+		return gav, fmt.Errorf("%q: too many segments, want "+
+			"group:artifact:version:classifier", c)
+	}
+	fields := []*string{&gav.Group, &gav.Artifact, &gav.Version,
+		&gav.Classifier}
+	names := []string{"group", "artifact", "version", "classifier"}
+	for i, s := range segments {
+		if s == "" {
+			return gav, fmt.Errorf("%q: empty %s", c, names[i])
+		}
+		legal := coordinateChars
+		if i == 2 {
+			legal = versionChars
+		}
+		// classifiers are free form, except for path separators
+		if i == 3 {
+			if strings.ContainsAny(s, "/\\ \t") {
+				return gav, fmt.Errorf("%q: illegal classifier %q",
+					c, s)
+			}
+		} else if err := checkChars(s, legal); err != nil {
+			return gav, fmt.Errorf("%q: %s %v", c, names[i], err)
+		}
+		*fields[i] = s
+	}
+	if packaging != nil {
+		if err := checkChars(*packaging, coordinateChars); err != nil {
+			return gav, fmt.Errorf("%q: packaging %v", c, err)
+		}
+		gav.Packaging = *packaging
+	}
+	return gav, nil
+}
+
+// SplitConcise splits a coordinate in concise notation at unescaped,
+// unquoted ':' and at most one '@' into its segments and its packaging,
+// which is nil if absent. The segments are not validated.
+func SplitConcise(c string) ([]string, *string, error) {
+	var segments []string
+	var packaging *string
+	var sb strings.Builder
+	quoted := false
+	for i := 0; i < len(c); i++ {
+		switch ch := c[i]; {
+		case ch == '\\':
+			if i+1 == len(c) {
+				return nil, nil, fmt.Errorf("%q: dangling escape", c)
+			}
+			i++
+			sb.WriteByte(c[i])
+		case ch == '"':
+			quoted = !quoted
+		case quoted:
+			sb.WriteByte(ch)
+		case ch == ':' && packaging == nil:
+			segments = append(segments, sb.String())
+			sb.Reset()
+		case ch == '@' && packaging == nil:
+			segments = append(segments, sb.String())
+			sb.Reset()
+			packaging = new(string)
+		case ch == ':' || ch == '@':
+			return nil, nil, fmt.Errorf("%q: unexpected %q after "+
+				"packaging", c, ch)
+		default:
+			sb.WriteByte(ch)
+		}
+	}
+	if quoted {
+		return nil, nil, fmt.Errorf("%q: unterminated quote", c)
+	}
+	if packaging != nil {
+		*packaging = sb.String()
+	} else {
+		segments = append(segments, sb.String())
+	}
+	return segments, packaging, nil
+}
+
+// CheckCoordinate reports an error if s is empty or contains characters
+// not allowed in a group, artifact or packaging.
+func CheckCoordinate(s string) error {
+	return checkChars(s, coordinateChars)
+}
+
+func checkChars(s, legal string) error {
+	if s == "" {
+		return fmt.Errorf("is empty")
+	}
+	if i := strings.IndexFunc(s, func(r rune) bool {
+		return !strings.ContainsRune(legal, r)
+	}); i >= 0 {
+		return fmt.Errorf("%q contains illegal character %q", s, s[i])
+	}
+	return nil
+}
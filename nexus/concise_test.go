@@ -0,0 +1,67 @@
+package nexus
+
+import (
+	"testing"
+)
+
+func TestParseConcise(t *testing.T) {
+	for _, tt := range []struct {
+		in   string
+		want Gav
+	}{
+		{"g", Gav{Group: "g"}},
+		{"g:a", Gav{Group: "g", Artifact: "a"}},
+		{"g:a:1.0", Gav{Group: "g", Artifact: "a", Version: "1.0"}},
+		{"g:a:1.0:c@zip", Gav{Group: "g", Artifact: "a", Version: "1.0",
+			Classifier: "c", Packaging: "zip"}},
+		{"g:a:[1.0,2.0)", Gav{Group: "g", Artifact: "a",
+			Version: "[1.0,2.0)"}},
+		{`g:a:1.0:x\:y`, Gav{Group: "g", Artifact: "a", Version: "1.0",
+			Classifier: "x:y"}},
+		{`g:a:1.0:"x:y@z"@jar`, Gav{Group: "g", Artifact: "a", Version: "1.0",
+			Classifier: "x:y@z", Packaging: "jar"}},
+		{"org.*:a", Gav{Group: "org.*", Artifact: "a"}},
+	} {
+		got, err := ParseConcise(tt.in)
+		if err != nil {
+			t.Fatalf("%s: unexpected error %v\n", tt.in, err)
+		}
+		if got != tt.want {
+			t.Fatalf("Expected %+v but got %+v\n", tt.want, got)
+		}
+		if back, _ := ParseConcise(got.ConciseNotation()); back != got {
+			t.Fatalf("Expected %+v to round trip but got %+v\n",
+				got, back)
+		}
+	}
+}
+
+func TestParseConciseRejects(t *testing.T) {
+	for _, in := range []string{
+		"", "g::1.0", "g:a:", ":a", "g:a:1.0:c:x", "g a:b", "g/a:b",
+		"g:a@", "g:a@jar@zip", "g:a@jar:x", `g:a:1.0:"c`, `g:a:1.0:c\`,
+	} {
+		if gav, err := ParseConcise(in); err == nil {
+			t.Fatalf("%q: expected error but got %+v\n", in, gav)
+		}
+	}
+}
+
+func TestParseConciseFunc(t *testing.T) {
+	expand := func(segments []string, packaging *string) ([]string, *string,
+		error) {
+		if segments[0] == "app" {
+			segments = append([]string{"com.acme", "application"},
+				segments[1:]...)
+		}
+		return segments, packaging, nil
+	}
+	got, err := ParseConciseFunc("app:1.0", expand)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Gav{Group: "com.acme", Artifact: "application", Version: "1.0"}
+	if got != want {
+		t.Fatalf("Expected %+v but got %+v\n", want, got)
+	}
+}
@@ -0,0 +1,45 @@
+package nexus
+
+import "fmt"
+
+// ServerKind selects the API dialect of the repository manager.
+type ServerKind string
+
+const (
+	Auto        ServerKind = "auto"
+	Nexus2      ServerKind = "nexus2"
+	Nexus3      ServerKind = "nexus3"
+	Artifactory ServerKind = "artifactory"
+)
+
+func (a *ServerKind) String() string {
+	return string(*a)
+}
+
+func (a *ServerKind) Set(s string) error {
+	switch k := ServerKind(s); k {
+	case Auto, Nexus2, Nexus3, Artifactory:
+		*a = k
+		return nil
+	}
+	return fmt.Errorf("unknown server type %q, expected auto, nexus2, "+
+		"nexus3 or artifactory", s)
+}
+
+// RepositoryPrefix returns the path of repository content below the
+// context root.
+func RepositoryPrefix(k ServerKind, id string) string {
+	switch k {
+	case Nexus3:
+		return "repository/" + id + "/"
+	case Artifactory:
+		return id + "/"
+	}
+	return "content/repositories/" + id + "/"
+}
+
+// LayoutOnly reports whether the server only serves plain repository paths
+// instead of the Nexus 2 REST API.
+func LayoutOnly(k ServerKind) bool {
+	return k == Nexus3 || k == Artifactory
+}
@@ -0,0 +1,275 @@
+// Package nexus searches and fetches Maven artifacts from Sonatype Nexus
+// and other repository managers serving the Maven layout. It is the library
+// behind the nexus-fetch command.
+package nexus
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// NexusInstance holds coordinates of a Nexus installation
+type NexusInstance struct {
+	Protocol    string
+	Server      string
+	Port        string
+	Contextroot string
+	Username    string
+	Password    string
+	// BasePath is prepended to the context root, for reverse proxies
+	// serving Nexus below a path of their own
+	BasePath string
+}
+
+// NexusRepository holds coordinates of a Nexus repository
+type NexusRepository struct {
+	NexusInstance
+	RepositoryID string
+}
+
+// Gav are the standard Maven coordinates
+type Gav struct {
+	Group      string `xml:"groupId"`
+	Artifact   string `xml:"artifactId"`
+	Version    string `xml:"version"`
+	Classifier string `xml:"classifier"`
+	Packaging  string `xml:"packaging"`
+}
+
+// Fqa holds coordinates to a fully qualified artifact
+type Fqa struct {
+	NexusRepository
+	Gav
+}
+
+// Concise converts a coordinate in GAV notation into concise notation.
+func (a Gav) ConciseNotation() string {
+	var sb strings.Builder
+	if len(a.Group) > 0 {
+		sb.WriteString(a.Group)
+	}
+	if len(a.Artifact) > 0 || len(a.Version) > 0 || len(a.Classifier) > 0 {
+		sb.WriteString(":")
+	}
+	if len(a.Artifact) > 0 {
+		sb.WriteString(a.Artifact)
+	}
+	if len(a.Version) > 0 || len(a.Classifier) > 0 {
+		sb.WriteString(":")
+	}
+	if len(a.Version) > 0 {
+		sb.WriteString(a.Version)
+	}
+	if len(a.Classifier) > 0 {
+		sb.WriteString(":")
+		// escape separators so that ParseConcise round trips
+		sb.WriteString(strings.NewReplacer(`\`, `\\`, `:`, `\:`,
+			`@`, `\@`, `"`, `\"`).Replace(a.Classifier))
+	}
+	if len(a.Packaging) > 0 {
+		sb.WriteString("@")
+		sb.WriteString(a.Packaging)
+	}
+	return sb.String()
+}
+
+// DefaultLayout translates a Gav into a file system hierarchy without leading /
+func (a Gav) DefaultLayout() string {
+	return fmt.Sprintf("%s/%s/%s/%s",
+		strings.Replace(a.Group, ".", "/", -1),
+		a.Artifact,
+		a.Version,
+		a.Filename())
+}
+
+// Normalize returns a without surrounding whitespace.
+func (a Gav) Normalize() Gav {
+	for _, p := range []*string{&a.Group, &a.Artifact, &a.Version,
+		&a.Classifier, &a.Packaging} {
+		*p = strings.TrimSpace(*p)
+	}
+	return a
+}
+
+// WithPackaging returns a with packaging p if it has none.
+func (a Gav) WithPackaging(p string) Gav {
+	if a.Packaging == "" {
+		a.Packaging = p
+	}
+	return a
+}
+
+// Extension returns the file extension of a, which is its normalized
+// packaging or jar.
+func (a Gav) Extension() string {
+	if p := a.Normalize().Packaging; p != "" {
+		return p
+	}
+	return "jar"
+}
+
+// Filename returns the basename part of a GAV default layout
+func (a Gav) Filename() string {
+	a = a.Normalize()
+	filename := fmt.Sprintf("%s-%s", a.Artifact, a.Version)
+	if a.Classifier != "" {
+		filename = fmt.Sprintf("%s-%s", filename, a.Classifier)
+	}
+	return fmt.Sprintf("%s.%s", filename, a.Extension())
+}
+
+// LuceneSearch builds a request path for given GAV
+func (a Gav) LuceneSearch() string {
+	a = a.Normalize()
+	url := ""
+	if a.Group != "" {
+		url += fmt.Sprintf("g=%s", a.Group)
+	}
+	if a.Artifact != "" {
+		url += fmt.Sprintf("&a=%s", a.Artifact)
+	}
+	if a.Version != "" {
+		url += fmt.Sprintf("&v=%s", a.Version)
+	}
+	if a.Packaging != "" {
+		url += fmt.Sprintf("&p=%s", a.Packaging)
+	}
+	if a.Classifier != "" {
+		url += fmt.Sprintf("&c=%s", a.Classifier)
+	}
+	return url
+}
+
+// BaseURL returns the URL of the Nexus root, always ending in /. An empty
+// port uses the protocol's default.
+func BaseURL(repo NexusRepository) *url.URL {
+	host := repo.Server
+	if repo.Port != "" {
+		host = net.JoinHostPort(repo.Server, repo.Port)
+	}
+	u := &url.URL{Scheme: repo.Protocol, Host: host,
+		Path: joinBasePath(repo.BasePath, repo.Contextroot)}
+	return u
+}
+
+// joinBasePath joins path segments into an absolute path with trailing
+// slash, tolerating leading, trailing, duplicate or missing slashes.
+func joinBasePath(ps ...string) string {
+	var segments []string
+	for _, p := range ps {
+		for _, s := range strings.Split(p, "/") {
+			if s != "" {
+				segments = append(segments, s)
+			}
+		}
+	}
+	if len(segments) == 0 {
+		return "/"
+	}
+	return "/" + strings.Join(segments, "/") + "/"
+}
+
+// ContentURL returns the URL of coords in the Maven layout of their
+// repository.
+func ContentURL(k ServerKind, coords Fqa) string {
+	s := BaseURL(coords.NexusRepository).String()
+	return s + RepositoryPrefix(k, coords.RepositoryID) +
+		coords.DefaultLayout()
+}
+
+// MavenURL returns the URL of a Maven REST endpoint such as resolve or
+// content for given coordinates. Servers without the Nexus 2 REST API
+// serve content by path instead.
+func MavenURL(k ServerKind, endpoint string, coords Fqa) string {
+	if LayoutOnly(k) && endpoint != "resolve" {
+		return ContentURL(k, coords)
+	}
+	u := BaseURL(coords.NexusRepository)
+	u.Path += "service/local/artifact/maven/" + endpoint
+	q := u.Query()
+	q.Add("r", coords.NexusRepository.RepositoryID)
+	gav := coords.Gav.Normalize()
+	if len(gav.Group) > 0 {
+		q.Set("g", gav.Group)
+	}
+	if len(gav.Artifact) > 0 {
+		q.Set("a", gav.Artifact)
+	}
+	if len(gav.Version) > 0 {
+		q.Set("v", gav.Version)
+	}
+	if len(gav.Classifier) > 0 {
+		q.Set("c", gav.Classifier)
+	}
+	if len(gav.Packaging) > 0 {
+		q.Set("p", gav.Packaging)
+	}
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// Ignored reports whether name is repository metadata or a checksum rather
+// than an artifact.
+func Ignored(name string) bool {
+	return strings.HasPrefix(name, "maven-metadata") ||
+		strings.HasSuffix(name, ".sha1") || strings.HasSuffix(name, ".md5") ||
+		strings.HasSuffix(name, ".sha256") || strings.HasSuffix(name, ".sha512")
+}
+
+// ContentDisposition extracts the file name from the Content-Disposition
+// header, format:
+// attachment; filename="helloworld-1.0.0-20180312.173914-4.jar"
+func ContentDisposition(res *http.Response) string {
+	v := res.Header.Get("Content-Disposition")
+	r := regexp.MustCompile(`attachment; filename="(.*)"`)
+	ss := r.FindStringSubmatch(v)
+	if len(ss) < 2 {
+		return ""
+	}
+	return ss[1]
+}
+
+// reservedNames cannot be used as file names on Windows, with or without
+// extension.
+var reservedNames = regexp.MustCompile(`(?i)^(con|prn|aux|nul|com[1-9]|` +
+	`lpt[1-9])(\.|$)`)
+
+// SanitizeName turns a file name sent by the server into one that is valid
+// on all platforms, so that output trees can be copied to Windows agents.
+// Path separators are replaced as well, a remote name must not leave the
+// output directory.
+func SanitizeName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r < 32 || strings.ContainsRune(`<>:"/\|?*`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+	// Windows drops trailing dots and spaces
+	name = strings.TrimRight(name, ". ")
+	if name == "" {
+		return "_"
+	}
+	if reservedNames.MatchString(name) {
+		name = "_" + name
+	}
+	return name
+}
+
+// ResponseFilename picks the file name of a download: the one announced by
+// the server, the target of a redirect, or the default for gav.
+func ResponseFilename(res *http.Response, gav Gav) string {
+	if f := ContentDisposition(res); len(f) > 0 {
+		return SanitizeName(f)
+	}
+	// a redirect resolving a snapshot points to the timestamped file
+	if res.Request != nil && res.Request.Response != nil {
+		return SanitizeName(path.Base(res.Request.URL.Path))
+	}
+	return gav.Filename()
+}
@@ -0,0 +1,42 @@
+package nexus
+
+import "testing"
+
+func TestSanitizeName(t *testing.T) {
+	for name, want := range map[string]string{
+		"app-1.0.tar.gz":     "app-1.0.tar.gz",
+		"app:1.0|linux?.zip": "app_1.0_linux_.zip",
+		`..\..\evil.jar`:     ".._.._evil.jar",
+		"../evil.jar":        ".._evil.jar",
+		"trailing. ":         "trailing",
+		"CON.jar":            "_CON.jar",
+		"console.jar":        "console.jar",
+		"..":                 "_",
+	} {
+		if got := SanitizeName(name); want != got {
+			t.Fatalf("Expected %s but got %s\n", want, got)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	gav := Gav{Group: " g", Artifact: "a ", Version: "1.0"}
+	if got := gav.Normalize(); got != (Gav{Group: "g", Artifact: "a",
+		Version: "1.0"}) {
+		t.Fatalf("Expected no packaging but got %+v\n", got)
+	}
+	if got := gav.LuceneSearch(); got != "g=g&a=a&v=1.0" {
+		t.Fatalf("Expected any packaging in search but got %s\n", got)
+	}
+	if got := gav.Filename(); got != "a-1.0.jar" {
+		t.Fatalf("Expected jar but got %s\n", got)
+	}
+
+	gav = gav.WithPackaging("zip")
+	if got := gav.LuceneSearch(); got != "g=g&a=a&v=1.0&p=zip" {
+		t.Fatalf("Expected default packaging in search but got %s\n", got)
+	}
+	if got := gav.WithPackaging("war").Filename(); got != "a-1.0.zip" {
+		t.Fatalf("Expected zip but got %s\n", got)
+	}
+}
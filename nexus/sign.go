@@ -0,0 +1,82 @@
+package nexus
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// RequestSigner adds authentication headers to outgoing requests, e.g. for
+// an API gateway in front of Nexus.
+type RequestSigner interface {
+	Sign(req *http.Request) error
+}
+
+// HMACSigner signs the request target, host and date with HMAC-SHA256 in
+// a Signature header as described by the HTTP signatures draft.
+type HMACSigner struct {
+	KeyID  string
+	Secret []byte
+	// Now defaults to time.Now
+	Now func() time.Time
+}
+
+func (a *HMACSigner) Sign(req *http.Request) error {
+	now := time.Now
+	if a.Now != nil {
+		now = a.Now
+	}
+	date := now().UTC().Format(http.TimeFormat)
+	req.Header.Set("Date", date)
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	s := fmt.Sprintf("(request-target): %s %s\nhost: %s\ndate: %s",
+		strings.ToLower(req.Method), req.URL.RequestURI(), host, date)
+	mac := hmac.New(sha256.New, a.Secret)
+	mac.Write([]byte(s))
+	req.Header.Set("Signature", fmt.Sprintf(`keyId="%s",`+
+		`algorithm="hmac-sha256",headers="(request-target) host date",`+
+		`signature="%s"`, a.KeyID,
+		base64.StdEncoding.EncodeToString(mac.Sum(nil))))
+	return nil
+}
+
+// SigningTransport signs a copy of each request with s before passing it
+// to rt.
+func SigningTransport(s RequestSigner,
+	rt http.RoundTripper) http.RoundTripper {
+	return signingTransport{s, rt}
+}
+
+type signingTransport struct {
+	signer RequestSigner
+	http.RoundTripper
+}
+
+func (a signingTransport) RoundTrip(req *http.Request) (*http.Response,
+	error) {
+	req = req.Clone(req.Context())
+	if err := a.signer.Sign(req); err != nil {
+		return nil, err
+	}
+	return a.RoundTripper.RoundTrip(req)
+}
+
+// WithSigner signs every request of the client with s.
+func WithSigner(s RequestSigner) Option {
+	return func(a *Client) {
+		c := *a.HTTPClient
+		rt := c.Transport
+		if rt == nil {
+			rt = http.DefaultTransport
+		}
+		c.Transport = SigningTransport(s, rt)
+		a.HTTPClient = &c
+	}
+}
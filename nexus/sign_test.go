@@ -0,0 +1,46 @@
+package nexus
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHMACSigner(t *testing.T) {
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	var got *http.Request
+	inst := fakeNexus(t, func(w http.ResponseWriter, r *http.Request) {
+		got = r
+	})
+	s := &HMACSigner{KeyID: "ci", Secret: []byte("secret"),
+		Now: func() time.Time { return now }}
+	c := NewClient(NexusRepository{NexusInstance: inst,
+		RepositoryID: "releases"},
+		WithHTTPClient(&http.Client{}), WithSigner(s))
+	req, _ := http.NewRequest(http.MethodGet,
+		BaseURL(c.NexusRepository).String()+"content/x?y=1", nil)
+	res, err := c.HTTPClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if req.Header.Get("Signature") != "" {
+		t.Fatalf("Expected the caller's request to stay unsigned\n")
+	}
+
+	date := "Thu, 02 Jan 2020 03:04:05 GMT"
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte("(request-target): get /nexus/content/x?y=1\n" +
+		"host: " + req.URL.Host + "\ndate: " + date))
+	want := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	sig := got.Header.Get("Signature")
+	if got.Header.Get("Date") != date ||
+		!strings.HasPrefix(sig, `keyId="ci",algorithm="hmac-sha256"`) ||
+		!strings.HasSuffix(sig, `signature="`+want+`"`) {
+		t.Fatalf("Expected signature %s but got %s\n", want, sig)
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/jhinrichsen/nexus-fetch/nexus"
+)
+
+// nexus3 calls the Nexus 3 REST API below service/rest/, sending in and
+// decoding the response into out as JSON if they are not nil.
+func nexus3(ctx context.Context, method string, inst NexusInstance,
+	p string, in, out interface{}) error {
+	u := nexus.BaseURL(NexusRepository{NexusInstance: inst}).String() +
+		"service/rest/" + p
+	var body io.Reader
+	if in != nil {
+		buf, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(buf)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if inst.Username != "" {
+		req.SetBasicAuth(inst.Username, inst.Password)
+	}
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode/100 != 2 {
+		return &StatusError{URL: u, StatusCode: res.StatusCode}
+	}
+	if out == nil || res.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	return json.NewDecoder(res.Body).Decode(out)
+}
+
+// nexus3Pages calls a paginated Nexus 3 endpoint until the continuation
+// token is exhausted, page receives the items of each page.
+func nexus3Pages(ctx context.Context, inst NexusInstance, p string,
+	q url.Values, page func(items json.RawMessage) error) error {
+	for {
+		var res struct {
+			Items             json.RawMessage `json:"items"`
+			ContinuationToken string          `json:"continuationToken"`
+		}
+		u := p
+		if len(q) > 0 {
+			u += "?" + q.Encode()
+		}
+		if err := nexus3(ctx, http.MethodGet, inst, u, nil,
+			&res); err != nil {
+			return err
+		}
+		if err := page(res.Items); err != nil {
+			return err
+		}
+		if res.ContinuationToken == "" {
+			return nil
+		}
+		q = cloneValues(q)
+		q.Set("continuationToken", res.ContinuationToken)
+	}
+}
+
+func cloneValues(q url.Values) url.Values {
+	c := make(url.Values)
+	for k, vs := range q {
+		c[k] = append([]string(nil), vs...)
+	}
+	return c
+}
@@ -0,0 +1,536 @@
+// Package nexusfetchtest provides a fake Nexus 2 server for end-to-end
+// tests of nexus-fetch and programs embedding it.
+//
+// The fake serves lucene search, the Maven resolve/content/redirect
+// services, default layout content including maven-metadata.xml, item
+// metadata and the repository list, all backed by in-memory artifacts.
+// DELETE of a content path removes all artifacts below it, PUT of a file in
+// default layout stores an artifact.
+package nexusfetchtest
+
+import (
+	"crypto/sha1"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ContextRoot is the path prefix the fake Nexus is served at.
+const ContextRoot = "nexus/"
+
+// Artifact is a single file stored in the fake Nexus.
+type Artifact struct {
+	Repository string
+	Group      string
+	Artifact   string
+	// Version may be a timestamped snapshot such as 1.0-20180312.173914-4
+	Version    string
+	Classifier string
+	// Extension defaults to jar
+	Extension string
+	Content   []byte
+	Uploaded  time.Time
+	Uploader  string
+}
+
+var timestamped = regexp.MustCompile(`-\d{8}\.\d{6}-\d+$`)
+
+// BaseVersion returns the version, or X-SNAPSHOT for timestamped snapshots.
+func (a Artifact) BaseVersion() string {
+	if timestamped.MatchString(a.Version) {
+		return timestamped.ReplaceAllString(a.Version, "-SNAPSHOT")
+	}
+	return a.Version
+}
+
+func (a Artifact) extension() string {
+	if a.Extension == "" {
+		return "jar"
+	}
+	return a.Extension
+}
+
+// Filename returns the name in default layout.
+func (a Artifact) Filename() string {
+	f := a.Artifact + "-" + a.Version
+	if a.Classifier != "" {
+		f += "-" + a.Classifier
+	}
+	return f + "." + a.extension()
+}
+
+// Path returns the repository path in default layout without leading /.
+func (a Artifact) Path() string {
+	return fmt.Sprintf("%s/%s/%s/%s", strings.Replace(a.Group, ".", "/", -1),
+		a.Artifact, a.BaseVersion(), a.Filename())
+}
+
+// Repository describes a repository of the fake Nexus.
+type Repository struct {
+	ID     string
+	Policy string
+}
+
+// Server is a fake Nexus. If Username is set, every request must
+// authenticate with Username and Password.
+type Server struct {
+	*httptest.Server
+	Username string
+	Password string
+
+	mu           sync.Mutex
+	repositories []Repository
+	artifacts    []Artifact
+	requests     []string
+}
+
+// NewServer starts a fake Nexus with repositories releases and snapshots.
+// Call Close when done.
+func NewServer(artifacts ...Artifact) *Server {
+	s := &Server{repositories: []Repository{
+		{"releases", "RELEASE"}, {"snapshots", "SNAPSHOT"}}}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	for _, a := range artifacts {
+		s.Add(a)
+	}
+	return s
+}
+
+// Add stores an artifact, creating its repository on the fly.
+func (s *Server) Add(a Artifact) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if a.Uploaded.IsZero() {
+		a.Uploaded = time.Now()
+	}
+	known := false
+	for _, r := range s.repositories {
+		known = known || r.ID == a.Repository
+	}
+	if !known {
+		s.repositories = append(s.repositories, Repository{a.Repository,
+			"MIXED"})
+	}
+	s.artifacts = append(s.artifacts, a)
+}
+
+// Host returns the host name of the fake Nexus.
+func (s *Server) Host() string {
+	u, _ := url.Parse(s.URL)
+	return u.Hostname()
+}
+
+// Port returns the port of the fake Nexus.
+func (s *Server) Port() string {
+	u, _ := url.Parse(s.URL)
+	return u.Port()
+}
+
+// Requests returns the request URIs received so far.
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string{}, s.requests...)
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, r.URL.RequestURI())
+	if s.Username != "" {
+		u, p, ok := r.BasicAuth()
+		if !ok || u != s.Username || p != s.Password {
+			w.Header().Set("WWW-Authenticate", `BASIC realm="Sonatype Nexus"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+	}
+	path := strings.TrimPrefix(r.URL.Path, "/"+ContextRoot)
+	q := r.URL.Query()
+	switch {
+	case r.Method == http.MethodDelete &&
+		strings.HasPrefix(path, "content/repositories/"):
+		s.delete(w, strings.TrimPrefix(path, "content/repositories/"))
+	case r.Method == http.MethodPut &&
+		strings.HasPrefix(path, "content/repositories/"):
+		s.put(w, r, strings.TrimPrefix(path, "content/repositories/"))
+	case path == "service/local/lucene/search":
+		s.search(w, q)
+	case path == "service/local/repositories":
+		s.listRepositories(w)
+	case path == "service/local/artifact/maven/resolve":
+		s.resolve(w, q)
+	case path == "service/local/artifact/maven/content":
+		s.content(w, q)
+	case path == "service/local/artifact/maven/redirect":
+		s.redirect(w, r, q)
+	case strings.HasPrefix(path, "service/local/repositories/") &&
+		q.Get("describe") == "info":
+		s.describe(w, strings.TrimPrefix(path, "service/local/repositories/"))
+	case strings.HasPrefix(path, "service/local/repositories/"):
+		s.list(w, strings.Replace(strings.TrimPrefix(path,
+			"service/local/repositories/"), "/content/", "/", 1))
+	case strings.HasPrefix(path, "content/repositories/"):
+		s.file(w, strings.TrimPrefix(path, "content/repositories/"))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// match returns all artifacts matching the non-empty query parameters.
+func (s *Server) match(q url.Values) []Artifact {
+	var as []Artifact
+	for _, a := range s.artifacts {
+		if !matches(q.Get("r"), a.Repository) ||
+			!matches(q.Get("repositoryId"), a.Repository) ||
+			!matches(q.Get("g"), a.Group) ||
+			!matches(q.Get("a"), a.Artifact) ||
+			!matches(q.Get("c"), a.Classifier) ||
+			!matches(q.Get("p"), a.extension()) ||
+			!matches(q.Get("e"), a.extension()) {
+			continue
+		}
+		v := q.Get("v")
+		if v != "" && v != "LATEST" && v != "RELEASE" &&
+			v != a.Version && v != a.BaseVersion() {
+			continue
+		}
+		if v == "RELEASE" && a.BaseVersion() != a.Version {
+			continue
+		}
+		as = append(as, a)
+	}
+	return as
+}
+
+// matches supports the trailing * wildcard of lucene searches.
+func matches(want, got string) bool {
+	if want == "" {
+		return true
+	}
+	if strings.HasSuffix(want, "*") {
+		return strings.HasPrefix(got, strings.TrimSuffix(want, "*"))
+	}
+	return want == got
+}
+
+// latest picks the most recently added match, which is how Nexus resolves
+// snapshots, LATEST and RELEASE.
+func (s *Server) latest(q url.Values) (Artifact, bool) {
+	as := s.match(q)
+	if len(as) == 0 {
+		return Artifact{}, false
+	}
+	return as[len(as)-1], true
+}
+
+func (s *Server) search(w http.ResponseWriter, q url.Values) {
+	as := s.match(q)
+	from, _ := strconv.Atoi(q.Get("from"))
+	count, err := strconv.Atoi(q.Get("count"))
+	if err != nil || count <= 0 {
+		count = 200
+	}
+	total := len(as)
+	if from > total {
+		from = total
+	}
+	if from+count < total {
+		as = as[from : from+count]
+	} else {
+		as = as[from:]
+	}
+	fmt.Fprintf(w, "<searchNGResponse><totalCount>%d</totalCount>"+
+		"<from>%d</from><count>%d</count>"+
+		"<tooManyResults>false</tooManyResults><data>", total, from, count)
+	for _, a := range as {
+		fmt.Fprintf(w, "<artifact><groupId>%s</groupId>"+
+			"<artifactId>%s</artifactId><version>%s</version>"+
+			"<artifactHits><artifactHit><repositoryId>%s</repositoryId>"+
+			"<artifactLinks><artifactLink><classifier>%s</classifier>"+
+			"<extension>%s</extension></artifactLink></artifactLinks>"+
+			"</artifactHit></artifactHits></artifact>",
+			esc(a.Group), esc(a.Artifact), esc(a.BaseVersion()),
+			esc(a.Repository), esc(a.Classifier), esc(a.extension()))
+	}
+	fmt.Fprint(w, "</data></searchNGResponse>")
+}
+
+func (s *Server) listRepositories(w http.ResponseWriter) {
+	fmt.Fprint(w, "<repositories><data>")
+	for _, r := range s.repositories {
+		fmt.Fprintf(w, "<repositories-item><id>%s</id><name>%s</name>"+
+			"<repoType>hosted</repoType><repoPolicy>%s</repoPolicy>"+
+			"<format>maven2</format></repositories-item>",
+			esc(r.ID), esc(r.ID), esc(r.Policy))
+	}
+	fmt.Fprint(w, "</data></repositories>")
+}
+
+func (s *Server) resolve(w http.ResponseWriter, q url.Values) {
+	a, ok := s.latest(q)
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	fmt.Fprintf(w, "<artifact-resolution><data>"+
+		"<presentLocally>true</presentLocally>"+
+		"<groupId>%s</groupId><artifactId>%s</artifactId>"+
+		"<version>%s</version><baseVersion>%s</baseVersion>"+
+		"<classifier>%s</classifier><extension>%s</extension>"+
+		"<snapshot>%v</snapshot><repositoryPath>/%s</repositoryPath>"+
+		"</data></artifact-resolution>",
+		esc(a.Group), esc(a.Artifact), esc(a.Version), esc(a.BaseVersion()),
+		esc(a.Classifier), esc(a.extension()),
+		a.Version != a.BaseVersion(), esc(a.Path()))
+}
+
+func (s *Server) content(w http.ResponseWriter, q url.Values) {
+	a, ok := s.latest(q)
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	serve(w, a)
+}
+
+func (s *Server) redirect(w http.ResponseWriter, r *http.Request,
+	q url.Values) {
+	a, ok := s.latest(q)
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	http.Redirect(w, r, fmt.Sprintf("/%scontent/repositories/%s/%s",
+		ContextRoot, a.Repository, a.Path()), http.StatusTemporaryRedirect)
+}
+
+func serve(w http.ResponseWriter, a Artifact) {
+	w.Header().Set("Content-Disposition",
+		fmt.Sprintf("attachment; filename=%q", a.Filename()))
+	w.Header().Set("Content-Length", strconv.Itoa(len(a.Content)))
+	w.Header().Set("Last-Modified", a.Uploaded.UTC().Format(http.TimeFormat))
+	w.Write(a.Content)
+}
+
+// lookup finds an artifact by repository and path.
+func (s *Server) lookup(p string) (Artifact, bool) {
+	for _, a := range s.artifacts {
+		if a.Repository+"/"+a.Path() == p {
+			return a, true
+		}
+	}
+	return Artifact{}, false
+}
+
+func (s *Server) describe(w http.ResponseWriter, p string) {
+	p = strings.Replace(p, "/content/", "/", 1)
+	a, ok := s.lookup(p)
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	fmt.Fprintf(w, "<org.sonatype.nexus.rest.model.ResourceResponse><data>"+
+		"<repositoryId>%s</repositoryId>"+
+		"<repositoryPath>/%s</repositoryPath>"+
+		"<mimeType>application/octet-stream</mimeType>"+
+		"<uploader>%s</uploader><uploaded>%d</uploaded>"+
+		"<lastChanged>%d</lastChanged><size>%d</size>"+
+		"</data></org.sonatype.nexus.rest.model.ResourceResponse>",
+		esc(a.Repository), esc(a.Path()), esc(a.Uploader),
+		millis(a.Uploaded), millis(a.Uploaded), len(a.Content))
+}
+
+// list returns the direct children of a repository directory.
+func (s *Server) list(w http.ResponseWriter, dir string) {
+	dir = strings.TrimSuffix(dir, "/") + "/"
+	seen := make(map[string]bool)
+	var sb strings.Builder
+	for _, a := range s.artifacts {
+		p := a.Repository + "/" + a.Path()
+		if !strings.HasPrefix(p, dir) {
+			continue
+		}
+		name := strings.TrimPrefix(p, dir)
+		leaf := !strings.Contains(name, "/")
+		if !leaf {
+			name = name[:strings.Index(name, "/")]
+		}
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		rel := strings.TrimPrefix(dir, a.Repository+"/") + name
+		var size int
+		if leaf {
+			size = len(a.Content)
+		}
+		fmt.Fprintf(&sb, "<content-item><text>%s</text>"+
+			"<relativePath>/%s</relativePath><leaf>%v</leaf>"+
+			"<lastModified>%s</lastModified><sizeOnDisk>%d</sizeOnDisk>"+
+			"</content-item>", esc(name), esc(rel), leaf,
+			a.Uploaded.UTC().Format("2006-01-02 15:04:05.0 UTC"), size)
+	}
+	if len(seen) == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	fmt.Fprintf(w, "<content><data>%s</data></content>", sb.String())
+}
+
+func millis(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+// delete removes the artifact stored at p or all artifacts below
+// directory p.
+func (s *Server) delete(w http.ResponseWriter, p string) {
+	dir := strings.TrimSuffix(p, "/") + "/"
+	var kept []Artifact
+	for _, a := range s.artifacts {
+		ap := a.Repository + "/" + a.Path()
+		if ap != p && !strings.HasPrefix(ap, dir) {
+			kept = append(kept, a)
+		}
+	}
+	if len(kept) == len(s.artifacts) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	s.artifacts = kept
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// put stores a release artifact, checksums and metadata are generated
+// and therefore ignored.
+func (s *Server) put(w http.ResponseWriter, r *http.Request, p string) {
+	ss := strings.Split(p, "/")
+	name := ss[len(ss)-1]
+	if strings.HasSuffix(name, ".sha1") || strings.HasSuffix(name, ".md5") ||
+		strings.HasPrefix(name, "maven-metadata") {
+		w.WriteHeader(http.StatusCreated)
+		return
+	}
+	if len(ss) < 5 {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	a := Artifact{Repository: ss[0],
+		Group:    strings.Join(ss[1:len(ss)-3], "."),
+		Artifact: ss[len(ss)-3], Version: ss[len(ss)-2]}
+	rest := strings.TrimPrefix(name, a.Artifact+"-"+a.Version)
+	if rest == name {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if strings.HasPrefix(rest, "-") {
+		i := strings.Index(rest, ".")
+		if i < 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		a.Classifier, rest = rest[1:i], rest[i:]
+	}
+	a.Extension = strings.TrimPrefix(rest, ".")
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	a.Content = body
+	a.Uploaded = time.Now()
+	var kept []Artifact
+	for _, b := range s.artifacts {
+		if b.Repository != a.Repository || b.Path() != a.Path() {
+			kept = append(kept, b)
+		}
+	}
+	s.artifacts = append(kept, a)
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (s *Server) file(w http.ResponseWriter, p string) {
+	if a, ok := s.lookup(p); ok {
+		serve(w, a)
+		return
+	}
+	if a, ok := s.lookup(strings.TrimSuffix(p, ".sha1")); ok {
+		fmt.Fprintf(w, "%x", sha1.Sum(a.Content))
+		return
+	}
+	if strings.HasSuffix(p, "/maven-metadata.xml") {
+		s.metadata(w, strings.TrimSuffix(p, "/maven-metadata.xml"))
+		return
+	}
+	w.WriteHeader(http.StatusNotFound)
+}
+
+// metadata generates artifact level or snapshot version level metadata.
+func (s *Server) metadata(w http.ResponseWriter, dir string) {
+	var versions, builds []string
+	var last Artifact
+	for _, a := range s.artifacts {
+		ga := a.Repository + "/" + strings.Replace(a.Group, ".", "/", -1) +
+			"/" + a.Artifact
+		switch dir {
+		case ga:
+			if !contains(versions, a.BaseVersion()) {
+				versions = append(versions, a.BaseVersion())
+			}
+		case ga + "/" + a.BaseVersion():
+			builds = append(builds, a.Version)
+		default:
+			continue
+		}
+		last = a
+	}
+	if len(versions) == 0 && len(builds) == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	fmt.Fprintf(w, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n"+
+		"<metadata><groupId>%s</groupId><artifactId>%s</artifactId>",
+		esc(last.Group), esc(last.Artifact))
+	updated := last.Uploaded.UTC().Format("20060102150405")
+	if len(builds) > 0 {
+		fmt.Fprintf(w, "<version>%s</version><versioning>",
+			esc(last.BaseVersion()))
+		if m := timestamped.FindString(last.Version); m != "" {
+			i := strings.LastIndex(m, "-")
+			fmt.Fprintf(w, "<snapshot><timestamp>%s</timestamp>"+
+				"<buildNumber>%s</buildNumber></snapshot>", m[1:i], m[i+1:])
+		}
+		fmt.Fprintf(w, "<lastUpdated>%s</lastUpdated>", updated)
+	} else {
+		fmt.Fprintf(w, "<versioning><latest>%s</latest><versions>",
+			esc(versions[len(versions)-1]))
+		for _, v := range versions {
+			fmt.Fprintf(w, "<version>%s</version>", esc(v))
+		}
+		fmt.Fprintf(w, "</versions><lastUpdated>%s</lastUpdated>", updated)
+	}
+	fmt.Fprint(w, "</versioning></metadata>")
+}
+
+func contains(ss []string, s string) bool {
+	for _, x := range ss {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+func esc(s string) string {
+	var sb strings.Builder
+	xml.EscapeText(&sb, []byte(s))
+	return sb.String()
+}
@@ -0,0 +1,54 @@
+package nexusfetchtest
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+func get(t *testing.T, s *Server, path, user, password string) (int,
+	string) {
+	req, err := http.NewRequest("GET", s.URL+"/"+ContextRoot+path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user != "" {
+		req.SetBasicAuth(user, password)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	body, _ := ioutil.ReadAll(res.Body)
+	return res.StatusCode, string(body)
+}
+
+func TestAuthEnforced(t *testing.T) {
+	s := NewServer(Artifact{Repository: "releases", Group: "g",
+		Artifact: "a", Version: "1.0", Content: []byte("jar")})
+	defer s.Close()
+	s.Username, s.Password = "admin", "admin123"
+	path := "content/repositories/releases/g/a/1.0/a-1.0.jar"
+	if code, _ := get(t, s, path, "", ""); code != 401 {
+		t.Fatalf("Expected 401 but got %d\n", code)
+	}
+	code, body := get(t, s, path, "admin", "admin123")
+	if code != 200 || body != "jar" {
+		t.Fatalf("Expected 200 jar but got %d %q\n", code, body)
+	}
+}
+
+func TestSnapshotResolution(t *testing.T) {
+	s := NewServer(
+		Artifact{Repository: "snapshots", Group: "g", Artifact: "a",
+			Version: "1.0-20180312.173914-4", Content: []byte("4")},
+		Artifact{Repository: "snapshots", Group: "g", Artifact: "a",
+			Version: "1.0-20180313.080000-5", Content: []byte("5")})
+	defer s.Close()
+	code, body := get(t, s, "service/local/artifact/maven/content"+
+		"?r=snapshots&g=g&a=a&v=1.0-SNAPSHOT", "", "")
+	if code != 200 || body != "5" {
+		t.Fatalf("Expected latest build 5 but got %d %q\n", code, body)
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Notification is sent to webhooks and notify commands as JSON.
+type Notification struct {
+	// Event is either "detected" (watch mode) or "fetched"
+	Event      string    `json:"event"`
+	Time       time.Time `json:"time"`
+	Repository string    `json:"repository"`
+	Gav        string    `json:"gav"`
+	Group      string    `json:"groupId"`
+	Artifact   string    `json:"artifactId"`
+	Version    string    `json:"version"`
+	Classifier string    `json:"classifier,omitempty"`
+	Packaging  string    `json:"packaging,omitempty"`
+	// File is the local path for fetched artifacts
+	File string `json:"file,omitempty"`
+}
+
+func newNotification(event string, a Fqa, file string) Notification {
+	return Notification{
+		Event:      event,
+		Time:       time.Now(),
+		Repository: a.RepositoryID,
+		Gav:        a.Gav.ConciseNotation(),
+		Group:      a.Group,
+		Artifact:   a.Artifact,
+		Version:    a.Version,
+		Classifier: a.Classifier,
+		Packaging:  a.Packaging,
+		File:       file,
+	}
+}
+
+// notifier POSTs notifications to a webhook and/or runs a command that
+// receives the JSON payload on stdin.
+type notifier struct {
+	webhook *string
+	command *string
+}
+
+func newNotifier(fs *flag.FlagSet) *notifier {
+	return &notifier{
+		webhook: fs.String("webhook", "",
+			"POST a JSON notification to this URL"),
+		command: fs.String("notify-command", "",
+			"Run this command with a JSON notification on stdin"),
+	}
+}
+
+func (a *notifier) validate() error {
+	if *a.command != "" && strings.TrimSpace(*a.command) == "" {
+		return fmt.Errorf("blank -notify-command")
+	}
+	return nil
+}
+
+// notify reports failures but never aborts, a broken chat integration
+// should not fail a download.
+func (a *notifier) notify(n Notification) {
+	if *a.webhook == "" && *a.command == "" {
+		return
+	}
+	buf, err := json.Marshal(n)
+	if err != nil {
+		log.Printf("cannot encode notification: %v\n", err)
+		return
+	}
+	if *a.webhook != "" {
+		if err := post(*a.webhook, buf); err != nil {
+			log.Printf("webhook failed: %v\n", err)
+		}
+	}
+	if *a.command != "" {
+		if err := run(*a.command, n, buf); err != nil {
+			log.Printf("notify command failed: %v\n", err)
+		}
+	}
+}
+
+func post(url string, payload []byte) error {
+	res, err := httpClient.Post(url, "application/json",
+		bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	if res.StatusCode/100 != 2 {
+		return fmt.Errorf("%s returns HTTP status code %d",
+			url, res.StatusCode)
+	}
+	return nil
+}
+
+func run(command string, n Notification, payload []byte) error {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return fmt.Errorf("blank notify command")
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(),
+		"NEXUS_FETCH_EVENT="+n.Event,
+		"NEXUS_FETCH_GAV="+n.Gav,
+		"NEXUS_FETCH_REPOSITORY="+n.Repository,
+		"NEXUS_FETCH_FILE="+n.File)
+	return cmd.Run()
+}
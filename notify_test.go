@@ -0,0 +1,43 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNotifyWebhook(t *testing.T) {
+	var got Notification
+	ts := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+				t.Fatal(err)
+			}
+		}))
+	defer ts.Close()
+
+	webhook, command := ts.URL, ""
+	nt := notifier{&webhook, &command}
+	fqa := Fqa{NexusRepository: NexusRepository{RepositoryID: "releases"},
+		Gav: Gav{Group: "g", Artifact: "a", Version: "v"}}
+	nt.notify(newNotification("fetched", fqa, "a-v.jar"))
+	if got.Gav != "g:a:v" || got.Event != "fetched" ||
+		got.File != "a-v.jar" || got.Repository != "releases" {
+		t.Fatalf("unexpected notification %+v\n", got)
+	}
+}
+
+func TestNotifierValidate(t *testing.T) {
+	webhook := ""
+	for command, ok := range map[string]bool{
+		"":            true,
+		"notify-send": true,
+		" \t":         false,
+	} {
+		nt := notifier{&webhook, &command}
+		if err := nt.validate(); (err == nil) != ok {
+			t.Fatalf("%q: expected ok %t but got %v\n", command, ok, err)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/template"
+)
+
+// Result is a search result or downloaded artifact as printed for scripts.
+type Result struct {
+	Repository string
+	Group      string
+	Artifact   string
+	Version    string
+	// ResolvedVersion is the timestamped version of a fetched snapshot
+	ResolvedVersion string
+	Classifier      string
+	Packaging       string
+	URL             string
+	// File is the local path, empty if not downloaded
+	File string
+	// RepositoryPath is the storage location inside the repository, only
+	// looked up if a -format-template uses it
+	RepositoryPath string
+}
+
+func newResult(a Fqa, url, file string) Result {
+	return Result{a.RepositoryID, a.Group, a.Artifact, a.Version,
+		resolvedVersion(a.Gav, file), a.Classifier, a.Packaging, url, file,
+		""}
+}
+
+// printer writes results to stdout in a script friendly format.
+type printer struct {
+	format   *string
+	print0   *bool
+	template *string
+	tmpl     *template.Template
+	w        io.Writer
+}
+
+func newPrinter(fs *flag.FlagSet) *printer {
+	return &printer{
+		format: fs.String("output", "",
+			"Print results as tsv, default prints nothing"),
+		print0: fs.Bool("print0", false,
+			"Print downloaded files (or coordinates) separated by NUL"),
+		template: fs.String("format-template", "",
+			"Print each result using a Go template, "+
+				"e.g. '{{.Group}}:{{.Artifact}}:{{.Version}}'"),
+		w: os.Stdout,
+	}
+}
+
+// uses reports whether the template refers to field, so that expensive
+// fields are only looked up on demand.
+func (a *printer) uses(field string) bool {
+	return a.tmpl != nil && strings.Contains(*a.template, "."+field)
+}
+
+// validate checks the format once after parsing flags.
+func (a *printer) validate() error {
+	if *a.template != "" {
+		t, err := template.New("format-template").Parse(*a.template)
+		if err != nil {
+			return err
+		}
+		a.tmpl = t
+	}
+	switch *a.format {
+	case "", "tsv":
+		return nil
+	}
+	return fmt.Errorf("unknown output format %q", *a.format)
+}
+
+var tsvEscaper = strings.NewReplacer("\\", "\\\\", "\t", "\\t",
+	"\n", "\\n", "\r", "\\r")
+
+func (a *printer) print(r Result) {
+	end := "\n"
+	if *a.print0 {
+		end = "\x00"
+	}
+	var s string
+	switch {
+	case a.tmpl != nil:
+		var sb strings.Builder
+		if err := a.tmpl.Execute(&sb, r); err != nil {
+			fail(err)
+		}
+		s = sb.String()
+	case *a.format == "tsv":
+		fields := []string{r.Repository, r.Group, r.Artifact, r.Version,
+			r.Classifier, r.Packaging, r.URL, r.File}
+		for i := range fields {
+			fields[i] = tsvEscaper.Replace(fields[i])
+		}
+		s = strings.Join(fields, "\t")
+	case *a.print0:
+		s = r.File
+		if s == "" {
+			s = Gav{Group: r.Group, Artifact: r.Artifact,
+				Version: r.Version, Classifier: r.Classifier,
+				Packaging: r.Packaging}.ConciseNotation()
+		}
+	default:
+		return
+	}
+	if _, err := io.WriteString(a.w, s+end); err != nil {
+		fail(err)
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func testPrinter(format string, print0 bool) (*printer, *bytes.Buffer) {
+	var buf bytes.Buffer
+	tmpl := ""
+	return &printer{format: &format, print0: &print0, template: &tmpl,
+		w: &buf}, &buf
+}
+
+func TestPrintTsv(t *testing.T) {
+	p, buf := testPrinter("tsv", false)
+	p.print(Result{Repository: "releases", Group: "g", Artifact: "a",
+		Version: "v", Packaging: "jar", File: "my\tdir/a-v.jar"})
+	want := "releases\tg\ta\tv\t\tjar\t\tmy\\tdir/a-v.jar\n"
+	if want != buf.String() {
+		t.Fatalf("Expected %q but got %q\n", want, buf.String())
+	}
+}
+
+func TestPrint0(t *testing.T) {
+	p, buf := testPrinter("", true)
+	p.print(Result{Group: "g", Artifact: "a", Version: "v",
+		File: "with space/a-v.jar"})
+	p.print(Result{Group: "g", Artifact: "a", Version: "w"})
+	want := "with space/a-v.jar\x00g:a:w\x00"
+	if want != buf.String() {
+		t.Fatalf("Expected %q but got %q\n", want, buf.String())
+	}
+}
+
+func TestPrintTemplate(t *testing.T) {
+	p, buf := testPrinter("", false)
+	*p.template = "{{.Group}}:{{.Artifact}}:{{.Version}} {{.File}}"
+	if err := p.validate(); err != nil {
+		t.Fatal(err)
+	}
+	p.print(Result{Group: "g", Artifact: "a", Version: "v",
+		File: "a-v.jar"})
+	want := "g:a:v a-v.jar\n"
+	if want != buf.String() {
+		t.Fatalf("Expected %q but got %q\n", want, buf.String())
+	}
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/signal"
+	"path"
+	"regexp"
+	"strings"
+	"syscall"
+
+	"github.com/jhinrichsen/nexus-fetch/nexus"
+)
+
+// p2 artifact classifiers
+const (
+	p2Bundle  = "osgi.bundle"
+	p2Feature = "org.eclipse.update.feature"
+)
+
+// p2Artifacts is the artifact repository index artifacts.xml of a p2
+// repository.
+type p2Artifacts struct {
+	Rules []struct {
+		Filter string `xml:"filter,attr"`
+		Output string `xml:"output,attr"`
+	} `xml:"mappings>rule"`
+	Artifacts []p2Artifact `xml:"artifacts>artifact"`
+}
+
+// p2Artifact is a bundle or feature of a p2 repository.
+type p2Artifact struct {
+	Classifier string `xml:"classifier,attr"`
+	ID         string `xml:"id,attr"`
+	Version    string `xml:"version,attr"`
+	Properties []struct {
+		Name  string `xml:"name,attr"`
+		Value string `xml:"value,attr"`
+	} `xml:"properties>property"`
+}
+
+func (a p2Artifact) property(name string) string {
+	for _, p := range a.Properties {
+		if p.Name == name {
+			return p.Value
+		}
+	}
+	return ""
+}
+
+// p2Content is the metadata repository index content.xml of a p2
+// repository.
+type p2Content struct {
+	Units []struct {
+		ID      string `xml:"id,attr"`
+		Version string `xml:"version,attr"`
+	} `xml:"units>unit"`
+}
+
+var p2FilterTerm = regexp.MustCompile(`\(\s*([\w.]+)\s*=\s*([^()\s]+)\s*\)`)
+
+// location returns the path of a artifact relative to the repository root
+// by applying the first matching mapping rule.
+func (a p2Artifacts) location(art p2Artifact) (string, error) {
+	attrs := map[string]string{
+		"classifier": art.Classifier,
+		"id":         art.ID,
+		"version":    art.Version,
+		"format":     art.property("format"),
+	}
+	for _, r := range a.Rules {
+		matches := true
+		for _, t := range p2FilterTerm.FindAllStringSubmatch(r.Filter, -1) {
+			if attrs[t[1]] != t[2] {
+				matches = false
+			}
+		}
+		if !matches {
+			continue
+		}
+		s := r.Output
+		for k, v := range attrs {
+			s = strings.Replace(s, "${"+k+"}", v, -1)
+		}
+		s = strings.Replace(s, "${repoUrl}", "", -1)
+		return strings.TrimLeft(s, "/"), nil
+	}
+	return "", fmt.Errorf("no mapping rule for %s %s %s", art.Classifier,
+		art.ID, art.Version)
+}
+
+// find returns the artifact with given classifier and id, of given version
+// or the highest version if empty. Packed variants are ignored.
+func (a p2Artifacts) find(classifier, id, version string) (p2Artifact,
+	bool) {
+	var found p2Artifact
+	var ok bool
+	for _, art := range a.Artifacts {
+		if art.Classifier != classifier || art.ID != id ||
+			art.property("format") != "" {
+			continue
+		}
+		if version != "" && art.Version != version {
+			continue
+		}
+		if !ok || compareVersions(art.Version, found.Version) > 0 {
+			found, ok = art, true
+		}
+	}
+	return found, ok
+}
+
+// p2Index reads an index such as artifacts or content of a p2 repository,
+// preferring the compressed jar over the plain xml.
+func p2Index(repo NexusRepository, name string) ([]byte, error) {
+	res, err := get(context.Background(),
+		RepositoryFileURL(repo, name+".jar"))
+	if err == nil {
+		defer res.Body.Close()
+		buf, err := ioutil.ReadAll(res.Body)
+		if err != nil {
+			return nil, err
+		}
+		zr, err := zip.NewReader(bytes.NewReader(buf), int64(len(buf)))
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range zr.File {
+			if f.Name != name+".xml" {
+				continue
+			}
+			rc, err := f.Open()
+			if err != nil {
+				return nil, err
+			}
+			defer rc.Close()
+			return ioutil.ReadAll(rc)
+		}
+		return nil, fmt.Errorf("%s.jar does not contain %s.xml", name, name)
+	}
+	if !nexus.IsNotFound(err) {
+		return nil, err
+	}
+	res, err = get(context.Background(),
+		RepositoryFileURL(repo, name+".xml"))
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	return ioutil.ReadAll(res.Body)
+}
+
+func p2Command(args []string) {
+	fs := newCommand("p2", "<bundle or feature id>[:version]")
+	nf := newNexusFlags(fs)
+	feature := fs.Bool("feature", false, "Fetch a feature instead of a "+
+		"bundle")
+	list := fs.Bool("list", false, "List installable units of the "+
+		"repository instead of fetching")
+	outputDir := fs.String("outputDir", ".", "Download directory")
+	dryRun := fs.Bool("dry-run", false,
+		"Print what would be fetched without downloading")
+	fs.Parse(args)
+	repo := nf.repo()
+
+	if *list {
+		buf, err := p2Index(repo, "content")
+		if err != nil {
+			fail(err)
+		}
+		var c p2Content
+		if err := xml.Unmarshal(buf, &c); err != nil {
+			fail(fmt.Errorf("content.xml: %w", err))
+		}
+		for _, u := range c.Units {
+			fmt.Printf("%s:%s\n", u.ID, u.Version)
+		}
+		return
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+	}
+
+	id, version := fs.Arg(0), ""
+	if i := strings.Index(id, ":"); i >= 0 {
+		id, version = id[:i], id[i+1:]
+	}
+	classifier := p2Bundle
+	if *feature {
+		classifier = p2Feature
+	}
+	buf, err := p2Index(repo, "artifacts")
+	if err != nil {
+		fail(err)
+	}
+	var as p2Artifacts
+	if err := xml.Unmarshal(buf, &as); err != nil {
+		fail(fmt.Errorf("artifacts.xml: %w", err))
+	}
+	art, ok := as.find(classifier, id, version)
+	if !ok {
+		log.Printf("%s %s not found in repository %s\n", classifier,
+			fs.Arg(0), repo.RepositoryID)
+		exit(exitNotFound)
+	}
+	p, err := as.location(art)
+	if err != nil {
+		fail(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt,
+		syscall.SIGTERM)
+	defer stop()
+	if *dryRun {
+		err := planFetch(ctx, RepositoryFileURL(repo, p), *outputDir,
+			nexus.SanitizeName(path.Base(p)), Gav{})
+		if err != nil {
+			exit(exitCode(err))
+		}
+		return
+	}
+	res, err := get(ctx, RepositoryFileURL(repo, p))
+	if err != nil {
+		fail(err)
+	}
+	f, err := persistBody(res, *outputDir, nexus.SanitizeName(path.Base(p)), 0)
+	if err != nil {
+		fail(err)
+	}
+	fmt.Println(f)
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/xml"
+	"testing"
+)
+
+const artifactsXML = `<?xml version='1.0' encoding='UTF-8'?>
+<?artifactRepository version='1.1.0'?>
+<repository name='test' type='org.eclipse.equinox.p2.artifact.repository.simpleRepository' version='1'>
+  <mappings size='3'>
+    <rule filter='(&amp; (classifier=osgi.bundle) (format=packed))' output='${repoUrl}/plugins/${id}_${version}.jar.pack.gz'/>
+    <rule filter='(&amp; (classifier=osgi.bundle))' output='${repoUrl}/plugins/${id}_${version}.jar'/>
+    <rule filter='(&amp; (classifier=org.eclipse.update.feature))' output='${repoUrl}/features/${id}_${version}.jar'/>
+  </mappings>
+  <artifacts size='4'>
+    <artifact classifier='osgi.bundle' id='org.acme.core' version='1.0.0.v2020'/>
+    <artifact classifier='osgi.bundle' id='org.acme.core' version='1.2.0.v2021'/>
+    <artifact classifier='osgi.bundle' id='org.acme.core' version='1.3.0.v2022'>
+      <properties size='1'>
+        <property name='format' value='packed'/>
+      </properties>
+    </artifact>
+    <artifact classifier='org.eclipse.update.feature' id='org.acme.feature' version='1.0.0'/>
+  </artifacts>
+</repository>`
+
+func TestP2Location(t *testing.T) {
+	var as p2Artifacts
+	if err := xml.Unmarshal([]byte(artifactsXML), &as); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		classifier, id, version, want string
+	}{
+		{p2Bundle, "org.acme.core", "", "plugins/org.acme.core_1.2.0.v2021.jar"},
+		{p2Bundle, "org.acme.core", "1.0.0.v2020",
+			"plugins/org.acme.core_1.0.0.v2020.jar"},
+		{p2Feature, "org.acme.feature", "", "features/org.acme.feature_1.0.0.jar"},
+	} {
+		art, ok := as.find(tt.classifier, tt.id, tt.version)
+		if !ok {
+			t.Fatalf("Expected to find %s\n", tt.id)
+		}
+		got, err := as.location(art)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Fatalf("Expected %s but got %s\n", tt.want, got)
+		}
+	}
+	if _, ok := as.find(p2Bundle, "org.acme.missing", ""); ok {
+		t.Fatalf("Expected missing bundle not to be found\n")
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// pick presents a numbered list of search results and lets the user choose
+// which ones to fetch, e.g. "1,3-5", "all" or nothing at all.
+func pick(ls []Fqa, in io.Reader, out io.Writer) ([]Fqa, error) {
+	for i, a := range ls {
+		fmt.Fprintf(out, "%3d) %s [%s]\n", i+1,
+			a.Gav.ConciseNotation(), a.RepositoryID)
+	}
+	sc := bufio.NewScanner(in)
+	for {
+		fmt.Fprintf(out, "select artifacts (e.g. 1,3-5 or all, "+
+			"empty for none): ")
+		if !sc.Scan() {
+			if err := sc.Err(); err != nil {
+				return nil, err
+			}
+			return nil, nil
+		}
+		idx, err := parseSelection(sc.Text(), len(ls))
+		if err != nil {
+			fmt.Fprintln(out, err)
+			continue
+		}
+		var picked []Fqa
+		for _, i := range idx {
+			picked = append(picked, ls[i])
+		}
+		return picked, nil
+	}
+}
+
+// parseSelection converts a user selection of 1-based numbers and ranges
+// into unique 0-based indices, preserving order.
+func parseSelection(s string, n int) ([]int, error) {
+	s = strings.TrimSpace(s)
+	if s == "all" || s == "*" {
+		s = fmt.Sprintf("1-%d", n)
+	}
+	var idx []int
+	seen := make(map[int]bool)
+	for _, part := range strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == ' '
+	}) {
+		from, to := part, part
+		if i := strings.Index(part, "-"); i > 0 {
+			from, to = part[:i], part[i+1:]
+		}
+		lo, err1 := strconv.Atoi(from)
+		hi, err2 := strconv.Atoi(to)
+		if err1 != nil || err2 != nil || lo < 1 || hi > n || lo > hi {
+			return nil, fmt.Errorf("illegal selection %q, "+
+				"expected numbers between 1 and %d", part, n)
+		}
+		for i := lo - 1; i < hi; i++ {
+			if !seen[i] {
+				seen[i] = true
+				idx = append(idx, i)
+			}
+		}
+	}
+	return idx, nil
+}
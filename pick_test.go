@@ -0,0 +1,49 @@
+package main
+
+import (
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseSelection(t *testing.T) {
+	for in, want := range map[string][]int{
+		"":        nil,
+		"1":       {0},
+		"3,1":     {2, 0},
+		"2-4":     {1, 2, 3},
+		"1 2,2-3": {0, 1, 2},
+		"all":     {0, 1, 2, 3, 4},
+	} {
+		got, err := parseSelection(in, 5)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(want, got) {
+			t.Fatalf("%q: expected %v but got %v\n", in, want, got)
+		}
+	}
+}
+
+func TestParseSelectionIllegal(t *testing.T) {
+	for _, in := range []string{"0", "6", "x", "4-2", "1-"} {
+		if _, err := parseSelection(in, 5); err == nil {
+			t.Fatalf("%q: expected error\n", in)
+		}
+	}
+}
+
+func TestPickRetriesOnIllegalInput(t *testing.T) {
+	ls := []Fqa{
+		{Gav: Gav{Group: "g", Artifact: "a", Version: "1"}},
+		{Gav: Gav{Group: "g", Artifact: "a", Version: "2"}},
+	}
+	got, err := pick(ls, strings.NewReader("9\n2\n"), ioutil.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Version != "2" {
+		t.Fatalf("Expected version 2 but got %+v\n", got)
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/jhinrichsen/nexus-fetch/nexus"
+)
+
+// nexusStatus is the part of Nexus 2's service/local/status used by ping.
+type nexusStatus struct {
+	Version string `xml:"data>version"`
+	Edition string `xml:"data>editionShort"`
+}
+
+// pingCheck is a single step of ping, it returns a short detail on
+// success.
+type pingCheck struct {
+	name string
+	run  func(ctx context.Context) (string, error)
+}
+
+func pingChecks(repo NexusRepository) []pingCheck {
+	base := nexus.BaseURL(repo).String()
+	cs := []pingCheck{{"server", func(ctx context.Context) (string,
+		error) {
+		res, err := get(ctx, base+"service/local/status")
+		if nexus.IsNotFound(err) {
+			// Nexus 3 answers its own status endpoint
+			if res, err = get(ctx,
+				base+"service/rest/v1/status"); err == nil {
+				res.Body.Close()
+				return "Nexus 3", nil
+			}
+		}
+		if err != nil {
+			return "", err
+		}
+		defer res.Body.Close()
+		var s nexusStatus
+		if err := xml.NewDecoder(res.Body).Decode(&s); err != nil {
+			return "Nexus 2, unknown version", nil
+		}
+		return fmt.Sprintf("Nexus %s %s", s.Edition, s.Version), nil
+	}}}
+	if repo.Username != "" {
+		cs = append(cs, pingCheck{"authentication",
+			func(ctx context.Context) (string, error) {
+				res, err := getAs(ctx, repo.NexusInstance,
+					base+"service/local/authentication/login")
+				if err != nil {
+					return "", err
+				}
+				res.Body.Close()
+				return "logged in as " + repo.Username, nil
+			}})
+	}
+	if repo.RepositoryID != "" {
+		cs = append(cs, pingCheck{"repository", func(ctx context.Context) (
+			string, error) {
+			res, err := getAs(ctx, repo.NexusInstance,
+				ContentListURL(repo, ""))
+			if err != nil {
+				return "", err
+			}
+			res.Body.Close()
+			return "can read " + repo.RepositoryID, nil
+		}})
+	}
+	return cs
+}
+
+// diagnose explains why a check failed.
+func diagnose(check string, err error) string {
+	var se *StatusError
+	if !errors.As(err, &se) {
+		if exitCode(err) == exitNetwork {
+			return "server not reachable, check -server, -port and " +
+				"-protocol: " + err.Error()
+		}
+		return err.Error()
+	}
+	switch {
+	case se.StatusCode == http.StatusUnauthorized:
+		return "invalid credentials, check -username and -password"
+	case se.StatusCode == http.StatusForbidden:
+		return "no permission, the account lacks the required privilege"
+	case se.StatusCode == http.StatusNotFound && check == "repository":
+		return "repository does not exist, check -repository"
+	case se.StatusCode == http.StatusNotFound:
+		return "not found, check -contextroot and -base-path: " + se.URL
+	}
+	return err.Error()
+}
+
+// ping runs all checks and stops at the first failure, which it returns.
+func ping(ctx context.Context, w io.Writer, cs []pingCheck) error {
+	for _, c := range cs {
+		start := time.Now()
+		detail, err := c.run(ctx)
+		elapsed := time.Since(start).Round(time.Millisecond)
+		if err != nil {
+			fmt.Fprintf(w, "%-15s FAIL %s (%s)\n", c.name,
+				diagnose(c.name, err), elapsed)
+			return err
+		}
+		fmt.Fprintf(w, "%-15s ok   %s (%s)\n", c.name, detail, elapsed)
+	}
+	return nil
+}
+
+func pingCommand(args []string) {
+	fs := newCommand("ping", "")
+	nf := newNexusFlags(fs)
+	fs.Parse(args)
+	if fs.NArg() != 0 {
+		fs.Usage()
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt,
+		syscall.SIGTERM)
+	defer stop()
+	if err := ping(ctx, os.Stdout, pingChecks(nf.repo())); err != nil {
+		exit(exitCode(err))
+	}
+}
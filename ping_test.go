@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestPing(t *testing.T) {
+	inst := fakeNexus(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/nexus/service/local/status":
+			w.Write([]byte("<status><data><version>2.14.5-02</version>" +
+				"<editionShort>OSS</editionShort></data></status>"))
+		case "/nexus/service/local/authentication/login":
+			if u, p, _ := r.BasicAuth(); u != "admin" || p != "admin123" {
+				w.WriteHeader(http.StatusUnauthorized)
+			}
+		case "/nexus/service/local/repositories/releases/content/":
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	for _, tt := range []struct {
+		repository, password string
+		code                 int
+		want                 string
+	}{
+		{"releases", "admin123", 0, "repository      ok   can read releases"},
+		{"releases", "wrong", exitAuth, "invalid credentials"},
+		{"missing", "admin123", exitError, "repository does not exist"},
+	} {
+		inst.Password = tt.password
+		var sb strings.Builder
+		err := ping(context.Background(), &sb,
+			pingChecks(NexusRepository{NexusInstance: inst,
+				RepositoryID: tt.repository}))
+		if code := exitCode(err); err != nil && code != tt.code ||
+			err == nil && tt.code != 0 {
+			t.Fatalf("Expected exit code %d but got %v\n", tt.code, err)
+		}
+		if !strings.Contains(sb.String(), "server          ok   "+
+			"Nexus OSS 2.14.5-02") || !strings.Contains(sb.String(),
+			tt.want) {
+			t.Fatalf("Expected %q in %q\n", tt.want, sb.String())
+		}
+	}
+}
@@ -0,0 +1,715 @@
+package main
+
+import (
+	"context"
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"syscall"
+
+	"github.com/jhinrichsen/nexus-fetch/nexus"
+)
+
+// pom holds the parts of a Maven project object model needed to resolve
+// dependencies.
+type pom struct {
+	GroupID    string `xml:"groupId"`
+	ArtifactID string `xml:"artifactId"`
+	Version    string `xml:"version"`
+	Packaging  string `xml:"packaging"`
+	Parent     struct {
+		GroupID      string  `xml:"groupId"`
+		ArtifactID   string  `xml:"artifactId"`
+		Version      string  `xml:"version"`
+		RelativePath *string `xml:"relativePath"`
+	} `xml:"parent"`
+	Properties           properties      `xml:"properties"`
+	DependencyManagement []pomDependency `xml:"dependencyManagement>dependencies>dependency"`
+	Dependencies         []pomDependency `xml:"dependencies>dependency"`
+	Relocation           *relocation     `xml:"distributionManagement>relocation"`
+	Licenses             []pomLicense    `xml:"licenses>license"`
+}
+
+// relocation points to the new coordinates of a moved artifact, empty
+// fields keep their old value.
+type relocation struct {
+	GroupID    string `xml:"groupId"`
+	ArtifactID string `xml:"artifactId"`
+	Version    string `xml:"version"`
+	Message    string `xml:"message"`
+}
+
+// pomDependency is a dependency as declared in a POM.
+type pomDependency struct {
+	GroupID    string `xml:"groupId"`
+	ArtifactID string `xml:"artifactId"`
+	Version    string `xml:"version"`
+	Classifier string `xml:"classifier"`
+	Type       string `xml:"type"`
+	Scope      string `xml:"scope"`
+	Optional   string `xml:"optional"`
+	Exclusions []struct {
+		GroupID    string `xml:"groupId"`
+		ArtifactID string `xml:"artifactId"`
+	} `xml:"exclusions>exclusion"`
+}
+
+// key identifies a dependency independent of its version.
+func (a pomDependency) key() string {
+	t := a.Type
+	if t == "" {
+		t = "jar"
+	}
+	return a.GroupID + ":" + a.ArtifactID + ":" + t + ":" + a.Classifier
+}
+
+// Gav converts a dependency into coordinates, mapping its type to a
+// packaging.
+func (a pomDependency) Gav() Gav {
+	gav := Gav{Group: a.GroupID, Artifact: a.ArtifactID, Version: a.Version,
+		Classifier: a.Classifier, Packaging: a.Type}
+	switch a.Type {
+	case "", "jar", "bundle", "maven-plugin", "ejb":
+		gav.Packaging = "jar"
+	case "test-jar":
+		gav.Packaging = "jar"
+		gav.Classifier = "tests"
+	}
+	return gav
+}
+
+// properties collects arbitrary <properties> children.
+type properties map[string]string
+
+func (a *properties) UnmarshalXML(d *xml.Decoder,
+	start xml.StartElement) error {
+	*a = make(properties)
+	for {
+		t, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch e := t.(type) {
+		case xml.StartElement:
+			var s string
+			if err := d.DecodeElement(&s, &e); err != nil {
+				return err
+			}
+			(*a)[e.Name.Local] = strings.TrimSpace(s)
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+func parsePom(r io.Reader) (*pom, error) {
+	var p pom
+	if err := xml.NewDecoder(r).Decode(&p); err != nil {
+		return nil, err
+	}
+	if p.Properties == nil {
+		p.Properties = make(properties)
+	}
+	return &p, nil
+}
+
+// pomLoader reads POMs from the local file system and from Nexus, caching
+// the latter.
+type pomLoader struct {
+	ctx   context.Context
+	repos []NexusRepository
+	cache map[Gav]*pom
+	// noRelocation fails on relocated dependencies instead of following
+	// them
+	noRelocation bool
+	// forced versions win any mediation
+	forced forcedVersions
+	// scopes to follow, Maven's defaults if empty
+	scopes map[string]bool
+	// includeOptional follows optional transitive dependencies
+	includeOptional bool
+	// conflicts of the last resolution
+	conflicts []versionConflict
+	// loading detects cycles of parents and imported BOMs
+	loading map[Gav]bool
+}
+
+func newPomLoader(ctx context.Context, repos []NexusRepository) *pomLoader {
+	return &pomLoader{ctx: ctx, repos: repos, cache: make(map[Gav]*pom),
+		forced: make(forcedVersions), loading: make(map[Gav]bool)}
+}
+
+// forcedVersions maps group:artifact to a version, see -force-version.
+type forcedVersions map[string]string
+
+func (a forcedVersions) String() string {
+	var ss []string
+	for k, v := range a {
+		ss = append(ss, k+":"+v)
+	}
+	sort.Strings(ss)
+	return strings.Join(ss, ",")
+}
+
+func (a forcedVersions) Set(s string) error {
+	gav, err := parseConcise(s)
+	if err != nil || gav.Group == "" || gav.Artifact == "" ||
+		gav.Version == "" {
+		return fmt.Errorf("want group:artifact:version but got %q", s)
+	}
+	a[gav.Group+":"+gav.Artifact] = gav.Version
+	return nil
+}
+
+// resolveFlags control transitive resolution.
+type resolveFlags struct {
+	forced   forcedVersions
+	scopes   *string
+	optional *bool
+}
+
+func newResolveFlags(fs *flag.FlagSet) *resolveFlags {
+	a := &resolveFlags{forced: make(forcedVersions)}
+	fs.Var(a.forced, "force-version", "Use this version of g:a "+
+		"regardless of mediation, format g:a:v, may be repeated")
+	a.scopes = fs.String("scopes", "", "Comma separated scopes of "+
+		"dependencies to follow, e.g. compile,runtime, default all; test "+
+		"and provided dependencies are followed only if direct")
+	a.optional = fs.Bool("include-optional", false,
+		"Follow optional transitive dependencies")
+	return a
+}
+
+func (a *resolveFlags) apply(pl *pomLoader) {
+	pl.forced = a.forced
+	pl.includeOptional = *a.optional
+	for _, s := range strings.Split(*a.scopes, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			if pl.scopes == nil {
+				pl.scopes = make(map[string]bool)
+			}
+			pl.scopes[s] = true
+		}
+	}
+}
+
+// follows reports whether a dependency of the given scope at depth, 0 for
+// direct ones, is part of the resolution.
+func (a *pomLoader) follows(scope string, optional bool, depth int) bool {
+	if scope == "" {
+		scope = "compile"
+	}
+	if scope == "system" {
+		return false
+	}
+	if depth > 0 && optional && !a.includeOptional {
+		return false
+	}
+	// like Maven, test and provided dependencies are not transitive
+	if depth > 0 && (scope == "test" || scope == "provided") {
+		return false
+	}
+	return a.scopes == nil || a.scopes[scope]
+}
+
+// versionConflict is a dependency that lost mediation.
+type versionConflict struct {
+	Artifact string
+	Winner   string
+	Loser    string
+	// By requires the losing version
+	By     Gav
+	Forced bool
+}
+
+func (a versionConflict) String() string {
+	reason := "nearest"
+	if a.Forced {
+		reason = "forced"
+	}
+	return fmt.Sprintf("%s %s wins over %s required by %s (%s)",
+		a.Artifact, a.Winner, a.Loser, a.By.ConciseNotation(), reason)
+}
+
+// fetch returns the effective POM for gav from the first repository that
+// contains it.
+func (a *pomLoader) fetch(gav Gav) (*pom, error) {
+	gav.Classifier = ""
+	gav.Packaging = "pom"
+	if p, ok := a.cache[gav]; ok {
+		return p, nil
+	}
+	if a.loading[gav] {
+		return nil, fmt.Errorf("%s inherits or imports itself",
+			gav.ConciseNotation())
+	}
+	a.loading[gav] = true
+	defer delete(a.loading, gav)
+	var err error
+	for _, repo := range a.repos {
+		var p *pom
+		p, err = a.fetchFrom(Fqa{NexusRepository: repo, Gav: gav})
+		if nexus.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if p, err = a.effective(p, ""); err != nil {
+			return nil, err
+		}
+		a.cache[gav] = p
+		return p, nil
+	}
+	if err == nil {
+		err = fmt.Errorf("no repository to fetch %s from",
+			gav.ConciseNotation())
+	}
+	return nil, err
+}
+
+func (a *pomLoader) fetchFrom(fqa Fqa) (*pom, error) {
+	res, err := content(a.ctx, fqa)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	p, err := parsePom(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", fqa.Gav.ConciseNotation(), err)
+	}
+	return p, nil
+}
+
+// open returns the effective POM of a local file.
+func (a *pomLoader) open(filename string) (*pom, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	p, err := parsePom(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	return a.effective(p, filepath.Dir(filename))
+}
+
+// parent returns the effective parent POM, preferring the file at
+// relativePath if it declares the expected coordinates.
+func (a *pomLoader) parent(p *pom, dir string) (*pom, error) {
+	gav := Gav{Group: p.Parent.GroupID, Artifact: p.Parent.ArtifactID,
+		Version: p.Parent.Version}
+	if dir != "" {
+		rel := "../pom.xml"
+		if p.Parent.RelativePath != nil {
+			rel = *p.Parent.RelativePath
+		}
+		if rel != "" {
+			f := filepath.Join(dir, filepath.FromSlash(rel))
+			if fi, err := os.Stat(f); err == nil && fi.IsDir() {
+				f = filepath.Join(f, "pom.xml")
+			}
+			if local, err := a.open(f); err == nil &&
+				local.GroupID == gav.Group &&
+				local.ArtifactID == gav.Artifact &&
+				local.Version == gav.Version {
+				return local, nil
+			}
+		}
+	}
+	return a.fetch(gav)
+}
+
+// relocate follows relocations of d as Maven does. Dependencies without a
+// POM are kept as they are.
+func (a *pomLoader) relocate(d pomDependency) (pomDependency, error) {
+	seen := make(map[string]bool)
+	for {
+		from := d.GroupID + ":" + d.ArtifactID + ":" + d.Version
+		if seen[from] {
+			return d, fmt.Errorf("relocation cycle at %s", from)
+		}
+		seen[from] = true
+		p, err := a.fetch(d.Gav())
+		if nexus.IsNotFound(err) {
+			return d, nil
+		}
+		if err != nil {
+			return d, err
+		}
+		r := p.Relocation
+		if r == nil {
+			return d, nil
+		}
+		if r.GroupID != "" {
+			d.GroupID = r.GroupID
+		}
+		if r.ArtifactID != "" {
+			d.ArtifactID = r.ArtifactID
+		}
+		if r.Version != "" {
+			d.Version = r.Version
+		}
+		to := d.GroupID + ":" + d.ArtifactID + ":" + d.Version
+		if to == from {
+			return d, nil
+		}
+		why := ""
+		if r.Message != "" {
+			why = ": " + r.Message
+		}
+		if a.noRelocation {
+			return d, fmt.Errorf("%s has been relocated to %s%s", from,
+				to, why)
+		}
+		log.Printf("notice: %s has been relocated to %s%s\n", from, to,
+			why)
+	}
+}
+
+// relocateGav follows relocations of requested coordinates, keeping their
+// classifier and packaging.
+func (a *pomLoader) relocateGav(gav Gav) (Gav, error) {
+	d, err := a.relocate(pomDependency{GroupID: gav.Group,
+		ArtifactID: gav.Artifact, Version: gav.Version})
+	if err != nil {
+		return gav, err
+	}
+	gav.Group, gav.Artifact, gav.Version = d.GroupID, d.ArtifactID, d.Version
+	return gav, nil
+}
+
+// effective merges p with its parents, interpolates properties and imports
+// BOMs. dir is the directory of a local POM, or empty for POMs fetched from
+// Nexus.
+func (a *pomLoader) effective(p *pom, dir string) (*pom, error) {
+	if p.Parent.ArtifactID != "" {
+		parent, err := a.parent(p, dir)
+		if err != nil {
+			return nil, fmt.Errorf("parent of %s: %w", p.ArtifactID, err)
+		}
+		if p.GroupID == "" {
+			p.GroupID = parent.GroupID
+		}
+		if p.Version == "" {
+			p.Version = parent.Version
+		}
+		for k, v := range parent.Properties {
+			if _, ok := p.Properties[k]; !ok {
+				p.Properties[k] = v
+			}
+		}
+		p.DependencyManagement = append(p.DependencyManagement,
+			parent.DependencyManagement...)
+		p.Dependencies = append(p.Dependencies, parent.Dependencies...)
+		if len(p.Licenses) == 0 {
+			p.Licenses = parent.Licenses
+		}
+	}
+	p.interpolate()
+	return p, a.imports(p)
+}
+
+// imports replaces import scoped dependency management by the dependency
+// management of the referenced BOM. Declarations of p win.
+func (a *pomLoader) imports(p *pom) error {
+	var own, imported []pomDependency
+	for _, d := range p.DependencyManagement {
+		if d.Scope != "import" || d.Type != "pom" {
+			own = append(own, d)
+			continue
+		}
+		bom, err := a.fetch(Gav{Group: d.GroupID, Artifact: d.ArtifactID,
+			Version: d.Version})
+		if err != nil {
+			return fmt.Errorf("BOM %s:%s:%s imported by %s: %w", d.GroupID,
+				d.ArtifactID, d.Version, p.ArtifactID, err)
+		}
+		imported = append(imported, bom.DependencyManagement...)
+	}
+	p.DependencyManagement = append(own, imported...)
+	return nil
+}
+
+var pomProperty = regexp.MustCompile(`\$\{([^}]+)\}`)
+
+// interpolate replaces ${...} references in all coordinates.
+func (a *pom) interpolate() {
+	lookup := func(name string) (string, bool) {
+		switch name {
+		case "project.groupId", "pom.groupId", "groupId":
+			return a.GroupID, true
+		case "project.artifactId", "pom.artifactId", "artifactId":
+			return a.ArtifactID, true
+		case "project.version", "pom.version", "version":
+			return a.Version, true
+		case "project.parent.groupId", "parent.groupId":
+			return a.Parent.GroupID, true
+		case "project.parent.artifactId", "parent.artifactId":
+			return a.Parent.ArtifactID, true
+		case "project.parent.version", "parent.version":
+			return a.Parent.Version, true
+		}
+		if strings.HasPrefix(name, "env.") {
+			return os.LookupEnv(name[len("env."):])
+		}
+		v, ok := a.Properties[name]
+		return v, ok
+	}
+	expand := func(s string) string {
+		// properties may refer to other properties
+		for i := 0; i < 10 && strings.Contains(s, "${"); i++ {
+			s = pomProperty.ReplaceAllStringFunc(s, func(m string) string {
+				if v, ok := lookup(m[2 : len(m)-1]); ok {
+					return v
+				}
+				return m
+			})
+		}
+		return s
+	}
+	a.GroupID = expand(a.GroupID)
+	a.Version = expand(a.Version)
+	for _, ds := range [][]pomDependency{a.DependencyManagement,
+		a.Dependencies} {
+		for i := range ds {
+			d := &ds[i]
+			d.GroupID = expand(d.GroupID)
+			d.ArtifactID = expand(d.ArtifactID)
+			d.Version = expand(d.Version)
+			d.Classifier = expand(d.Classifier)
+			d.Type = expand(d.Type)
+			d.Scope = expand(d.Scope)
+		}
+	}
+}
+
+// managed returns the dependency management of p by dependency key.
+func (a *pom) managed() map[string]pomDependency {
+	m := make(map[string]pomDependency)
+	for _, d := range a.DependencyManagement {
+		// children come first and win
+		if _, ok := m[d.key()]; !ok {
+			m[d.key()] = d
+		}
+	}
+	return m
+}
+
+// depNode is a dependency in the resolved tree. Omitted nodes lost
+// mediation against the version nearer to the root, their dependencies are
+// not resolved.
+type depNode struct {
+	gav      Gav
+	scope    string
+	omitted  string
+	children []*depNode
+}
+
+// dependencies returns the direct dependencies of root, or the transitive
+// closure where the dependency nearest to root wins. Unless configured
+// otherwise, transitive dependencies exclude test, provided and optional
+// ones, as Maven does.
+func (a *pomLoader) dependencies(root *pom, transitive bool) ([]Gav,
+	error) {
+	_, gavs, err := a.resolveTree(root, transitive)
+	return gavs, err
+}
+
+// resolveTree resolves the dependencies of root breadth first, so that the
+// first occurrence of an artifact is the nearest. It returns the tree and
+// the winning dependencies in resolution order.
+func (a *pomLoader) resolveTree(root *pom, transitive bool) (*depNode,
+	[]Gav, error) {
+	type node struct {
+		p        *pom
+		excluded map[string]bool
+		tree     *depNode
+	}
+	tree := &depNode{gav: Gav{Group: root.GroupID,
+		Artifact: root.ArtifactID, Version: root.Version,
+		Packaging: root.Packaging}}
+	rootManaged := root.managed()
+	seen := make(map[string]string)
+	a.conflicts = nil
+	var gavs []Gav
+	queue := []node{{root, nil, tree}}
+	for depth := 0; len(queue) > 0; depth++ {
+		var next []node
+		for _, n := range queue {
+			managed := n.p.managed()
+			for _, d := range n.p.Dependencies {
+				m := managed[d.key()]
+				// the root's dependency management overrides versions
+				// of transitive dependencies
+				if rm, ok := rootManaged[d.key()]; ok && depth > 0 &&
+					rm.Version != "" {
+					d.Version = rm.Version
+				}
+				if d.Version == "" {
+					d.Version = m.Version
+				}
+				if d.Scope == "" {
+					d.Scope = m.Scope
+				}
+				forced, isForced := a.forced[d.GroupID+":"+d.ArtifactID]
+				if isForced {
+					d.Version = forced
+				}
+				if n.excluded[d.GroupID+":"+d.ArtifactID] ||
+					!a.follows(d.Scope, d.Optional == "true", depth) {
+					continue
+				}
+				if d.Version == "" {
+					return nil, nil, fmt.Errorf("%s:%s: missing version",
+						d.GroupID, d.ArtifactID)
+				}
+				if m := pomProperty.FindString(d.GroupID + d.ArtifactID +
+					d.Version); m != "" {
+					return nil, nil, fmt.Errorf("%s:%s:%s: undefined "+
+						"property %s", d.GroupID, d.ArtifactID, d.Version, m)
+				}
+				var err error
+				if d, err = a.relocate(d); err != nil {
+					return nil, nil, err
+				}
+				child := &depNode{gav: d.Gav(), scope: d.Scope}
+				if child.scope == "" {
+					child.scope = "compile"
+				}
+				n.tree.children = append(n.tree.children, child)
+				if v, ok := seen[d.key()]; ok {
+					child.omitted = "duplicate"
+					if v != d.Version {
+						child.omitted = "conflict with " + v
+						a.conflicts = append(a.conflicts, versionConflict{
+							d.GroupID + ":" + d.ArtifactID, v, d.Version,
+							n.tree.gav, isForced})
+					}
+					continue
+				}
+				seen[d.key()] = d.Version
+				gavs = append(gavs, d.Gav())
+				if !transitive {
+					continue
+				}
+				dp, err := a.fetch(d.Gav())
+				if err != nil {
+					return nil, nil, err
+				}
+				excluded := make(map[string]bool)
+				for k := range n.excluded {
+					excluded[k] = true
+				}
+				for _, e := range d.Exclusions {
+					excluded[e.GroupID+":"+e.ArtifactID] = true
+				}
+				next = append(next, node{dp, excluded, child})
+			}
+		}
+		queue = next
+	}
+	return tree, gavs, nil
+}
+
+// renderDeps writes the tree like mvn dependency:tree.
+func renderDeps(w io.Writer, n *depNode) {
+	fmt.Fprintln(w, n.gav.ConciseNotation())
+	var walk func(n *depNode, indent string)
+	walk = func(n *depNode, indent string) {
+		for i, c := range n.children {
+			branch, next := "├── ", "│   "
+			if i == len(n.children)-1 {
+				branch, next = "└── ", "    "
+			}
+			s := c.gav.ConciseNotation() + " (" + c.scope + ")"
+			if c.omitted != "" {
+				s = "(" + c.gav.ConciseNotation() + " - omitted for " +
+					c.omitted + ")"
+			}
+			fmt.Fprintf(w, "%s%s%s\n", indent, branch, s)
+			walk(c, indent+next)
+		}
+	}
+	walk(n, "")
+}
+
+func treeDepsCommand(args []string) {
+	fs := newCommand("tree-deps", "<g:a:v>")
+	nf := newNexusFlags(fs)
+	rf := newResolveFlags(fs)
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+	}
+	gav, err := parseConcise(fs.Arg(0))
+	if err != nil || gav.Group == "" || gav.Artifact == "" ||
+		gav.Version == "" {
+		log.Printf("expected group:artifact:version: %q\n", fs.Arg(0))
+		exit(exitUsage)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt,
+		syscall.SIGTERM)
+	defer stop()
+	pl := newPomLoader(ctx, nf.repos())
+	rf.apply(pl)
+	p, err := pl.fetch(gav)
+	if err != nil {
+		fail(err)
+	}
+	tree, _, err := pl.resolveTree(p, true)
+	if err != nil {
+		fail(err)
+	}
+	renderDeps(os.Stdout, tree)
+}
+
+func fromPomCommand(args []string) {
+	fs := newCommand("from-pom", "<pom.xml>")
+	nf := newNexusFlags(fs)
+	ff := newFetchFlags(fs)
+	transitive := fs.Bool("transitive", false,
+		"Fetch the full closure of dependencies, not just direct ones")
+	noRelocation := fs.Bool("no-relocation", false,
+		"Fail on relocated dependencies instead of following them")
+	lf := newLicenseFlags(fs)
+	rf := newResolveFlags(fs)
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt,
+		syscall.SIGTERM)
+	defer stop()
+	pl := newPomLoader(ctx, nf.repos())
+	pl.noRelocation = *noRelocation
+	rf.apply(pl)
+	p, err := pl.open(fs.Arg(0))
+	if err != nil {
+		fail(err)
+	}
+	gavs, err := pl.dependencies(p, *transitive)
+	if err != nil {
+		fail(err)
+	}
+	log.Printf("%s resolves to %d dependencies\n", fs.Arg(0), len(gavs))
+	for _, c := range pl.conflicts {
+		log.Printf("conflict: %s\n", c)
+	}
+	if err := lf.check(pl, gavs); err != nil {
+		fail(err)
+	}
+	failures := ff.fetchAll(ctx, nf.repos(), gavs)
+	exit(summaryExitCode(len(gavs), failures))
+}
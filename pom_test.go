@@ -0,0 +1,394 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/jhinrichsen/nexus-fetch/nexusfetchtest"
+)
+
+func pomArtifact(g, a, v, xml string) nexusfetchtest.Artifact {
+	return nexusfetchtest.Artifact{Repository: "releases", Group: g,
+		Artifact: a, Version: v, Extension: "pom", Content: []byte(xml)}
+}
+
+func TestPomDependencies(t *testing.T) {
+	_, inst := newFakeNexus(t,
+		pomArtifact("com.acme", "parent", "1", `<project>
+  <groupId>com.acme</groupId><artifactId>parent</artifactId>
+  <version>1</version>
+  <properties><lib.version>2.0</lib.version></properties>
+  <dependencyManagement><dependencies>
+    <dependency><groupId>com.acme</groupId><artifactId>util</artifactId>
+      <version>3.0</version></dependency>
+  </dependencies></dependencyManagement>
+</project>`),
+		pomArtifact("com.acme", "lib", "2.0", `<project>
+  <groupId>com.acme</groupId><artifactId>lib</artifactId>
+  <version>2.0</version>
+  <dependencies>
+    <dependency><groupId>com.acme</groupId><artifactId>util</artifactId>
+      <version>1.0</version></dependency>
+    <dependency><groupId>com.acme</groupId><artifactId>excluded</artifactId>
+      <version>1.0</version></dependency>
+    <dependency><groupId>junit</groupId><artifactId>junit</artifactId>
+      <version>4.13</version><scope>test</scope></dependency>
+  </dependencies>
+</project>`),
+		pomArtifact("com.acme", "util", "3.0", `<project>
+  <groupId>com.acme</groupId><artifactId>util</artifactId>
+  <version>3.0</version>
+</project>`))
+
+	dir := t.TempDir()
+	f := filepath.Join(dir, "pom.xml")
+	err := os.WriteFile(f, []byte(`<project>
+  <parent><groupId>com.acme</groupId><artifactId>parent</artifactId>
+    <version>1</version></parent>
+  <artifactId>app</artifactId>
+  <dependencies>
+    <dependency><groupId>${project.groupId}</groupId>
+      <artifactId>lib</artifactId><version>${lib.version}</version>
+      <exclusions><exclusion><groupId>com.acme</groupId>
+        <artifactId>excluded</artifactId></exclusion></exclusions>
+    </dependency>
+    <dependency><groupId>com.acme</groupId><artifactId>util</artifactId>
+      <version>3.0</version><type>test-jar</type><scope>test</scope>
+    </dependency>
+  </dependencies>
+</project>`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pl := newPomLoader(context.Background(),
+		[]NexusRepository{{NexusInstance: inst, RepositoryID: "releases"}})
+	p, err := pl.open(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.GroupID != "com.acme" || p.Version != "1" {
+		t.Fatalf("Expected inherited coordinates but got %s:%s\n",
+			p.GroupID, p.Version)
+	}
+
+	direct, err := pl.dependencies(p, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Gav{
+		{Group: "com.acme", Artifact: "lib", Version: "2.0", Classifier: "",
+			Packaging: "jar"},
+		{Group: "com.acme", Artifact: "util", Version: "3.0",
+			Classifier: "tests", Packaging: "jar"},
+	}
+	if !reflect.DeepEqual(want, direct) {
+		t.Fatalf("Expected %+v but got %+v\n", want, direct)
+	}
+
+	all, err := pl.dependencies(p, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// util is managed to 3.0 by the parent, excluded and junit are dropped
+	want = append(want, Gav{Group: "com.acme", Artifact: "util", Version: "3.0",
+		Packaging: "jar"})
+	if !reflect.DeepEqual(want, all) {
+		t.Fatalf("Expected %+v but got %+v\n", want, all)
+	}
+}
+
+func TestPomRelocation(t *testing.T) {
+	_, inst := newFakeNexus(t,
+		pomArtifact("old", "lib", "1.0", `<project>
+  <groupId>old</groupId><artifactId>lib</artifactId><version>1.0</version>
+  <distributionManagement><relocation><groupId>new</groupId>
+    <message>moved to new</message></relocation></distributionManagement>
+</project>`),
+		pomArtifact("new", "lib", "1.0", `<project>
+  <groupId>new</groupId><artifactId>lib</artifactId><version>1.0</version>
+</project>`))
+	p := &pom{Dependencies: []pomDependency{
+		{GroupID: "old", ArtifactID: "lib", Version: "1.0"},
+		{GroupID: "new", ArtifactID: "lib", Version: "1.0"},
+	}}
+	pl := newPomLoader(context.Background(),
+		[]NexusRepository{{NexusInstance: inst, RepositoryID: "releases"}})
+	gavs, err := pl.dependencies(p, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Gav{{Group: "new", Artifact: "lib", Version: "1.0",
+		Packaging: "jar"}}
+	if !reflect.DeepEqual(want, gavs) {
+		t.Fatalf("Expected %+v but got %+v\n", want, gavs)
+	}
+
+	pl.noRelocation = true
+	if _, err := pl.dependencies(p, false); err == nil {
+		t.Fatalf("Expected relocation error but got nil\n")
+	}
+}
+
+func TestRelocateGav(t *testing.T) {
+	_, inst := newFakeNexus(t,
+		pomArtifact("old", "lib", "1.0", `<project>
+  <groupId>old</groupId><artifactId>lib</artifactId><version>1.0</version>
+  <distributionManagement><relocation><groupId>new</groupId>
+    <version>2.0</version></relocation></distributionManagement>
+</project>`),
+		pomArtifact("new", "lib", "2.0", `<project>
+  <groupId>new</groupId><artifactId>lib</artifactId><version>2.0</version>
+</project>`))
+	pl := newPomLoader(context.Background(),
+		[]NexusRepository{{NexusInstance: inst, RepositoryID: "releases"}})
+	got, err := pl.relocateGav(Gav{Group: "old", Artifact: "lib",
+		Version: "1.0", Classifier: "sources", Packaging: "jar"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Gav{Group: "new", Artifact: "lib", Version: "2.0",
+		Classifier: "sources", Packaging: "jar"}
+	if want != got {
+		t.Fatalf("Expected %+v but got %+v\n", want, got)
+	}
+
+	// coordinates without POM are fetched as they are
+	want = Gav{Group: "other", Artifact: "lib", Version: "1.0"}
+	if got, err = pl.relocateGav(want); err != nil || want != got {
+		t.Fatalf("Expected %+v but got %+v, %v\n", want, got, err)
+	}
+
+	pl.noRelocation = true
+	if _, err := pl.relocateGav(Gav{Group: "old", Artifact: "lib",
+		Version: "1.0"}); err == nil {
+		t.Fatalf("Expected relocation error but got nil\n")
+	}
+}
+
+func TestRenderDeps(t *testing.T) {
+	_, inst := newFakeNexus(t,
+		pomArtifact("com.acme", "app", "1.0", `<project>
+  <groupId>com.acme</groupId><artifactId>app</artifactId>
+  <version>1.0</version>
+  <dependencies>
+    <dependency><groupId>com.acme</groupId><artifactId>lib</artifactId>
+      <version>2.0</version></dependency>
+    <dependency><groupId>com.acme</groupId><artifactId>util</artifactId>
+      <version>3.0</version><scope>runtime</scope></dependency>
+  </dependencies>
+</project>`),
+		pomArtifact("com.acme", "lib", "2.0", `<project>
+  <groupId>com.acme</groupId><artifactId>lib</artifactId>
+  <version>2.0</version>
+  <dependencies>
+    <dependency><groupId>com.acme</groupId><artifactId>util</artifactId>
+      <version>1.0</version></dependency>
+  </dependencies>
+</project>`),
+		pomArtifact("com.acme", "util", "3.0", `<project>
+  <groupId>com.acme</groupId><artifactId>util</artifactId>
+  <version>3.0</version>
+</project>`))
+	pl := newPomLoader(context.Background(),
+		[]NexusRepository{{NexusInstance: inst, RepositoryID: "releases"}})
+	p, err := pl.fetch(Gav{Group: "com.acme", Artifact: "app",
+		Version: "1.0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree, _, err := pl.resolveTree(p, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	renderDeps(&buf, tree)
+	want := `com.acme:app:1.0
+├── com.acme:lib:2.0@jar (compile)
+│   └── (com.acme:util:1.0@jar - omitted for conflict with 3.0)
+└── com.acme:util:3.0@jar (runtime)
+`
+	if buf.String() != want {
+		t.Fatalf("Expected\n%s but got\n%s\n", want, buf.String())
+	}
+}
+
+func TestForceVersion(t *testing.T) {
+	_, inst := newFakeNexus(t,
+		pomArtifact("com.acme", "app", "1.0", `<project>
+  <groupId>com.acme</groupId><artifactId>app</artifactId>
+  <version>1.0</version>
+  <dependencies>
+    <dependency><groupId>com.acme</groupId><artifactId>lib</artifactId>
+      <version>2.0</version></dependency>
+    <dependency><groupId>com.acme</groupId><artifactId>util</artifactId>
+      <version>3.0</version></dependency>
+  </dependencies>
+</project>`),
+		pomArtifact("com.acme", "lib", "2.0", `<project>
+  <groupId>com.acme</groupId><artifactId>lib</artifactId>
+  <version>2.0</version>
+  <dependencies>
+    <dependency><groupId>com.acme</groupId><artifactId>util</artifactId>
+      <version>1.0</version></dependency>
+  </dependencies>
+</project>`),
+		pomArtifact("com.acme", "util", "1.0", `<project>
+  <groupId>com.acme</groupId><artifactId>util</artifactId>
+  <version>1.0</version>
+</project>`),
+		pomArtifact("com.acme", "util", "3.0", `<project>
+  <groupId>com.acme</groupId><artifactId>util</artifactId>
+  <version>3.0</version>
+</project>`))
+	pl := newPomLoader(context.Background(),
+		[]NexusRepository{{NexusInstance: inst, RepositoryID: "releases"}})
+	if err := pl.forced.Set("com.acme:util:1.0"); err != nil {
+		t.Fatal(err)
+	}
+	p, err := pl.fetch(Gav{Group: "com.acme", Artifact: "app",
+		Version: "1.0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, gavs, err := pl.resolveTree(p, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, gav := range gavs {
+		got = append(got, gav.ConciseNotation())
+	}
+	want := "com.acme:lib:2.0@jar,com.acme:util:1.0@jar"
+	if strings.Join(got, ",") != want {
+		t.Fatalf("Expected %s but got %s\n", want, strings.Join(got, ","))
+	}
+	if len(pl.conflicts) != 0 {
+		t.Fatalf("Expected no conflicts but got %v\n", pl.conflicts)
+	}
+
+	pl.forced = make(forcedVersions)
+	pl.cache = make(map[Gav]*pom)
+	p, _ = pl.fetch(Gav{Group: "com.acme", Artifact: "app", Version: "1.0"})
+	if _, _, err = pl.resolveTree(p, true); err != nil {
+		t.Fatal(err)
+	}
+	if len(pl.conflicts) != 1 {
+		t.Fatalf("Expected 1 conflict but got %v\n", pl.conflicts)
+	}
+	want = "com.acme:util 3.0 wins over 1.0 required by com.acme:lib:2.0@jar " +
+		"(nearest)"
+	if pl.conflicts[0].String() != want {
+		t.Fatalf("Expected %s but got %s\n", want, pl.conflicts[0])
+	}
+}
+
+func TestFollows(t *testing.T) {
+	var pl pomLoader
+	tests := []struct {
+		scopes   string
+		optional bool
+		scope    string
+		opt      bool
+		depth    int
+		want     bool
+	}{
+		{"", false, "test", false, 0, true},
+		{"", false, "test", false, 1, false},
+		{"", false, "", false, 1, true},
+		{"", false, "runtime", true, 1, false},
+		{"", true, "runtime", true, 1, true},
+		{"", false, "system", false, 0, false},
+		{"compile,runtime", false, "test", false, 0, false},
+		{"compile,runtime", false, "", false, 2, true},
+		{"compile,test", false, "test", false, 0, true},
+		{"compile,test", false, "test", false, 2, false},
+	}
+	for _, tt := range tests {
+		pl.scopes = nil
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		rf := newResolveFlags(fs)
+		fs.Parse([]string{"-scopes", tt.scopes,
+			"-include-optional=" + strconv.FormatBool(tt.optional)})
+		rf.apply(&pl)
+		got := pl.follows(tt.scope, tt.opt, tt.depth)
+		if got != tt.want {
+			t.Fatalf("%+v: Expected %t but got %t\n", tt, tt.want, got)
+		}
+	}
+}
+
+func TestPomImports(t *testing.T) {
+	_, inst := newFakeNexus(t,
+		pomArtifact("com.acme", "bom", "1", `<project>
+  <groupId>com.acme</groupId><artifactId>bom</artifactId>
+  <version>1</version>
+  <properties><util.version>3.0</util.version></properties>
+  <dependencyManagement><dependencies>
+    <dependency><groupId>com.acme</groupId><artifactId>util</artifactId>
+      <version>${util.version}</version></dependency>
+    <dependency><groupId>com.acme</groupId><artifactId>lib</artifactId>
+      <version>1.0</version></dependency>
+  </dependencies></dependencyManagement>
+</project>`),
+		pomArtifact("com.acme", "loop", "1", `<project>
+  <parent><groupId>com.acme</groupId><artifactId>loop</artifactId>
+    <version>1</version></parent>
+  <artifactId>loop</artifactId>
+</project>`))
+
+	dir := t.TempDir()
+	f := filepath.Join(dir, "pom.xml")
+	err := os.WriteFile(f, []byte(`<project>
+  <groupId>com.acme</groupId><artifactId>app</artifactId>
+  <version>1</version>
+  <dependencyManagement><dependencies>
+    <dependency><groupId>com.acme</groupId><artifactId>lib</artifactId>
+      <version>2.0</version></dependency>
+    <dependency><groupId>com.acme</groupId><artifactId>bom</artifactId>
+      <version>1</version><type>pom</type><scope>import</scope>
+    </dependency>
+  </dependencies></dependencyManagement>
+  <dependencies>
+    <dependency><groupId>com.acme</groupId><artifactId>lib</artifactId>
+    </dependency>
+    <dependency><groupId>com.acme</groupId><artifactId>util</artifactId>
+    </dependency>
+  </dependencies>
+</project>`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pl := newPomLoader(context.Background(),
+		[]NexusRepository{{NexusInstance: inst, RepositoryID: "releases"}})
+	p, err := pl.open(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	direct, err := pl.dependencies(p, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the own management wins over the imported one
+	want := []Gav{
+		{Group: "com.acme", Artifact: "lib", Version: "2.0", Classifier: "",
+			Packaging: "jar"},
+		{Group: "com.acme", Artifact: "util", Version: "3.0", Classifier: "",
+			Packaging: "jar"},
+	}
+	if !reflect.DeepEqual(want, direct) {
+		t.Fatalf("Expected %+v but got %+v\n", want, direct)
+	}
+
+	_, err = pl.fetch(Gav{Group: "com.acme", Artifact: "loop",
+		Version: "1"})
+	if err == nil {
+		t.Fatalf("Expected cycle error but got none\n")
+	}
+}
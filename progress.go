@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// progressInterval limits bytes events per download.
+const progressInterval = 500 * time.Millisecond
+
+// ProgressEvent is one line of the -progress json stream.
+type ProgressEvent struct {
+	Time time.Time `json:"time"`
+	// Event is queued, started, bytes, finished or failed
+	Event      string `json:"event"`
+	Gav        string `json:"gav"`
+	Repository string `json:"repository,omitempty"`
+	URL        string `json:"url,omitempty"`
+	File       string `json:"file,omitempty"`
+	// Bytes counts what arrived so far, Total is the expected size if
+	// known
+	Bytes int64  `json:"bytes,omitempty"`
+	Total int64  `json:"total,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// progress writes events about downloads for GUIs and CI plugins.
+type progress struct {
+	format *string
+	to     *string
+	mu     sync.Mutex
+	enc    *json.Encoder
+}
+
+func newProgress(fs *flag.FlagSet) *progress {
+	return &progress{
+		format: fs.String("progress", "", "Emit progress events, json "+
+			"for newline-delimited JSON"),
+		to: fs.String("progress-to", "", "Write progress events to "+
+			"this file or named pipe instead of stderr"),
+	}
+}
+
+func (a *progress) validate() error {
+	switch *a.format {
+	case "", "json":
+		return nil
+	}
+	return fmt.Errorf("unknown progress format %q", *a.format)
+}
+
+// open starts the event stream. A named pipe blocks until it has a reader.
+func (a *progress) open() error {
+	if *a.format == "" {
+		return nil
+	}
+	var w io.Writer = os.Stderr
+	if *a.to != "" {
+		f, err := os.OpenFile(*a.to, os.O_WRONLY|os.O_APPEND|os.O_CREATE,
+			0644)
+		if err != nil {
+			return err
+		}
+		w = f
+	}
+	a.enc = json.NewEncoder(w)
+	return nil
+}
+
+func (a *progress) emit(e ProgressEvent) {
+	if a.enc == nil {
+		return
+	}
+	e.Time = time.Now()
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	// progress is informational, a vanished reader must not fail the run
+	a.enc.Encode(e)
+}
+
+func (a *progress) queued(fqa Fqa) {
+	a.emit(ProgressEvent{Event: "queued", Gav: fqa.Gav.ConciseNotation(),
+		Repository: fqa.RepositoryID})
+}
+
+// started reports a download and has its body emit bytes events.
+func (a *progress) started(gav Gav, url string, res *http.Response) {
+	e := ProgressEvent{Gav: gav.ConciseNotation(), URL: url,
+		Total: res.ContentLength}
+	if e.Total < 0 {
+		e.Total = 0
+	}
+	e.Event = "started"
+	a.emit(e)
+	if a.enc == nil {
+		return
+	}
+	res.Body = &progressReader{ReadCloser: res.Body, p: a, e: e,
+		last: time.Now()}
+}
+
+// finished reports the outcome of a download.
+func (a *progress) finished(fqa Fqa, url, file string, err error) {
+	e := ProgressEvent{Event: "finished", Gav: fqa.Gav.ConciseNotation(),
+		Repository: fqa.RepositoryID, URL: url, File: file}
+	if fi, serr := os.Stat(file); serr == nil && file != "" {
+		e.Bytes = fi.Size()
+	}
+	if err != nil {
+		e.Event, e.Error = "failed", err.Error()
+	}
+	a.emit(e)
+}
+
+// progressReader emits bytes events while a body is read.
+type progressReader struct {
+	io.ReadCloser
+	p    *progress
+	e    ProgressEvent
+	last time.Time
+}
+
+func (a *progressReader) Read(p []byte) (int, error) {
+	n, err := a.ReadCloser.Read(p)
+	a.e.Bytes += int64(n)
+	if now := time.Now(); now.Sub(a.last) >= progressInterval {
+		a.last = now
+		a.e.Event = "bytes"
+		a.p.emit(a.e)
+	}
+	return n, err
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProgress(t *testing.T) {
+	format := "json"
+	var buf bytes.Buffer
+	p := &progress{format: &format, enc: json.NewEncoder(&buf)}
+	a := Fqa{NexusRepository: NexusRepository{RepositoryID: "releases"},
+		Gav: Gav{Group: "g", Artifact: "a", Version: "1.0"}}
+	p.queued(a)
+	res := &http.Response{ContentLength: 5,
+		Body: io.NopCloser(strings.NewReader("12345"))}
+	p.started(a.Gav, "http://nexus/a-1.0.jar", res)
+	res.Body.(*progressReader).last = time.Time{}
+	if _, err := ioutil.ReadAll(res.Body); err != nil {
+		t.Fatal(err)
+	}
+	f := filepath.Join(t.TempDir(), "a-1.0.jar")
+	if err := os.WriteFile(f, []byte("12345"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	p.finished(a, "http://nexus/a-1.0.jar", f, nil)
+	p.finished(a, "http://nexus/a-1.0.jar", "", errors.New("boom"))
+
+	var events []string
+	dec := json.NewDecoder(&buf)
+	for {
+		var e ProgressEvent
+		if err := dec.Decode(&e); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		if e.Gav != "g:a:1.0" {
+			t.Fatalf("Expected g:a:1.0 but got %s\n", e.Gav)
+		}
+		if e.Event == "bytes" && (e.Bytes != 5 || e.Total != 5) {
+			t.Fatalf("Expected 5 of 5 bytes but got %d of %d\n", e.Bytes,
+				e.Total)
+		}
+		if e.Event == "failed" && e.Error != "boom" {
+			t.Fatalf("Expected boom but got %s\n", e.Error)
+		}
+		events = append(events, e.Event)
+	}
+	want := "queued started bytes finished failed"
+	if got := strings.Join(events, " "); want != got {
+		t.Fatalf("Expected %s but got %s\n", want, got)
+	}
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"strings"
+)
+
+// gavPattern matches coordinates field by field with shell patterns, e.g.
+// com.acme:app-*:1.0.*. Omitted trailing fields match anything.
+type gavPattern []string
+
+func parseGavPattern(s string) (gavPattern, error) {
+	p := gavPattern(strings.Split(s, ":"))
+	if len(p) > 4 {
+		return nil, fmt.Errorf("illegal pattern %q, expected "+
+			"group[:artifact[:version[:classifier]]]", s)
+	}
+	for _, f := range p {
+		if _, err := path.Match(f, ""); err != nil {
+			return nil, fmt.Errorf("illegal pattern %q: %v", s, err)
+		}
+	}
+	return p, nil
+}
+
+func (a gavPattern) match(gav Gav) bool {
+	fields := []string{gav.Group, gav.Artifact, gav.Version, gav.Classifier}
+	for i, f := range a {
+		if ok, _ := path.Match(f, fields[i]); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// matchVersion is match ignoring the classifier, a version matches if any
+// of its files does.
+func (a gavPattern) matchVersion(gav Gav) bool {
+	if len(a) > 3 {
+		a = a[:3]
+	}
+	return a.match(gav)
+}
+
+func (a gavPattern) String() string {
+	return strings.Join(a, ":")
+}
+
+// defaultProtectedFile is read if present, other files must exist.
+const defaultProtectedFile = "protected.txt"
+
+// protection holds coordinates that housekeeping must never delete.
+type protection struct {
+	file     *string
+	patterns []gavPattern
+	loaded   bool
+}
+
+func newProtection(fs *flag.FlagSet) *protection {
+	var a protection
+	a.file = fs.String("protected", defaultProtectedFile, "File of "+
+		"coordinate patterns such as com.acme:app-*:1.0.* that are never "+
+		"deleted, one per line")
+	fs.Func("protect", "Never delete coordinates matching this "+
+		"pattern, repeatable", func(s string) error {
+		p, err := parseGavPattern(s)
+		if err == nil {
+			a.patterns = append(a.patterns, p)
+		}
+		return err
+	})
+	return &a
+}
+
+// load reads the protected file once, blank lines and lines starting with
+// # are ignored.
+func (a *protection) load() error {
+	if a.loaded {
+		return nil
+	}
+	a.loaded = true
+	f, err := os.Open(*a.file)
+	if os.IsNotExist(err) && *a.file == defaultProtectedFile {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		p, err := parseGavPattern(line)
+		if err != nil {
+			return fmt.Errorf("%s:%d: %v", *a.file, n, err)
+		}
+		a.patterns = append(a.patterns, p)
+	}
+	return sc.Err()
+}
+
+// protects returns the first pattern matching gav, or nil.
+func (a *protection) protects(gav Gav) gavPattern {
+	for _, p := range a.patterns {
+		if p.match(gav) {
+			return p
+		}
+	}
+	return nil
+}
+
+// protectsVersion returns the first pattern matching a file of the version
+// gav, or nil.
+func (a *protection) protectsVersion(gav Gav) gavPattern {
+	for _, p := range a.patterns {
+		if p.matchVersion(gav) {
+			return p
+		}
+	}
+	return nil
+}
+
+// filter drops protected coordinates before anything is deleted.
+func (a *protection) filter(gavs []Gav) ([]Gav, error) {
+	return a.filterBy(gavs, a.protects)
+}
+
+// filterVersions drops versions holding any protected file, for deletions
+// of whole version directories.
+func (a *protection) filterVersions(gavs []Gav) ([]Gav, error) {
+	return a.filterBy(gavs, a.protectsVersion)
+}
+
+func (a *protection) filterBy(gavs []Gav,
+	protects func(Gav) gavPattern) ([]Gav, error) {
+	if err := a.load(); err != nil {
+		return nil, err
+	}
+	var ok []Gav
+	for _, gav := range gavs {
+		if p := protects(gav); p != nil {
+			log.Printf("%s is protected by %s, skipping\n",
+				gav.ConciseNotation(), p)
+			continue
+		}
+		ok = append(ok, gav)
+	}
+	return ok, nil
+}
+
+// layoutGavs returns the coordinates a file of the Maven layout may belong
+// to. Metadata lies in artifact or version directories and yields both
+// readings.
+func layoutGavs(p string) []Gav {
+	ss := splitPath(p)
+	if len(ss) < 2 {
+		return nil
+	}
+	name, dir := ss[len(ss)-1], ss[:len(ss)-1]
+	// at is the reading with the artifact at dir[i]
+	at := func(i int) Gav {
+		gav := Gav{Group: strings.Join(dir[:i], "."), Artifact: dir[i]}
+		if i+1 < len(dir) {
+			gav.Version = dir[i+1]
+		}
+		return gav
+	}
+	if strings.HasPrefix(name, "maven-metadata") {
+		gavs := []Gav{at(len(dir) - 1)}
+		if len(dir) > 1 {
+			gavs = append(gavs, at(len(dir)-2))
+		}
+		return gavs
+	}
+	if len(dir) < 2 {
+		return []Gav{at(len(dir) - 1)}
+	}
+	gav := at(len(dir) - 2)
+	prefix := gav.Artifact + "-" + gav.Version + "-"
+	if strings.HasPrefix(name, prefix) {
+		gav.Classifier = strings.SplitN(name[len(prefix):], ".", 2)[0]
+	}
+	return []Gav{gav}
+}
+
+// filterPaths drops repository paths holding protected coordinates before
+// anything is deleted. Directories are listed and dropped if any file
+// below is protected.
+func (a *protection) filterPaths(repo NexusRepository, ps []string) (
+	[]string, error) {
+	if err := a.load(); err != nil {
+		return nil, err
+	}
+	if len(a.patterns) == 0 {
+		return ps, nil
+	}
+	// protectedBelow returns the first protecting pattern of p or below
+	var protectedBelow func(p string, leaf bool) (gavPattern, error)
+	protectedBelow = func(p string, leaf bool) (gavPattern, error) {
+		if leaf {
+			for _, gav := range layoutGavs(p) {
+				if pat := a.protects(gav); pat != nil {
+					return pat, nil
+				}
+			}
+			return nil, nil
+		}
+		items, err := listContent(repo, p)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			pat, err := protectedBelow(item.RelativePath, item.Leaf)
+			if pat != nil || err != nil {
+				return pat, err
+			}
+		}
+		return nil, nil
+	}
+	var ok []string
+	for _, p := range ps {
+		pat, err := protectedBelow(p, !strings.HasSuffix(p, "/"))
+		if err != nil {
+			return nil, err
+		}
+		if pat != nil {
+			log.Printf("%s holds coordinates protected by %s, skipping\n",
+				p, pat)
+			continue
+		}
+		ok = append(ok, p)
+	}
+	return ok, nil
+}
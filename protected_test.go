@@ -0,0 +1,71 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestProtection(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "protected.txt")
+	err := os.WriteFile(file, []byte("# releases of the app\n"+
+		"com.acme:app-*:1.0.*\n\norg.legacy\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	p := newProtection(fs)
+	if err := fs.Parse([]string{"-protected", file,
+		"-protect", "*:*:*:sources"}); err != nil {
+		t.Fatal(err)
+	}
+	gavs := []Gav{
+		{Group: "com.acme", Artifact: "app-core", Version: "1.0.3"},
+		{Group: "com.acme", Artifact: "app-core", Version: "1.1.0"},
+		{Group: "com.acme", Artifact: "lib", Version: "1.0.0"},
+		{Group: "org.legacy", Artifact: "x", Version: "0.1"},
+		{Group: "com.acme", Artifact: "lib", Version: "2.0",
+			Classifier: "sources"},
+	}
+	got, err := p.filter(gavs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0] != gavs[1] || got[1] != gavs[2] {
+		t.Fatalf("Expected %v but got %v\n", gavs[1:3], got)
+	}
+}
+
+func TestProtectionOfVersions(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	p := newProtection(fs)
+	if err := fs.Parse([]string{"-protect",
+		"com.acme:lib:2.0:sources"}); err != nil {
+		t.Fatal(err)
+	}
+	gavs := []Gav{
+		{Group: "com.acme", Artifact: "lib", Version: "1.0"},
+		{Group: "com.acme", Artifact: "lib", Version: "2.0"},
+	}
+	got, err := p.filterVersions(gavs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 2.0 holds the protected sources
+	if len(got) != 1 || got[0] != gavs[0] {
+		t.Fatalf("Expected %v but got %v\n", gavs[:1], got)
+	}
+}
+
+func TestProtectionDefaultFileOptional(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	p := newProtection(fs)
+	*p.file = filepath.Join(t.TempDir(), "missing.txt")
+	if _, err := p.filter(nil); err == nil {
+		t.Fatalf("Expected error for missing explicit file\n")
+	}
+	if _, err := parseGavPattern("a:b:c:d:e"); err == nil {
+		t.Fatalf("Expected error for too many fields\n")
+	}
+}
@@ -0,0 +1,444 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/jhinrichsen/nexus-fetch/nexus"
+)
+
+// pruneCandidate is a version that housekeeping selected for deletion.
+type pruneCandidate struct {
+	Repository   string    `json:"repository"`
+	Group        string    `json:"groupId"`
+	Artifact     string    `json:"artifactId"`
+	Version      string    `json:"version"`
+	Size         int64     `json:"size"`
+	LastModified time.Time `json:"lastModified"`
+	AgeDays      int       `json:"ageDays"`
+	Reason       string    `json:"reason"`
+}
+
+func (a pruneCandidate) gav() Gav {
+	return Gav{Group: a.Group, Artifact: a.Artifact, Version: a.Version}
+}
+
+// dir returns the version directory in the repository.
+func (a pruneCandidate) dir() string {
+	return path.Join(strings.Replace(a.Group, ".", "/", -1), a.Artifact,
+		a.Version)
+}
+
+// contentTime parses the lastModified format of content listings.
+func contentTime(s string) (time.Time, error) {
+	return time.Parse("2006-01-02 15:04:05.0 MST", s)
+}
+
+// pruneVersions returns all versions of artifacts matching gav in repo
+// with their size and time of last modification.
+func pruneVersions(repo NexusRepository, gav Gav) ([]pruneCandidate,
+	error) {
+	hits, err := searchAll([]NexusRepository{repo}, gav)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[Gav]bool)
+	var vs []pruneCandidate
+	for _, hit := range hits {
+		c := pruneCandidate{Repository: repo.RepositoryID,
+			Group: hit.Group, Artifact: hit.Artifact, Version: hit.Version}
+		if seen[c.gav()] {
+			continue
+		}
+		seen[c.gav()] = true
+		items, err := listContent(repo, c.dir())
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			// directories report a size of -1
+			if item.Leaf && item.Size > 0 {
+				c.Size += item.Size
+			}
+			if t, err := contentTime(item.LastModified); err == nil &&
+				t.After(c.LastModified) {
+				c.LastModified = t
+			}
+		}
+		vs = append(vs, c)
+	}
+	return vs, nil
+}
+
+// pruneRules select versions for deletion.
+type pruneRules struct {
+	keep      *int
+	olderThan *int
+}
+
+func newPruneRules(fs *flag.FlagSet) *pruneRules {
+	return &pruneRules{
+		keep: fs.Int("keep", 5,
+			"Keep this many newest versions of each artifact"),
+		olderThan: fs.Int("older-than-days", 0,
+			"Only delete versions not modified for this many days"),
+	}
+}
+
+// candidates returns the versions to delete, newest versions of each
+// artifact are kept.
+func (a *pruneRules) candidates(vs []pruneCandidate,
+	now time.Time) []pruneCandidate {
+	artifacts := make(map[string][]pruneCandidate)
+	var keys []string
+	for _, v := range vs {
+		k := v.Group + ":" + v.Artifact
+		if artifacts[k] == nil {
+			keys = append(keys, k)
+		}
+		artifacts[k] = append(artifacts[k], v)
+	}
+	sort.Strings(keys)
+	var cs []pruneCandidate
+	for _, k := range keys {
+		versions := artifacts[k]
+		sort.Slice(versions, func(i, j int) bool {
+			return compareVersions(versions[i].Version,
+				versions[j].Version) > 0
+		})
+		for i, v := range versions {
+			if i < *a.keep {
+				continue
+			}
+			if v.LastModified.IsZero() {
+				log.Printf("%s: unknown age, keeping it\n",
+					v.gav().ConciseNotation())
+				continue
+			}
+			v.AgeDays = int(now.Sub(v.LastModified).Hours() / 24)
+			if *a.olderThan > 0 && v.AgeDays < *a.olderThan {
+				continue
+			}
+			v.Reason = fmt.Sprintf("%d newer versions", i)
+			if *a.olderThan > 0 {
+				v.Reason += fmt.Sprintf(", older than %d days",
+					*a.olderThan)
+			}
+			cs = append(cs, v)
+		}
+	}
+	return cs
+}
+
+func writeCandidates(w io.Writer, cs []pruneCandidate, format string) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if cs == nil {
+			cs = []pruneCandidate{}
+		}
+		return enc.Encode(cs)
+	case "":
+		var total int64
+		for _, c := range cs {
+			total += c.Size
+			fmt.Fprintf(w, "%10s %5dd  %s/%s  (%s)\n", humanSize(c.Size),
+				c.AgeDays, c.Repository, c.gav().ConciseNotation(),
+				c.Reason)
+		}
+		fmt.Fprintf(w, "%d versions, %s\n", len(cs), humanSize(total))
+		return nil
+	}
+	return fmt.Errorf("unknown output format %q", format)
+}
+
+// deletePath removes a file or directory from a repository.
+func deletePath(ctx context.Context, repo NexusRepository, p string) error {
+	return httpDelete(ctx, repo, RepositoryFileURL(repo, p))
+}
+
+// rebuildMetadata has Nexus 2 regenerate the maven-metadata.xml files
+// below dir. Nexus 3 does this on its own.
+func rebuildMetadata(ctx context.Context, repo NexusRepository,
+	dir string) error {
+	if layoutOnly() {
+		return nil
+	}
+	return httpDelete(ctx, repo, nexus.BaseURL(repo).String()+
+		"service/local/metadata/repositories/"+repo.RepositoryID+
+		"/content/"+dir)
+}
+
+func httpDelete(ctx context.Context, repo NexusRepository, u string) error {
+	log.Printf("deleting %s\n", u)
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, u, nil)
+	if err != nil {
+		return err
+	}
+	if repo.Username != "" {
+		req.SetBasicAuth(repo.Username, repo.Password)
+	}
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	if res.StatusCode/100 != 2 {
+		return &StatusError{URL: u, StatusCode: res.StatusCode}
+	}
+	return nil
+}
+
+// pruneTask is the deletion of one path of a candidate.
+type pruneTask struct {
+	candidate int
+	path      string
+}
+
+// forEach runs f for all tasks, at most n at a time.
+func forEach(ts []pruneTask, n int, f func(pruneTask)) {
+	if n < 1 {
+		n = 1
+	}
+	sem := make(chan struct{}, n)
+	var wg sync.WaitGroup
+	for _, t := range ts {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(t pruneTask) {
+			defer wg.Done()
+			f(t)
+			<-sem
+		}(t)
+	}
+	wg.Wait()
+}
+
+// prune deletes the version directories of cs with up to n requests at a
+// time. Artifact files go first, then their checksums and metadata, then
+// the emptied directories, so that Maven clients never see checksums or
+// metadata of missing files. Deletion of a candidate stops at its first
+// failure. Metadata is rebuilt once per affected artifact at the end. The
+// result holds the error of each candidate.
+func prune(ctx context.Context, repo NexusRepository, cs []pruneCandidate,
+	n int) []error {
+	var mu sync.Mutex
+	errs := make([]error, len(cs))
+	var files, sidecars, dirs []pruneTask
+	for i, c := range cs {
+		items, err := listContent(repo, c.dir())
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		for _, item := range items {
+			t := pruneTask{i, path.Join(c.dir(), item.Name)}
+			switch {
+			case !item.Leaf:
+				dirs = append(dirs, pruneTask{i, t.path + "/"})
+			case nexus.Ignored(item.Name):
+				sidecars = append(sidecars, t)
+			default:
+				files = append(files, t)
+			}
+		}
+		dirs = append(dirs, pruneTask{i, c.dir() + "/"})
+	}
+	for _, phase := range [][]pruneTask{files, sidecars, dirs} {
+		forEach(phase, n, func(t pruneTask) {
+			mu.Lock()
+			failed := errs[t.candidate] != nil
+			mu.Unlock()
+			if failed {
+				return
+			}
+			err := deletePath(ctx, repo, t.path)
+			// directories may vanish with their last file
+			if err == nil || strings.HasSuffix(t.path, "/") &&
+				nexus.IsNotFound(err) {
+				return
+			}
+			mu.Lock()
+			errs[t.candidate] = err
+			mu.Unlock()
+		})
+	}
+
+	var artifacts []string
+	seen := make(map[string]bool)
+	for _, c := range cs {
+		if dir := path.Dir(c.dir()); !seen[dir] {
+			seen[dir] = true
+			artifacts = append(artifacts, dir)
+		}
+	}
+	for _, dir := range artifacts {
+		if err := rebuildMetadata(ctx, repo, dir); err != nil {
+			log.Printf("cannot rebuild metadata of %s: %v\n", dir, err)
+		}
+	}
+	return errs
+}
+
+func pruneCommand(args []string) {
+	fs := newCommand("prune", "<g[:a]>")
+	nf := newNexusFlags(fs)
+	rules := newPruneRules(fs)
+	protected := newProtection(fs)
+	refs := newReferences(fs)
+	confirm := newConfirmation(fs)
+	audit := newAuditLog(fs)
+	var (
+		reportOnly = fs.Bool("report-only", false,
+			"List candidates with size, age and reason, delete nothing")
+		output = fs.String("output", "", "Print candidates as json, "+
+			"default is a table")
+		serverSide = fs.Bool("server-side", false, "Nexus 3: assign "+
+			"-cleanup-policy to -repository and run the cleanup task and "+
+			"the compact task of its blob store instead of deleting "+
+			"client-side")
+		policy = fs.String("cleanup-policy", "", "Nexus 3: create or "+
+			"update this cleanup policy from -older-than-days first")
+		format = fs.String("cleanup-format", "maven2",
+			"Nexus 3: repository format of -cleanup-policy")
+		poll = fs.Duration("poll", 5*time.Second,
+			"Nexus 3: interval to check for finished tasks")
+		parallel = fs.Int("parallel", 4,
+			"Delete up to this many items at a time")
+		dryRun = fs.Bool("dry-run", false,
+			"Print what would be deleted without deleting it")
+	)
+	fs.Parse(args)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt,
+		syscall.SIGTERM)
+	defer stop()
+	if *serverSide {
+		if fs.NArg() != 0 || nf.repo().RepositoryID == "" {
+			fs.Usage()
+		}
+		var p *nexus3CleanupPolicy
+		if *policy != "" {
+			p = &nexus3CleanupPolicy{Name: *policy, Format: *format,
+				Notes:                   "maintained by nexus-fetch prune",
+				CriteriaLastBlobUpdated: *rules.olderThan}
+		}
+		if *dryRun {
+			fmt.Printf("would run the cleanup and compact tasks of %s\n",
+				nf.repo().RepositoryID)
+			return
+		}
+		if err := confirm.confirm("run server side cleanup of",
+			[]string{nf.repo().RepositoryID}); err != nil {
+			fail(err)
+		}
+		n, err := serverSideCleanup(ctx, nf.repo(), p, *poll)
+		if aerr := audit.record(newAuditRecord("cleanup", nf.repo(), Gav{},
+			"", err)); aerr != nil {
+			fail(aerr)
+		}
+		if err != nil {
+			fail(err)
+		}
+		log.Printf("reclaimed %s\n", humanSize(n))
+		return
+	}
+	if fs.NArg() != 1 || nf.repo().RepositoryID == "" {
+		fs.Usage()
+	}
+	gav, err := parseConcise(fs.Arg(0))
+	if err != nil || gav.Version != "" {
+		log.Printf("expected group[:artifact]: %q\n", fs.Arg(0))
+		exit(exitUsage)
+	}
+	repo := nf.repo()
+	vs, err := pruneVersions(repo, gav)
+	if err != nil {
+		fail(err)
+	}
+	cs := rules.candidates(vs, time.Now())
+	cs, err = pruneFilter(ctx, cs, protected, refs, repo, nf.repos())
+	if err != nil {
+		fail(err)
+	}
+	if *reportOnly {
+		if err := writeCandidates(os.Stdout, cs, *output); err != nil {
+			log.Println(err)
+			exit(exitUsage)
+		}
+		return
+	}
+	if *dryRun {
+		for _, c := range cs {
+			fmt.Printf("would delete %s (%s)\n",
+				RepositoryFileURL(repo, c.dir()+"/"), humanSize(c.Size))
+		}
+		return
+	}
+
+	var names []string
+	for _, c := range cs {
+		names = append(names, c.gav().ConciseNotation())
+	}
+	if err := confirm.confirm("delete", names); err != nil {
+		fail(err)
+	}
+	var failures []error
+	for i, err := range prune(ctx, repo, cs, *parallel) {
+		c := cs[i]
+		if aerr := audit.record(newAuditRecord("delete", repo, c.gav(),
+			c.dir(), err)); aerr != nil {
+			fail(aerr)
+		}
+		if err != nil {
+			log.Printf("%s: %v\n", c.gav().ConciseNotation(), err)
+			failures = append(failures, err)
+		}
+	}
+	exit(summaryExitCode(len(cs), failures))
+}
+
+// pruneFilter drops protected and still referenced candidates.
+func pruneFilter(ctx context.Context, cs []pruneCandidate,
+	protected *protection, refs *references, repo NexusRepository,
+	repos []NexusRepository) ([]pruneCandidate, error) {
+	var gavs []Gav
+	for _, c := range cs {
+		gavs = append(gavs, c.gav())
+	}
+	gavs, err := protected.filterVersions(gavs)
+	if err != nil {
+		return nil, err
+	}
+	if refs.active() {
+		if err := refs.load(ctx, repo, repos); err != nil {
+			return nil, err
+		}
+		gavs = refs.filter(gavs)
+	}
+	keep := make(map[Gav]bool)
+	for _, gav := range gavs {
+		keep[gav] = true
+	}
+	var ok []pruneCandidate
+	for _, c := range cs {
+		if keep[c.gav()] {
+			ok = append(ok, c)
+		}
+	}
+	return ok, nil
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jhinrichsen/nexus-fetch/nexus"
+	"github.com/jhinrichsen/nexus-fetch/nexusfetchtest"
+)
+
+func TestPruneCandidates(t *testing.T) {
+	now := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+	days := func(n int) time.Time { return now.AddDate(0, 0, -n) }
+	vs := []pruneCandidate{
+		{Group: "g", Artifact: "a", Version: "1.10", LastModified: days(10)},
+		{Group: "g", Artifact: "a", Version: "1.9", LastModified: days(100)},
+		{Group: "g", Artifact: "a", Version: "1.2", LastModified: days(20)},
+		// unknown age
+		{Group: "g", Artifact: "a", Version: "1.1"},
+		{Group: "g", Artifact: "b", Version: "1.0", LastModified: days(300)},
+	}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	rules := newPruneRules(fs)
+	if err := fs.Parse([]string{"-keep", "1",
+		"-older-than-days", "30"}); err != nil {
+		t.Fatal(err)
+	}
+	cs := rules.candidates(vs, now)
+	if len(cs) != 1 || cs[0].Version != "1.9" || cs[0].AgeDays != 100 ||
+		cs[0].Reason != "1 newer versions, older than 30 days" {
+		t.Fatalf("Expected only a:1.9 but got %+v\n", cs)
+	}
+}
+
+func TestPruneReport(t *testing.T) {
+	old := time.Now().AddDate(-1, 0, 0)
+	_, inst := newFakeNexus(t,
+		nexusfetchtest.Artifact{Repository: "releases", Group: "com.acme",
+			Artifact: "app", Version: "1.0", Content: []byte("one"),
+			Uploaded: old},
+		nexusfetchtest.Artifact{Repository: "releases", Group: "com.acme",
+			Artifact: "app", Version: "1.1", Content: []byte("eleven")})
+	repo := NexusRepository{NexusInstance: inst, RepositoryID: "releases"}
+	vs, err := pruneVersions(repo, Gav{Group: "com.acme"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	keep, older := 1, 0
+	cs := (&pruneRules{&keep, &older}).candidates(vs, time.Now())
+
+	var buf bytes.Buffer
+	if err := writeCandidates(&buf, cs, "json"); err != nil {
+		t.Fatal(err)
+	}
+	var got []pruneCandidate
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Version != "1.0" || got[0].Size != 3 ||
+		got[0].AgeDays < 365 {
+		t.Fatalf("Expected app:1.0 of 3 bytes, a year old, but got "+
+			"%+v\n", got)
+	}
+
+	if err := deletePath(context.Background(), repo,
+		got[0].dir()+"/"); err != nil {
+		t.Fatal(err)
+	}
+	if vs, _ := pruneVersions(repo, Gav{Group: "com.acme"}); len(vs) != 1 {
+		t.Fatalf("Expected one version left but got %+v\n", vs)
+	}
+}
+
+func TestPruneOrder(t *testing.T) {
+	var mu sync.Mutex
+	var deleted []string
+	inst := fakeNexus(t, func(w http.ResponseWriter, r *http.Request) {
+		p := strings.TrimPrefix(r.URL.Path, "/nexus/service/local/")
+		if r.Method == http.MethodGet {
+			var items string
+			for _, name := range []string{"a-1.0.jar", "a-1.0.jar.sha1",
+				"a-1.0.pom", "a-1.0.pom.md5"} {
+				items += "<content-item><text>" + name + "</text>" +
+					"<leaf>true</leaf></content-item>"
+			}
+			fmt.Fprintf(w, "<content><data>%s</data></content>", items)
+			return
+		}
+		mu.Lock()
+		deleted = append(deleted, p)
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	})
+	repo := NexusRepository{NexusInstance: inst, RepositoryID: "releases"}
+	cs := []pruneCandidate{{Group: "g", Artifact: "a", Version: "1.0"},
+		{Group: "g", Artifact: "a", Version: "1.1"}}
+	for _, err := range prune(context.Background(), repo, cs, 4) {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	// files of both versions, then checksums, directories and metadata
+	rank := func(p string) int {
+		switch {
+		case strings.HasPrefix(p, "metadata/"):
+			return 3
+		case strings.HasSuffix(p, "/"):
+			return 2
+		case nexus.Ignored(path.Base(p)):
+			return 1
+		}
+		return 0
+	}
+	if len(deleted) != 11 {
+		t.Fatalf("Expected 11 deletions but got %v\n", deleted)
+	}
+	for i := 1; i < len(deleted); i++ {
+		if rank(deleted[i-1]) > rank(deleted[i]) {
+			t.Fatalf("Expected %s before %s\n", deleted[i], deleted[i-1])
+		}
+	}
+	want := "metadata/repositories/releases/content/g/a"
+	if got := deleted[len(deleted)-1]; want != got {
+		t.Fatalf("Expected %s but got %s\n", want, got)
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// quarantineError reports a component blocked by Nexus Firewall.
+type quarantineError struct {
+	URL string
+	// Reason is the explanation of the server, usually naming the policy
+	// and a link to the IQ report
+	Reason string
+}
+
+func (a *quarantineError) Error() string {
+	return a.URL + " is quarantined by repository firewall policy: " +
+		a.Reason
+}
+
+var (
+	htmlTag    = regexp.MustCompile(`<[^>]*>`)
+	whitespace = regexp.MustCompile(`\s+`)
+	iqLink     = regexp.MustCompile(`https?://[^\s"'<>]*quarantine[^\s"'<>]*`)
+)
+
+// quarantined returns a quarantineError if a failed response is the
+// firewall blocking the download. Nexus answers 403, some proxies in front
+// of it 404, with a page explaining the policy.
+func quarantined(u string, res *http.Response) error {
+	if res.StatusCode != http.StatusForbidden &&
+		res.StatusCode != http.StatusNotFound {
+		return nil
+	}
+	buf, err := io.ReadAll(io.LimitReader(res.Body, 64*1024))
+	if err != nil {
+		return nil
+	}
+	return quarantineReason(u, string(buf))
+}
+
+// quarantineReason extracts the sentences mentioning the quarantine from
+// an HTML or text body, and the link to the policy report if any.
+func quarantineReason(u, body string) error {
+	if !strings.Contains(strings.ToLower(body), "quarantine") {
+		return nil
+	}
+	link := iqLink.FindString(body)
+	text := htmlTag.ReplaceAllString(body, " ")
+	text = strings.TrimSpace(whitespace.ReplaceAllString(text, " "))
+	var reasons []string
+	for _, s := range strings.Split(text, ". ") {
+		l := strings.ToLower(s)
+		if strings.Contains(l, "quarantine") ||
+			strings.Contains(l, "policy") {
+			reasons = append(reasons, strings.TrimSuffix(s, "."))
+		}
+	}
+	reason := strings.Join(reasons, ". ")
+	if link != "" && !strings.Contains(reason, link) {
+		reason += " (" + link + ")"
+	}
+	return &quarantineError{u, reason}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/jhinrichsen/nexus-fetch/nexus"
+)
+
+func TestQuarantined(t *testing.T) {
+	inst := fakeNexus(t, func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "missing-1.0.jar") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`<html><head><title>403 - Nexus Repository Manager
+</title></head><body><h1>Error 403</h1>
+<p>Requested item is quarantined. Component violates policy
+Security-Critical. For more information see
+<a href="http://iq:8070/ui/links/repositories/quarantinedComponent/42">
+the report</a>.</p></body></html>`))
+	})
+	base := "http://" + inst.Server + ":" + inst.Port + "/nexus/"
+	_, err := get(context.Background(), base+"app-1.0.jar")
+	if exitCode(err) != exitPolicy {
+		t.Fatalf("Expected quarantine but got %v\n", err)
+	}
+	for _, want := range []string{"Security-Critical",
+		"http://iq:8070/ui/links/repositories/quarantinedComponent/42"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("Expected %s in %v\n", want, err)
+		}
+	}
+
+	_, err = get(context.Background(), base+"missing-1.0.jar")
+	if !nexus.IsNotFound(err) {
+		t.Fatalf("Expected not found but got %v\n", err)
+	}
+}
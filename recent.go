@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxRecent limits the number of remembered coordinates.
+const maxRecent = 100
+
+// recentFile returns the location of recently used coordinates, or an empty
+// string if there is no user cache directory.
+func recentFile() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "nexus-fetch", "recent")
+}
+
+// recent returns recently used coordinates in concise notation, most
+// recent first.
+func recent() []string {
+	f, err := os.Open(recentFile())
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	var cs []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		if c := strings.TrimSpace(sc.Text()); c != "" {
+			cs = append(cs, c)
+		}
+	}
+	return cs
+}
+
+// remember moves coordinates to the top of the recently used list. This is
+// a convenience only, so errors are ignored.
+func remember(gav Gav) {
+	c := gav.ConciseNotation()
+	fn := recentFile()
+	if c == "" || fn == "" {
+		return
+	}
+	cs := []string{c}
+	for _, r := range recent() {
+		if r != c && len(cs) < maxRecent {
+			cs = append(cs, r)
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(fn), 0755); err != nil {
+		return
+	}
+	os.WriteFile(fn, []byte(strings.Join(cs, "\n")+"\n"), 0644)
+}
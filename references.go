@@ -0,0 +1,236 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path"
+	"sort"
+	"strings"
+	"syscall"
+)
+
+// references answers which artifacts still depend on a version, from the
+// POMs of selected repositories and/or an exported dependency graph.
+type references struct {
+	graph *string
+	scan  []string
+	warn  *bool
+	// index maps group:artifact:version to its dependants
+	index map[string][]string
+	// ranges maps group:artifact to dependants on a version range or an
+	// unresolved version
+	ranges map[string][]rangeReference
+}
+
+// rangeReference is a dependant on all versions within spec.
+type rangeReference struct {
+	spec, dependant string
+}
+
+func newReferences(fs *flag.FlagSet) *references {
+	var a references
+	a.graph = fs.String("dependency-graph", "", "File of lines "+
+		"'dependant -> dependency' in concise notation")
+	fs.Func("referenced-in", "Scan POMs below this repository/path "+
+		"for dependants, repeatable", func(s string) error {
+		a.scan = append(a.scan, strings.Trim(s, "/"))
+		return nil
+	})
+	a.warn = fs.Bool("referenced-warn", false, "Only warn about "+
+		"referenced versions instead of keeping them")
+	return &a
+}
+
+func (a *references) active() bool {
+	return *a.graph != "" || len(a.scan) > 0
+}
+
+// referenceKey identifies a version independent of classifier and
+// packaging.
+func referenceKey(gav Gav) string {
+	return gav.Group + ":" + gav.Artifact + ":" + gav.Version
+}
+
+func (a *references) add(dependency Gav, dependant string) {
+	if v := dependency.Version; strings.ContainsAny(v, "[(") ||
+		strings.Contains(v, "${") {
+		k := dependency.Group + ":" + dependency.Artifact
+		a.ranges[k] = append(a.ranges[k], rangeReference{v, dependant})
+		return
+	}
+	k := referenceKey(dependency)
+	for _, d := range a.index[k] {
+		if d == dependant {
+			return
+		}
+	}
+	a.index[k] = append(a.index[k], dependant)
+}
+
+// load builds the index once. repo supplies the instance for scanned
+// repositories, repos resolve parent POMs.
+func (a *references) load(ctx context.Context, repo NexusRepository,
+	repos []NexusRepository) error {
+	if a.index != nil {
+		return nil
+	}
+	a.index = make(map[string][]string)
+	a.ranges = make(map[string][]rangeReference)
+	if *a.graph != "" {
+		if err := a.loadGraph(*a.graph); err != nil {
+			return err
+		}
+	}
+	pl := newPomLoader(ctx, repos)
+	for _, s := range a.scan {
+		r := repo
+		r.RepositoryID = s
+		var dir string
+		if i := strings.Index(s, "/"); i >= 0 {
+			r.RepositoryID, dir = s[:i], s[i+1:]
+		}
+		pl.repos = append([]NexusRepository{r}, repos...)
+		if err := a.scanPoms(pl, r, dir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *references) loadGraph(filename string) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.Split(line, "->")
+		if len(parts) != 2 {
+			return fmt.Errorf("%s:%d: expected 'dependant -> "+
+				"dependency'", filename, n)
+		}
+		dependency, err := parseConcise(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return fmt.Errorf("%s:%d: %v", filename, n, err)
+		}
+		a.add(dependency, strings.TrimSpace(parts[0]))
+	}
+	return sc.Err()
+}
+
+// scanPoms reads all POMs below dir and records their direct
+// dependencies. A POM that cannot be resolved fails the scan, its
+// dependencies could otherwise be deleted.
+func (a *references) scanPoms(pl *pomLoader, repo NexusRepository,
+	dir string) error {
+	items, err := listContent(repo, dir)
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		p := path.Join(dir, item.Name)
+		if !item.Leaf {
+			if err := a.scanPoms(pl, repo, p); err != nil {
+				return err
+			}
+			continue
+		}
+		if !strings.HasSuffix(item.Name, ".pom") {
+			continue
+		}
+		// <group>/<artifact>/<version>/<artifact>-<version>.pom
+		parts := strings.Split(p, "/")
+		if len(parts) < 4 {
+			continue
+		}
+		n := len(parts)
+		gav := Gav{Group: strings.Join(parts[:n-3], "."),
+			Artifact: parts[n-3], Version: parts[n-2]}
+		pom, err := pl.fetch(gav)
+		if err != nil {
+			return fmt.Errorf("cannot read dependencies of %s: %v", p, err)
+		}
+		deps, err := pl.dependencies(pom, false)
+		if err != nil {
+			return fmt.Errorf("cannot read dependencies of %s: %v", p, err)
+		}
+		for _, d := range deps {
+			a.add(d, referenceKey(gav))
+		}
+	}
+	return nil
+}
+
+// dependants returns the artifacts that depend on gav.
+func (a *references) dependants(gav Gav) []string {
+	ds := append([]string(nil), a.index[referenceKey(gav)]...)
+	for _, r := range a.ranges[gav.Group+":"+gav.Artifact] {
+		if inRange(r.spec, gav.Version) {
+			ds = append(ds, r.dependant)
+		}
+	}
+	sort.Strings(ds)
+	return ds
+}
+
+// filter drops referenced versions, or only warns about them.
+func (a *references) filter(gavs []Gav) []Gav {
+	var ok []Gav
+	for _, gav := range gavs {
+		ds := a.dependants(gav)
+		if len(ds) == 0 {
+			ok = append(ok, gav)
+			continue
+		}
+		if *a.warn {
+			log.Printf("warning: %s is referenced by %s\n",
+				gav.ConciseNotation(), strings.Join(ds, ", "))
+			ok = append(ok, gav)
+			continue
+		}
+		log.Printf("%s is referenced by %s, keeping it\n",
+			gav.ConciseNotation(), strings.Join(ds, ", "))
+	}
+	return ok
+}
+
+func referencedByCommand(args []string) {
+	fs := newCommand("referenced-by", "<g:a:v>...")
+	nf := newNexusFlags(fs)
+	refs := newReferences(fs)
+	fs.Parse(args)
+	if fs.NArg() == 0 || !refs.active() {
+		fs.Usage()
+	}
+	var gavs []Gav
+	for _, arg := range fs.Args() {
+		gav, err := parseConcise(arg)
+		if err != nil {
+			log.Println(err)
+			exit(exitUsage)
+		}
+		gavs = append(gavs, gav)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt,
+		syscall.SIGTERM)
+	defer stop()
+	if err := refs.load(ctx, nf.repo(), nf.repos()); err != nil {
+		fail(err)
+	}
+	for _, gav := range gavs {
+		for _, d := range refs.dependants(gav) {
+			fmt.Printf("%s <- %s\n", gav.ConciseNotation(), d)
+		}
+	}
+}
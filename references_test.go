@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReferences(t *testing.T) {
+	_, inst := newFakeNexus(t,
+		pomArtifact("com.acme", "app", "1.0", `<project>
+  <groupId>com.acme</groupId><artifactId>app</artifactId>
+  <version>1.0</version>
+  <dependencies>
+    <dependency><groupId>com.acme</groupId><artifactId>lib</artifactId>
+      <version>2.0</version></dependency>
+  </dependencies>
+</project>`),
+		pomArtifact("com.acme", "lib", "2.0", `<project>
+  <groupId>com.acme</groupId><artifactId>lib</artifactId>
+  <version>2.0</version>
+</project>`))
+	graph := filepath.Join(t.TempDir(), "graph.txt")
+	err := os.WriteFile(graph, []byte("# exported\n"+
+		"org.other:svc:3.1 -> com.acme:lib:1.0\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	refs := newReferences(fs)
+	if err := fs.Parse([]string{"-dependency-graph", graph,
+		"-referenced-in", "releases/com/acme"}); err != nil {
+		t.Fatal(err)
+	}
+	repo := NexusRepository{NexusInstance: inst, RepositoryID: "releases"}
+	err = refs.load(context.Background(), repo, []NexusRepository{repo})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lib1 := Gav{Group: "com.acme", Artifact: "lib", Version: "1.0"}
+	lib2 := Gav{Group: "com.acme", Artifact: "lib", Version: "2.0"}
+	lib3 := Gav{Group: "com.acme", Artifact: "lib", Version: "3.0"}
+	if got, want := refs.dependants(lib2),
+		[]string{"com.acme:app:1.0"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Expected %v but got %v\n", want, got)
+	}
+	if got := refs.filter([]Gav{lib1, lib2, lib3}); !reflect.DeepEqual(got,
+		[]Gav{lib3}) {
+		t.Fatalf("Expected only unreferenced %v but got %v\n", lib3, got)
+	}
+	*refs.warn = true
+	if got := refs.filter([]Gav{lib1, lib3}); len(got) != 2 {
+		t.Fatalf("Expected warnings only but got %v\n", got)
+	}
+}
+
+func TestReferencesRange(t *testing.T) {
+	_, inst := newFakeNexus(t,
+		pomArtifact("com.acme", "app", "1.0", `<project>
+  <groupId>com.acme</groupId><artifactId>app</artifactId>
+  <version>1.0</version>
+  <dependencies>
+    <dependency><groupId>com.acme</groupId><artifactId>lib</artifactId>
+      <version>[1.0,2.0)</version></dependency>
+  </dependencies>
+</project>`))
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	refs := newReferences(fs)
+	if err := fs.Parse([]string{"-referenced-in",
+		"releases/com/acme"}); err != nil {
+		t.Fatal(err)
+	}
+	repo := NexusRepository{NexusInstance: inst, RepositoryID: "releases"}
+	err := refs.load(context.Background(), repo, []NexusRepository{repo})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lib1 := Gav{Group: "com.acme", Artifact: "lib", Version: "1.5"}
+	lib2 := Gav{Group: "com.acme", Artifact: "lib", Version: "2.0"}
+	if got := refs.filter([]Gav{lib1, lib2}); !reflect.DeepEqual(got,
+		[]Gav{lib2}) {
+		t.Fatalf("Expected only unreferenced %v but got %v\n", lib2, got)
+	}
+}
+
+func TestReferencesUnreadablePom(t *testing.T) {
+	_, inst := newFakeNexus(t,
+		pomArtifact("com.acme", "app", "1.0", `<project><broken`))
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	refs := newReferences(fs)
+	if err := fs.Parse([]string{"-referenced-in",
+		"releases/com/acme"}); err != nil {
+		t.Fatal(err)
+	}
+	repo := NexusRepository{NexusInstance: inst, RepositoryID: "releases"}
+	err := refs.load(context.Background(), repo, []NexusRepository{repo})
+	if err == nil {
+		t.Fatalf("Expected error for unreadable POM but got nil\n")
+	}
+}
@@ -0,0 +1,354 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"encoding/xml"
+	"fmt"
+	"hash"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"path"
+	"sort"
+	"strings"
+	"syscall"
+
+	"github.com/jhinrichsen/nexus-fetch/nexus"
+)
+
+// relocatedFile is a file to copy from old to new coordinates.
+type relocatedFile struct {
+	From, To string
+}
+
+// relocatedName maps a file of from to the same file of to, if it belongs
+// to from at all.
+func relocatedName(name string, from, to Gav) (string, bool) {
+	prefix := from.Artifact + "-" + from.Version
+	rest := strings.TrimPrefix(name, prefix)
+	if rest == name || rest == "" || rest[0] != '-' && rest[0] != '.' {
+		return "", false
+	}
+	return to.Artifact + "-" + to.Version + rest, true
+}
+
+// relocationPlan lists the files of from in repo and their paths below to.
+// Checksums and metadata are not copied, uploads create them anew.
+func relocationPlan(repo NexusRepository, from, to Gav) ([]relocatedFile,
+	error) {
+	fromDir := path.Dir(from.DefaultLayout())
+	toDir := path.Dir(to.DefaultLayout())
+	items, err := listContent(repo, fromDir)
+	if err != nil {
+		return nil, err
+	}
+	var files []relocatedFile
+	for _, item := range items {
+		if !item.Leaf || nexus.Ignored(item.Name) {
+			continue
+		}
+		name, ok := relocatedName(item.Name, from, to)
+		if !ok {
+			log.Printf("skipping %s/%s\n", fromDir, item.Name)
+			continue
+		}
+		files = append(files, relocatedFile{fromDir + "/" + item.Name,
+			toDir + "/" + name})
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].From < files[j].From
+	})
+	return files, nil
+}
+
+// rewritePom replaces the coordinates of the project, keeping those of its
+// parent and dependencies as well as all formatting. Coordinates inherited
+// from the parent are added if they change.
+func rewritePom(data []byte, to Gav) ([]byte, error) {
+	type edit struct {
+		start, end int
+		text       string
+	}
+	escape := func(s string) string {
+		var sb strings.Builder
+		xml.EscapeText(&sb, []byte(s))
+		return sb.String()
+	}
+	want := map[string]string{"groupId": to.Group,
+		"artifactId": to.Artifact, "version": to.Version}
+	found := make(map[string]bool)
+	var edits []edit
+	var stack []string
+	var contentStart, artifactStart, artifactEnd int
+	d := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		pos := int(d.InputOffset())
+		t, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch e := t.(type) {
+		case xml.StartElement:
+			stack = append(stack, e.Name.Local)
+			if len(stack) == 2 {
+				contentStart = int(d.InputOffset())
+				if e.Name.Local == "artifactId" {
+					artifactStart = pos
+				}
+			}
+		case xml.EndElement:
+			if len(stack) == 2 {
+				name := stack[1]
+				if v, ok := want[name]; ok {
+					found[name] = true
+					edits = append(edits, edit{contentStart, pos,
+						escape(v)})
+				}
+				if name == "artifactId" {
+					artifactEnd = int(d.InputOffset())
+				}
+			}
+			stack = stack[:len(stack)-1]
+		}
+	}
+	if !found["artifactId"] {
+		return nil, fmt.Errorf("POM without artifactId")
+	}
+	if !found["groupId"] {
+		edits = append(edits, edit{artifactStart, artifactStart,
+			"<groupId>" + escape(to.Group) + "</groupId>"})
+	}
+	if !found["version"] {
+		edits = append(edits, edit{artifactEnd, artifactEnd,
+			"<version>" + escape(to.Version) + "</version>"})
+	}
+	sort.SliceStable(edits, func(i, j int) bool {
+		return edits[i].start > edits[j].start
+	})
+	out := append([]byte{}, data...)
+	for _, e := range edits {
+		out = append(out[:e.start], append([]byte(e.text),
+			out[e.end:]...)...)
+	}
+	return out, nil
+}
+
+// uploadPath stores data at p in repo together with its checksums, as
+// Maven deploys do.
+func uploadPath(ctx context.Context, repo NexusRepository, p string,
+	data []byte) error {
+	return uploadStream(ctx, repo, p, bytes.NewReader(data),
+		int64(len(data)))
+}
+
+// uploadStream deploys size bytes read from r to p without buffering them,
+// the checksums follow once r is consumed.
+func uploadStream(ctx context.Context, repo NexusRepository, p string,
+	r io.Reader, size int64) error {
+	s1, m5 := sha1.New(), md5.New()
+	err := putPath(ctx, repo, p, io.TeeReader(r, io.MultiWriter(s1, m5)),
+		size)
+	if err != nil {
+		return err
+	}
+	for _, f := range []struct {
+		suffix string
+		hash   hash.Hash
+	}{{".sha1", s1}, {".md5", m5}} {
+		sum := fmt.Sprintf("%x", f.hash.Sum(nil))
+		err := putPath(ctx, repo, p+f.suffix, strings.NewReader(sum),
+			int64(len(sum)))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// putPath uploads size bytes read from r to p.
+func putPath(ctx context.Context, repo NexusRepository, p string,
+	r io.Reader, size int64) error {
+	u := RepositoryFileURL(repo, p)
+	log.Printf("uploading %s\n", u)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u, r)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	if repo.Username != "" {
+		req.SetBasicAuth(repo.Username, repo.Password)
+	}
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	if res.StatusCode/100 != 2 {
+		return &StatusError{URL: u, StatusCode: res.StatusCode}
+	}
+	return nil
+}
+
+// existingTargets returns the targets of files that dst already holds.
+func existingTargets(ctx context.Context, dst NexusRepository,
+	files []relocatedFile) ([]string, error) {
+	var ss []string
+	for _, f := range files {
+		u := RepositoryFileURL(dst, f.To)
+		req, err := http.NewRequestWithContext(ctx, http.MethodHead, u, nil)
+		if err != nil {
+			return nil, err
+		}
+		if dst.Username != "" {
+			req.SetBasicAuth(dst.Username, dst.Password)
+		}
+		res, err := httpClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		res.Body.Close()
+		switch res.StatusCode {
+		case http.StatusOK:
+			ss = append(ss, f.To)
+		case http.StatusNotFound:
+		default:
+			return nil, &StatusError{URL: u, StatusCode: res.StatusCode}
+		}
+	}
+	return ss, nil
+}
+
+// relocateFile copies a single file, rewriting POMs to their new
+// coordinates.
+func relocateFile(ctx context.Context, src, dst NexusRepository,
+	f relocatedFile, to Gav) error {
+	res, err := getAs(ctx, src.NexusInstance,
+		RepositoryFileURL(src, f.From))
+	if err != nil {
+		return err
+	}
+	data, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return err
+	}
+	if strings.HasSuffix(f.To, ".pom") {
+		if data, err = rewritePom(data, to); err != nil {
+			return fmt.Errorf("%s: %v", f.From, err)
+		}
+	}
+	return uploadPath(ctx, dst, f.To, data)
+}
+
+func relocateCommand(args []string) {
+	fs := newCommand("relocate", "<g:a:v> <new g[:a[:v]]>")
+	nf := newNexusFlags(fs)
+	audit := newAuditLog(fs)
+	conf := newConfirmation(fs)
+	var (
+		target = fs.String("target-repository", "",
+			"Deploy to this repository, default is -repository")
+		dryRun = fs.Bool("dry-run", false,
+			"Print the files to copy without copying them")
+	)
+	fs.Parse(args)
+	if fs.NArg() != 2 || nf.repo().RepositoryID == "" {
+		fs.Usage()
+	}
+	from, err := parseConcise(fs.Arg(0))
+	if err != nil || from.Group == "" || from.Artifact == "" ||
+		from.Version == "" {
+		log.Printf("expected group:artifact:version: %q\n", fs.Arg(0))
+		exit(exitUsage)
+	}
+	if strings.HasSuffix(from.Version, "SNAPSHOT") {
+		log.Println("snapshots cannot be relocated, deploy them again")
+		exit(exitUsage)
+	}
+	to, err := parseConcise(fs.Arg(1))
+	if err != nil {
+		log.Println(err)
+		exit(exitUsage)
+	}
+	// missing parts keep their old value
+	for _, p := range []struct{ to, from *string }{
+		{&to.Group, &from.Group}, {&to.Artifact, &from.Artifact},
+		{&to.Version, &from.Version}} {
+		if *p.to == "" {
+			*p.to = *p.from
+		}
+	}
+	to.Classifier, to.Packaging = "", ""
+	from.Classifier, from.Packaging = "", ""
+	if to == from {
+		log.Println("new coordinates equal the old ones")
+		exit(exitUsage)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt,
+		syscall.SIGTERM)
+	defer stop()
+	src := nf.repo()
+	dst := src
+	if *target != "" {
+		dst.RepositoryID = *target
+	}
+	files, err := relocationPlan(src, from, to)
+	if err != nil {
+		fail(err)
+	}
+	if len(files) == 0 {
+		log.Printf("no files of %s in %s\n", from.ConciseNotation(),
+			src.RepositoryID)
+		exit(exitNotFound)
+	}
+	existing, err := existingTargets(ctx, dst, files)
+	if err != nil {
+		fail(err)
+	}
+	for _, s := range existing {
+		log.Printf("%s/%s exists\n", dst.RepositoryID, s)
+	}
+	if len(existing) > 0 && !*conf.yes {
+		log.Println("refusing to overwrite existing files, use -force")
+		exit(exitPolicy)
+	}
+	if *dryRun {
+		for _, f := range files {
+			fmt.Printf("%s/%s -> %s/%s\n", src.RepositoryID, f.From,
+				dst.RepositoryID, f.To)
+		}
+		return
+	}
+	var targets []string
+	for _, f := range files {
+		targets = append(targets, dst.RepositoryID+"/"+f.To)
+	}
+	if err := conf.confirm("relocate", targets); err != nil {
+		fail(err)
+	}
+	var failures []error
+	for _, f := range files {
+		err := relocateFile(ctx, src, dst, f, to)
+		if aerr := audit.record(newAuditRecord("relocate", dst, to, f.To,
+			err)); aerr != nil {
+			fail(aerr)
+		}
+		if ctx.Err() != nil {
+			fail(ctx.Err())
+		}
+		if err != nil {
+			log.Printf("%s: %v\n", f.From, err)
+			failures = append(failures, err)
+		}
+	}
+	exit(summaryExitCode(len(files), failures))
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/jhinrichsen/nexus-fetch/nexusfetchtest"
+)
+
+func TestRewritePom(t *testing.T) {
+	to := Gav{Group: "com.new", Artifact: "app", Version: "2.0"}
+	for _, tt := range []struct{ in, want string }{
+		{`<project>
+  <parent><groupId>com.old</groupId><version>1</version></parent>
+  <groupId>com.old</groupId>
+  <artifactId>app</artifactId>
+  <version>1.0</version>
+  <dependencies><dependency><groupId>com.old</groupId>
+    <artifactId>lib</artifactId><version>1.0</version></dependency>
+  </dependencies>
+</project>`, `<project>
+  <parent><groupId>com.old</groupId><version>1</version></parent>
+  <groupId>com.new</groupId>
+  <artifactId>app</artifactId>
+  <version>2.0</version>
+  <dependencies><dependency><groupId>com.old</groupId>
+    <artifactId>lib</artifactId><version>1.0</version></dependency>
+  </dependencies>
+</project>`},
+		// inherited coordinates
+		{`<project><parent/><artifactId>app</artifactId></project>`,
+			`<project><parent/><groupId>com.new</groupId>` +
+				`<artifactId>app</artifactId><version>2.0</version></project>`},
+	} {
+		got, err := rewritePom([]byte(tt.in), to)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tt.want {
+			t.Fatalf("Expected %s but got %s\n", tt.want, got)
+		}
+	}
+}
+
+func TestRelocate(t *testing.T) {
+	old := func(classifier, ext, content string) nexusfetchtest.Artifact {
+		return nexusfetchtest.Artifact{Repository: "releases",
+			Group: "com.old", Artifact: "app", Version: "1.0",
+			Classifier: classifier, Extension: ext,
+			Content: []byte(content)}
+	}
+	_, inst := newFakeNexus(t, old("", "jar", "jar"),
+		old("sources", "jar", "src"), old("", "pom", `<project>
+  <groupId>com.old</groupId><artifactId>app</artifactId>
+  <version>1.0</version>
+</project>`))
+	repo := NexusRepository{NexusInstance: inst, RepositoryID: "releases"}
+	from := Gav{Group: "com.old", Artifact: "app", Version: "1.0"}
+	to := Gav{Group: "com.new", Artifact: "app", Version: "1.0"}
+
+	files, err := relocationPlan(repo, from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []relocatedFile{
+		{"com/old/app/1.0/app-1.0-sources.jar",
+			"com/new/app/1.0/app-1.0-sources.jar"},
+		{"com/old/app/1.0/app-1.0.jar", "com/new/app/1.0/app-1.0.jar"},
+		{"com/old/app/1.0/app-1.0.pom", "com/new/app/1.0/app-1.0.pom"},
+	}
+	if !reflect.DeepEqual(want, files) {
+		t.Fatalf("Expected %+v but got %+v\n", want, files)
+	}
+	ctx := context.Background()
+	existing, err := existingTargets(ctx, repo, files)
+	if err != nil || len(existing) != 0 {
+		t.Fatalf("Expected no existing targets but got %v, %v\n",
+			existing, err)
+	}
+	for _, f := range files {
+		if err := relocateFile(ctx, repo, repo, f, to); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p, err := newPomLoader(ctx, []NexusRepository{repo}).fetch(to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.GroupID != "com.new" {
+		t.Fatalf("Expected %s but got %s\n", "com.new", p.GroupID)
+	}
+	found, err := exists(ctx, Fqa{NexusRepository: repo,
+		Gav: Gav{Group: "com.new",
+			Artifact: "app", Version: "1.0", Classifier: "sources"}})
+	if err != nil || !found {
+		t.Fatalf("Expected relocated sources but got %v, %v\n", found, err)
+	}
+	// a second run must not overwrite
+	existing, err = existingTargets(ctx, repo, files)
+	if err != nil || len(existing) != len(files) {
+		t.Fatalf("Expected %d existing targets but got %v, %v\n",
+			len(files), existing, err)
+	}
+}
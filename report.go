@@ -0,0 +1,170 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+)
+
+// Report entry states
+const (
+	statusOK     = "ok"
+	statusFailed = "failed"
+)
+
+// RunReport records the outcome of every download of a run as JSON, so
+// that failed entries can be re-attempted later.
+type RunReport struct {
+	Started  time.Time     `json:"started"`
+	Finished time.Time     `json:"finished"`
+	Entries  []ReportEntry `json:"entries"`
+	// attempts counts earlier tries of downloads re-attempted from a
+	// previous report
+	attempts map[Fqa]int
+	// repositoryPath looks up the storage location of downloads, if set
+	repositoryPath func(Fqa) string
+}
+
+// ReportEntry is the outcome of a single download.
+type ReportEntry struct {
+	Repository string `json:"repository"`
+	Group      string `json:"groupId"`
+	Artifact   string `json:"artifactId"`
+	Version    string `json:"version"`
+	// ResolvedVersion is the timestamped version of a fetched snapshot
+	ResolvedVersion string `json:"resolvedVersion,omitempty"`
+	Classifier      string `json:"classifier,omitempty"`
+	Packaging       string `json:"packaging,omitempty"`
+	URL             string `json:"url"`
+	File            string `json:"file,omitempty"`
+	Status          string `json:"status"`
+	Error           string `json:"error,omitempty"`
+	// Bytes is the size of the downloaded file
+	Bytes          int64   `json:"bytes"`
+	ElapsedSeconds float64 `json:"elapsedSeconds"`
+	// Attempt counts tries across resumed reports, starting at 1
+	Attempt int `json:"attempt"`
+	// RepositoryPath is the storage location inside the repository
+	RepositoryPath string `json:"repositoryPath,omitempty"`
+}
+
+// Throughput returns bytes per second.
+func (a ReportEntry) Throughput() float64 {
+	if a.ElapsedSeconds == 0 {
+		return 0
+	}
+	return float64(a.Bytes) / a.ElapsedSeconds
+}
+
+func newRunReport() *RunReport {
+	return &RunReport{Started: time.Now()}
+}
+
+func (a *RunReport) add(fqa Fqa, url, file string, elapsed time.Duration,
+	err error) {
+	e := ReportEntry{
+		Repository:      fqa.RepositoryID,
+		Group:           fqa.Group,
+		Artifact:        fqa.Artifact,
+		Version:         fqa.Version,
+		Classifier:      fqa.Classifier,
+		Packaging:       fqa.Packaging,
+		URL:             url,
+		File:            file,
+		Status:          statusOK,
+		ResolvedVersion: resolvedVersion(fqa.Gav, file),
+		ElapsedSeconds:  elapsed.Seconds(),
+		Attempt:         a.attempts[fqa] + 1,
+	}
+	if fi, err := os.Stat(file); err == nil && file != "" {
+		e.Bytes = fi.Size()
+	}
+	if err != nil {
+		e.Status = statusFailed
+		e.Error = err.Error()
+	} else if a.repositoryPath != nil {
+		e.RepositoryPath = a.repositoryPath(fqa)
+	}
+	a.Entries = append(a.Entries, e)
+}
+
+func (a ReportEntry) fqa(inst NexusInstance) Fqa {
+	return Fqa{NexusRepository: NexusRepository{NexusInstance: inst,
+		RepositoryID: a.Repository},
+		Gav: Gav{Group: a.Group, Artifact: a.Artifact, Version: a.Version,
+			Classifier: a.Classifier, Packaging: a.Packaging}}
+}
+
+// failed returns all entries that did not complete, and a report holding
+// the remaining successful ones.
+func (a *RunReport) failed(inst NexusInstance) ([]Fqa, *RunReport) {
+	var fs []Fqa
+	ok := newRunReport()
+	ok.attempts = make(map[Fqa]int)
+	for _, e := range a.Entries {
+		if e.Status == statusOK {
+			ok.Entries = append(ok.Entries, e)
+		} else {
+			fqa := e.fqa(inst)
+			fs = append(fs, fqa)
+			// reports before statistics do not count attempts
+			ok.attempts[fqa] = e.Attempt
+			if e.Attempt == 0 {
+				ok.attempts[fqa] = 1
+			}
+		}
+	}
+	return fs, ok
+}
+
+func readReport(filename string) (*RunReport, error) {
+	buf, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var rep RunReport
+	err = json.Unmarshal(buf, &rep)
+	return &rep, err
+}
+
+// write stores the report, an empty filename disables reporting. Failing
+// to write the report is logged only, it must not mask the run's outcome.
+func (a *RunReport) write(filename string) {
+	if filename == "" {
+		return
+	}
+	a.Finished = time.Now()
+	buf, err := json.MarshalIndent(a, "", "  ")
+	if err == nil {
+		err = os.WriteFile(filename, append(buf, '\n'), 0644)
+	}
+	if err != nil {
+		log.Printf("cannot write report %s: %v\n", filename, err)
+	}
+}
+
+// summary writes statistics per downloaded file and in total.
+func (a *RunReport) summary(w io.Writer) {
+	var n int
+	var bytes int64
+	var seconds float64
+	var retries int
+	for _, e := range a.Entries {
+		retries += e.Attempt - 1
+		if e.Status != statusOK {
+			continue
+		}
+		n++
+		bytes += e.Bytes
+		seconds += e.ElapsedSeconds
+		fmt.Fprintf(w, "%10s %8.2fs %10s/s  %s\n", humanSize(e.Bytes),
+			e.ElapsedSeconds, humanSize(int64(e.Throughput())), e.File)
+	}
+	total := ReportEntry{Bytes: bytes, ElapsedSeconds: seconds}
+	fmt.Fprintf(w, "%d files, %s in %.2fs, average %s/s, %d retries\n", n,
+		humanSize(bytes), seconds, humanSize(int64(total.Throughput())),
+		retries)
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReportResume(t *testing.T) {
+	ok := Fqa{NexusRepository: NexusRepository{RepositoryID: "releases"},
+		Gav: Gav{Group: "g", Artifact: "a", Version: "1.0"}}
+	broken := Fqa{NexusRepository: NexusRepository{RepositoryID: "releases"},
+		Gav: Gav{Group: "g", Artifact: "a", Version: "1.1", Classifier: "dist",
+			Packaging: "zip"}}
+	rep := newRunReport()
+	rep.add(ok, "u1", "a-1.0.jar", time.Second, nil)
+	rep.add(broken, "u2", "", time.Second, errors.New("crc mismatch"))
+
+	f := filepath.Join(t.TempDir(), "report.json")
+	rep.write(f)
+	got, err := readReport(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inst := NexusInstance{Server: "nexus"}
+	failed, remaining := got.failed(inst)
+	broken.NexusInstance = inst
+	if len(failed) != 1 || failed[0] != broken {
+		t.Fatalf("Expected %+v but got %+v\n", broken, failed)
+	}
+	if len(remaining.Entries) != 1 || remaining.Entries[0].File != "a-1.0.jar" {
+		t.Fatalf("Expected successful entry to remain but got %+v\n",
+			remaining.Entries)
+	}
+}
+
+func TestReportStatistics(t *testing.T) {
+	dir := t.TempDir()
+	f := filepath.Join(dir, "a-1.0.jar")
+	if err := os.WriteFile(f, make([]byte, 2048), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fqa := Fqa{NexusRepository: NexusRepository{RepositoryID: "releases"},
+		Gav: Gav{Group: "g", Artifact: "a", Version: "1.0"}}
+	rep := newRunReport()
+	rep.add(fqa, "u1", "", time.Second, errors.New("timeout"))
+	_, rep = rep.failed(NexusInstance{})
+	rep.add(fqa, "u1", f, 2*time.Second, nil)
+
+	e := rep.Entries[0]
+	if e.Bytes != 2048 || e.Attempt != 2 || e.Throughput() != 1024 {
+		t.Fatalf("Expected 2048 bytes in attempt 2 at 1024 B/s but got "+
+			"%+v\n", e)
+	}
+	var sb strings.Builder
+	rep.summary(&sb)
+	want := "1 files, 2.0KiB in 2.00s, average 1.0KiB/s, 1 retries\n"
+	if got := sb.String(); !strings.HasSuffix(got, want) {
+		t.Fatalf("Expected %q but got %q\n", want, got)
+	}
+}
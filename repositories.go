@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/jhinrichsen/nexus-fetch/nexus"
+)
+
+// Repository describes a repository as listed by the repositories service.
+type Repository struct {
+	ID     string `xml:"id"`
+	Name   string `xml:"name"`
+	Type   string `xml:"repoType"`
+	Policy string `xml:"repoPolicy"`
+	Format string `xml:"format"`
+}
+
+type repositoriesResponse struct {
+	Repositories []Repository `xml:"data>repositories-item"`
+}
+
+// repositories lists all repositories of a Nexus instance.
+func repositories(inst NexusInstance) ([]Repository, error) {
+	u := nexus.BaseURL(NexusRepository{NexusInstance: inst}).String() +
+		"service/local/repositories"
+	log.Printf("getting %s\n", u)
+	res, err := httpClient.Get(u)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returns HTTP status code %d",
+			u, res.StatusCode)
+	}
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	var rs repositoriesResponse
+	err = xml.Unmarshal(body, &rs)
+	return rs.Repositories, err
+}
+
+// wantedPolicy returns the repository policy a version requires.
+func wantedPolicy(version string) string {
+	if strings.HasSuffix(version, "SNAPSHOT") {
+		return "SNAPSHOT"
+	}
+	return "RELEASE"
+}
+
+// mismatch is true if a repository cannot host version. Repositories
+// without policy (groups) or with MIXED policy accept everything.
+func (a Repository) mismatch(version string) bool {
+	switch a.Policy {
+	case "RELEASE", "SNAPSHOT":
+		return a.Policy != wantedPolicy(version)
+	}
+	return false
+}
+
+// checkPolicy warns if the repository policy does not match the requested
+// version, which Nexus would answer with a confusing 404. If redirect is
+// set, the first hosted repository with a matching policy is used instead.
+func checkPolicy(fqa Fqa, redirect bool) (Fqa, error) {
+	rs, err := repositories(fqa.NexusInstance)
+	if err != nil {
+		log.Printf("cannot check repository policy: %v\n", err)
+		return fqa, nil
+	}
+	for _, r := range rs {
+		if r.ID != fqa.RepositoryID || !r.mismatch(fqa.Version) {
+			continue
+		}
+		if err := warn("repository %s has policy %s, but version %s "+
+			"requires %s", r.ID, r.Policy, fqa.Version,
+			wantedPolicy(fqa.Version)); err != nil {
+			return fqa, err
+		}
+		if !redirect {
+			return fqa, nil
+		}
+		for _, alt := range rs {
+			if alt.Type == "hosted" && alt.Policy == wantedPolicy(fqa.Version) {
+				log.Printf("using repository %s instead\n", alt.ID)
+				fqa.RepositoryID = alt.ID
+				return fqa, nil
+			}
+		}
+		log.Printf("no %s repository available\n", wantedPolicy(fqa.Version))
+	}
+	return fqa, nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+const repositoriesResponseXML = `<repositories>
+  <data>
+    <repositories-item>
+      <id>releases</id>
+      <name>Releases</name>
+      <repoType>hosted</repoType>
+      <repoPolicy>RELEASE</repoPolicy>
+      <format>maven2</format>
+    </repositories-item>
+    <repositories-item>
+      <id>snapshots</id>
+      <name>Snapshots</name>
+      <repoType>hosted</repoType>
+      <repoPolicy>SNAPSHOT</repoPolicy>
+      <format>maven2</format>
+    </repositories-item>
+  </data>
+</repositories>`
+
+func TestCheckPolicy(t *testing.T) {
+	inst := fakeNexus(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, repositoriesResponseXML)
+	})
+	fqa := Fqa{NexusRepository: NexusRepository{NexusInstance: inst,
+		RepositoryID: "releases"},
+		Gav: Gav{Group: "g", Artifact: "a", Version: "1.0-SNAPSHOT"}}
+	for _, tt := range []struct {
+		version  string
+		redirect bool
+		want     string
+	}{
+		{"1.0-SNAPSHOT", false, "releases"},
+		{"1.0-SNAPSHOT", true, "snapshots"},
+		{"1.0", true, "releases"},
+	} {
+		fqa.Version = tt.version
+		got, err := checkPolicy(fqa, tt.redirect)
+		if err != nil || got.RepositoryID != tt.want {
+			t.Fatalf("Expected %s but got %s, %v\n", tt.want,
+				got.RepositoryID, err)
+		}
+	}
+	defer func(s bool) { strict = s }(strict)
+	strict = true
+	fqa.Version = "1.0-SNAPSHOT"
+	if _, err := checkPolicy(fqa, false); err == nil {
+		t.Fatalf("Expected a policy error with -strict\n")
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// newRunDir creates a directory below base named after the start of the
+// run, with a suffix if another run started in the same second.
+func newRunDir(base string, now time.Time) (string, error) {
+	if err := os.MkdirAll(base, 0755); err != nil {
+		return "", err
+	}
+	id := now.UTC().Format("20060102T150405Z")
+	for i := 1; ; i++ {
+		dir := filepath.Join(base, id)
+		if i > 1 {
+			dir += fmt.Sprintf("-%d", i)
+		}
+		err := os.Mkdir(dir, 0755)
+		if os.IsExist(err) {
+			continue
+		}
+		return dir, err
+	}
+}
+
+// startRun moves all output of a run into a new run directory: relative
+// download directory and report are placed inside, the log is copied to
+// run.log, and run.info names the process and command line.
+func startRun(base string, outputDir, reportFile *string) (string, error) {
+	now := time.Now()
+	dir, err := newRunDir(base, now)
+	if err != nil {
+		return "", err
+	}
+	if !filepath.IsAbs(*outputDir) {
+		*outputDir = filepath.Join(dir, *outputDir)
+	}
+	if *reportFile == "" {
+		*reportFile = "report.json"
+	}
+	if !filepath.IsAbs(*reportFile) {
+		*reportFile = filepath.Join(dir, *reportFile)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "run.info"),
+		[]byte(runInfo(now, os.Args)), 0644); err != nil {
+		return "", err
+	}
+	f, err := os.Create(filepath.Join(dir, "run.log"))
+	if err != nil {
+		return "", err
+	}
+	// the file is closed by the exit of the process
+	log.SetOutput(io.MultiWriter(os.Stderr, f))
+	log.Printf("run directory %s\n", dir)
+	return dir, nil
+}
+
+// runInfo describes the process of a run, without secrets on the command
+// line.
+func runInfo(now time.Time, args []string) string {
+	e := newHistoryEntry(now, args[1:], 0)
+	return fmt.Sprintf("pid: %d\nstarted: %s\nargs: %s\n", os.Getpid(),
+		now.Format(time.RFC3339),
+		strings.Join(append([]string{args[0]}, e.Flags...), " "))
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewRunDir(t *testing.T) {
+	base := t.TempDir()
+	now := time.Date(2024, 3, 12, 17, 39, 14, 0, time.UTC)
+	for _, want := range []string{"20240312T173914Z",
+		"20240312T173914Z-2"} {
+		dir, err := newRunDir(base, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := filepath.Base(dir); got != want {
+			t.Fatalf("Expected %s but got %s\n", want, got)
+		}
+	}
+}
+
+func TestRunInfoRedactsSecrets(t *testing.T) {
+	got := runInfo(time.Now(), []string{"nexus-fetch", "-username", "me",
+		"-password", "secret", "-sign-hmac=key", "g:a:1.0"})
+	if strings.Contains(got, "secret") || strings.Contains(got, "key") {
+		t.Fatalf("Expected secrets to be redacted but got %s\n", got)
+	}
+	want := "args: nexus-fetch -username me g:a:1.0\n"
+	if !strings.Contains(got, want) {
+		t.Fatalf("Expected %s but got %s\n", want, got)
+	}
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// sbomComponent is a fetched artifact as listed in an SBOM.
+type sbomComponent struct {
+	Gav
+	URL          string
+	SHA1, SHA256 string
+}
+
+// newSBOMComponent hashes the fetched file.
+func newSBOMComponent(gav Gav, u, file string) (sbomComponent, error) {
+	c := sbomComponent{Gav: gav.Normalize(), URL: u}
+	var err error
+	if c.SHA1, err = digestFile(file, sha1.New()); err != nil {
+		return c, err
+	}
+	c.SHA256, err = digestFile(file, sha256.New())
+	return c, err
+}
+
+// purl returns the package URL of a Maven artifact.
+func (a sbomComponent) purl() string {
+	s := fmt.Sprintf("pkg:maven/%s/%s@%s", url.PathEscape(a.Group),
+		url.PathEscape(a.Artifact), url.PathEscape(a.Version))
+	q := url.Values{}
+	if a.Classifier != "" {
+		q.Set("classifier", a.Classifier)
+	}
+	if a.Packaging != "" && a.Packaging != "jar" {
+		q.Set("type", a.Packaging)
+	}
+	if len(q) > 0 {
+		s += "?" + q.Encode()
+	}
+	return s
+}
+
+// sbomWriters write an SBOM document for the fetched components.
+var sbomWriters = map[string]func(w io.Writer, cs []sbomComponent,
+	now time.Time) error{
+	"cyclonedx": writeCycloneDX,
+	"spdx":      writeSPDX,
+}
+
+// newUUID returns a random UUID as required for document identifiers.
+func newUUID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10],
+		b[10:])
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// writeCycloneDX writes a CycloneDX 1.5 JSON document.
+func writeCycloneDX(w io.Writer, cs []sbomComponent, now time.Time) error {
+	type hash struct {
+		Alg     string `json:"alg"`
+		Content string `json:"content"`
+	}
+	type component struct {
+		Type    string `json:"type"`
+		BomRef  string `json:"bom-ref"`
+		Group   string `json:"group"`
+		Name    string `json:"name"`
+		Version string `json:"version"`
+		Purl    string `json:"purl"`
+		Hashes  []hash `json:"hashes"`
+	}
+	type tool struct {
+		Type string `json:"type"`
+		Name string `json:"name"`
+	}
+	var bom struct {
+		BomFormat    string `json:"bomFormat"`
+		SpecVersion  string `json:"specVersion"`
+		SerialNumber string `json:"serialNumber"`
+		Version      int    `json:"version"`
+		Metadata     struct {
+			Timestamp string `json:"timestamp"`
+			Tools     struct {
+				Components []tool `json:"components"`
+			} `json:"tools"`
+		} `json:"metadata"`
+		Components []component `json:"components"`
+	}
+	bom.BomFormat = "CycloneDX"
+	bom.SpecVersion = "1.5"
+	bom.SerialNumber = "urn:uuid:" + newUUID()
+	bom.Version = 1
+	bom.Metadata.Timestamp = now.UTC().Format(time.RFC3339)
+	bom.Metadata.Tools.Components = []tool{{"application", "nexus-fetch"}}
+	bom.Components = []component{}
+	for _, c := range cs {
+		bom.Components = append(bom.Components, component{"library",
+			c.purl(), c.Group, c.Artifact, c.Version, c.purl(),
+			[]hash{{"SHA-1", c.SHA1}, {"SHA-256", c.SHA256}}})
+	}
+	return writeJSON(w, bom)
+}
+
+// writeSPDX writes an SPDX 2.3 JSON document.
+func writeSPDX(w io.Writer, cs []sbomComponent, now time.Time) error {
+	type checksum struct {
+		Algorithm string `json:"algorithm"`
+		Value     string `json:"checksumValue"`
+	}
+	type externalRef struct {
+		Category string `json:"referenceCategory"`
+		Type     string `json:"referenceType"`
+		Locator  string `json:"referenceLocator"`
+	}
+	type pkg struct {
+		SPDXID           string        `json:"SPDXID"`
+		Name             string        `json:"name"`
+		VersionInfo      string        `json:"versionInfo"`
+		DownloadLocation string        `json:"downloadLocation"`
+		FilesAnalyzed    bool          `json:"filesAnalyzed"`
+		Checksums        []checksum    `json:"checksums"`
+		ExternalRefs     []externalRef `json:"externalRefs"`
+	}
+	var doc struct {
+		SPDXVersion       string `json:"spdxVersion"`
+		DataLicense       string `json:"dataLicense"`
+		SPDXID            string `json:"SPDXID"`
+		Name              string `json:"name"`
+		DocumentNamespace string `json:"documentNamespace"`
+		CreationInfo      struct {
+			Created  string   `json:"created"`
+			Creators []string `json:"creators"`
+		} `json:"creationInfo"`
+		Packages []pkg `json:"packages"`
+	}
+	doc.SPDXVersion = "SPDX-2.3"
+	doc.DataLicense = "CC0-1.0"
+	doc.SPDXID = "SPDXRef-DOCUMENT"
+	doc.Name = "nexus-fetch"
+	doc.DocumentNamespace = "https://spdx.org/spdxdocs/nexus-fetch-" +
+		newUUID()
+	doc.CreationInfo.Created = now.UTC().Format(time.RFC3339)
+	doc.CreationInfo.Creators = []string{"Tool: nexus-fetch"}
+	doc.Packages = []pkg{}
+	for i, c := range cs {
+		loc := c.URL
+		if loc == "" {
+			loc = "NOASSERTION"
+		}
+		doc.Packages = append(doc.Packages, pkg{
+			SPDXID:           fmt.Sprintf("SPDXRef-Package-%d", i+1),
+			Name:             c.Group + ":" + c.Artifact,
+			VersionInfo:      c.Version,
+			DownloadLocation: loc,
+			Checksums: []checksum{{"SHA1", c.SHA1},
+				{"SHA256", c.SHA256}},
+			ExternalRefs: []externalRef{{"PACKAGE-MANAGER", "purl",
+				c.purl()}},
+		})
+	}
+	return writeJSON(w, doc)
+}
+
+// writeSBOM writes the SBOM in format to filename.
+func writeSBOM(filename, format string, cs []sbomComponent) error {
+	write, ok := sbomWriters[strings.ToLower(format)]
+	if !ok {
+		return fmt.Errorf("unknown SBOM format %q, expected cyclonedx "+
+			"or spdx", format)
+	}
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	err = write(f, cs, time.Now())
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
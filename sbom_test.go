@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPurl(t *testing.T) {
+	c := sbomComponent{Gav: Gav{Group: "com.acme", Artifact: "app",
+		Version: "1.0", Classifier: "linux x86", Packaging: "zip"}}
+	want := "pkg:maven/com.acme/app@1.0?classifier=linux+x86&type=zip"
+	if got := c.purl(); got != want {
+		t.Fatalf("Expected %s but got %s\n", want, got)
+	}
+}
+
+func TestSBOM(t *testing.T) {
+	f := filepath.Join(t.TempDir(), "app-1.0.jar")
+	if err := os.WriteFile(f, []byte("jar"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c, err := newSBOMComponent(Gav{Group: "com.acme", Artifact: "app",
+		Version: "1.0"}, "http://nexus/app-1.0.jar", f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// sha1sum of "jar"
+	if want := "f92e777f4341930bad9b2422283c4680d00dbc06"; c.SHA1 != want {
+		t.Fatalf("Expected %s but got %s\n", want, c.SHA1)
+	}
+	for format, key := range map[string]string{"cyclonedx": "components",
+		"spdx": "packages"} {
+		var buf bytes.Buffer
+		err := sbomWriters[format](&buf, []sbomComponent{c}, time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+		var doc map[string][]map[string]interface{}
+		json.Unmarshal(buf.Bytes(), &doc)
+		if len(doc[key]) != 1 {
+			t.Fatalf("%s: Expected one component but got %s\n", format,
+				buf.String())
+		}
+		if !bytes.Contains(buf.Bytes(), []byte(c.SHA256)) ||
+			!bytes.Contains(buf.Bytes(), []byte(c.purl())) {
+			t.Fatalf("%s: Expected hash and purl but got %s\n", format,
+				buf.String())
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// cronSchedule is a parsed five field cron expression: minute, hour, day
+// of month, month and day of week.
+type cronSchedule struct {
+	fields [5]map[int]bool
+	// restricted day of month and day of week match either, as in cron
+	anyDom, anyDow bool
+}
+
+var cronRanges = [5][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+
+// parseCron accepts *, numbers, ranges a-b, lists and steps */n or a-b/n.
+func parseCron(expr string) (*cronSchedule, error) {
+	fs := strings.Fields(expr)
+	if len(fs) != 5 {
+		return nil, fmt.Errorf("illegal schedule %q, expected 5 fields",
+			expr)
+	}
+	var a cronSchedule
+	for i, f := range fs {
+		m, err := parseCronField(f, cronRanges[i][0], cronRanges[i][1])
+		if err != nil {
+			return nil, fmt.Errorf("illegal schedule %q: %v", expr, err)
+		}
+		a.fields[i] = m
+	}
+	// Sunday is 0 or 7
+	if a.fields[4][7] {
+		a.fields[4][0] = true
+	}
+	a.anyDom, a.anyDow = fs[2] == "*", fs[4] == "*"
+	return &a, nil
+}
+
+func parseCronField(f string, min, max int) (map[int]bool, error) {
+	m := make(map[int]bool)
+	for _, part := range strings.Split(f, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("illegal step in %q", part)
+			}
+			step, part = n, part[:i]
+		}
+		lo, hi := min, max
+		if part != "*" {
+			bounds := strings.SplitN(part, "-", 2)
+			var err error
+			if lo, err = strconv.Atoi(bounds[0]); err != nil {
+				return nil, fmt.Errorf("illegal value %q", part)
+			}
+			hi = lo
+			if len(bounds) == 2 {
+				if hi, err = strconv.Atoi(bounds[1]); err != nil {
+					return nil, fmt.Errorf("illegal value %q", part)
+				}
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return nil, fmt.Errorf("%q out of range %d-%d", part, min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			m[v] = true
+		}
+	}
+	return m, nil
+}
+
+func (a *cronSchedule) match(t time.Time) bool {
+	dom, dow := a.fields[2][t.Day()], a.fields[4][int(t.Weekday())]
+	day := dom && dow
+	switch {
+	case a.anyDom && !a.anyDow:
+		day = dow
+	case !a.anyDom && a.anyDow:
+		day = dom
+	case !a.anyDom && !a.anyDow:
+		day = dom || dow
+	}
+	return a.fields[0][t.Minute()] && a.fields[1][t.Hour()] &&
+		a.fields[3][int(t.Month())] && day
+}
+
+// next returns the first matching minute after t, or the zero time if
+// there is none within five years.
+func (a *cronSchedule) next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	for end := t.AddDate(5, 0, 0); t.Before(end); t = t.Add(time.Minute) {
+		if a.match(t) {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// scheduleArgs removes -schedule and -health-listen from a command line,
+// they may be given as -flag value or -flag=value.
+func scheduleArgs(args []string) (schedule, health string, rest []string) {
+	for i := 0; i < len(args); i++ {
+		name := strings.TrimLeft(args[i], "-")
+		var value *string
+		switch strings.SplitN(name, "=", 2)[0] {
+		case "schedule":
+			value = &schedule
+		case "health-listen":
+			value = &health
+		}
+		if value == nil || !strings.HasPrefix(args[i], "-") ||
+			args[i] == "--" {
+			rest = append(rest, args[i])
+			continue
+		}
+		if j := strings.Index(name, "="); j >= 0 {
+			*value = name[j+1:]
+		} else if i+1 < len(args) {
+			i++
+			*value = args[i]
+		}
+	}
+	return
+}
+
+// runState is reported by the health endpoint.
+type runState struct {
+	mu       sync.Mutex
+	Last     time.Time `json:"lastRun,omitempty"`
+	ExitCode int       `json:"lastExitCode"`
+	Next     time.Time `json:"nextRun"`
+}
+
+// ServeHTTP answers 503 if the last run failed.
+func (a *runState) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	if a.ExitCode != 0 {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(a)
+}
+
+// runScheduled runs nexus-fetch with args on every match of the schedule
+// until interrupted. Each run is a child process, so that failures of a
+// run do not end the schedule.
+func runScheduled(expr, health string, args []string) {
+	sched, err := parseCron(expr)
+	if err != nil {
+		log.Println(err)
+		exit(exitUsage)
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		fail(err)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt,
+		syscall.SIGTERM)
+	defer stop()
+
+	var state runState
+	if health != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/health", &state)
+		go func() {
+			fail(http.ListenAndServe(health, mux))
+		}()
+	}
+	for {
+		next := sched.next(time.Now())
+		if next.IsZero() {
+			log.Printf("schedule %q never matches\n", expr)
+			exit(exitUsage)
+		}
+		state.mu.Lock()
+		state.Next = next
+		state.mu.Unlock()
+		log.Printf("next run at %s\n", next.Format(time.RFC3339))
+		select {
+		case <-ctx.Done():
+			exit(exitInterrupted)
+		case <-time.After(time.Until(next)):
+		}
+
+		cmd := exec.CommandContext(ctx, exe, args...)
+		cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+		code := 0
+		if err := cmd.Run(); err != nil {
+			var ee *exec.ExitError
+			code = exitError
+			if errors.As(err, &ee) {
+				code = ee.ExitCode()
+			}
+			log.Printf("run failed: %v\n", err)
+		}
+		state.mu.Lock()
+		state.Last, state.ExitCode = time.Now(), code
+		state.mu.Unlock()
+	}
+}
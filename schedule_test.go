@@ -0,0 +1,66 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCronNext(t *testing.T) {
+	// a Wednesday
+	now := time.Date(2020, 1, 1, 3, 30, 0, 0, time.UTC)
+	for _, tt := range []struct {
+		expr string
+		want time.Time
+	}{
+		{"0 3 * * *", time.Date(2020, 1, 2, 3, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2020, 1, 1, 3, 45, 0, 0, time.UTC)},
+		{"0 0 * * 0", time.Date(2020, 1, 5, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2020, 1, 5, 0, 0, 0, 0, time.UTC)},
+		{"0 12 1-2 * 5", time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)},
+		{"30 4 15 6 *", time.Date(2020, 6, 15, 4, 30, 0, 0, time.UTC)},
+	} {
+		s, err := parseCron(tt.expr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := s.next(now); !got.Equal(tt.want) {
+			t.Fatalf("%s: expected %s but got %s\n", tt.expr, tt.want, got)
+		}
+	}
+	for _, expr := range []string{"* * * *", "60 * * * *", "5-1 * * * *",
+		"*/0 * * * *"} {
+		if _, err := parseCron(expr); err == nil {
+			t.Fatalf("Expected error for %q\n", expr)
+		}
+	}
+}
+
+func TestScheduleArgs(t *testing.T) {
+	sched, health, rest := scheduleArgs([]string{"prune", "-schedule",
+		"0 3 * * *", "-health-listen=:8081", "-repository", "releases",
+		"com.acme"})
+	want := []string{"prune", "-repository", "releases", "com.acme"}
+	if sched != "0 3 * * *" || health != ":8081" ||
+		!reflect.DeepEqual(rest, want) {
+		t.Fatalf("Expected %v but got %q %q %v\n", want, sched, health,
+			rest)
+	}
+}
+
+func TestRunStateHealth(t *testing.T) {
+	var state runState
+	rec := httptest.NewRecorder()
+	state.ServeHTTP(rec, httptest.NewRequest("GET", "/health", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200 but got %d\n", rec.Code)
+	}
+	state.ExitCode = exitPartial
+	rec = httptest.NewRecorder()
+	state.ServeHTTP(rec, httptest.NewRequest("GET", "/health", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected 503 but got %d\n", rec.Code)
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jhinrichsen/nexus-fetch/nexus"
+)
+
+// srCache is used for searches. Its zero value does not cache, commands
+// opt in by registering its flags.
+var srCache = &searchCache{}
+
+// searchCache keeps search responses on disk for a short time, so that
+// repeated identical queries do not hit the Nexus index again.
+type searchCache struct {
+	dir string
+	ttl time.Duration
+}
+
+func (a *searchCache) flags(fs *flag.FlagSet) {
+	if dir, err := os.UserCacheDir(); err == nil {
+		a.dir = filepath.Join(dir, "nexus-fetch", "search")
+	}
+	fs.DurationVar(&a.ttl, "search-cache", 0, "Reuse identical search "+
+		"responses for this long, e.g. 60s, 0 to always search")
+}
+
+// cachedSearch is a cache entry.
+type cachedSearch struct {
+	URL     string
+	User    string
+	Fetched time.Time
+	Body    []byte
+}
+
+func (a *searchCache) enabled() bool {
+	return a.dir != "" && a.ttl > 0
+}
+
+// file keys entries by user as well, users may see different repositories.
+func (a *searchCache) file(user, u string) string {
+	h := sha1.Sum([]byte(user + " " + u))
+	return filepath.Join(a.dir, hex.EncodeToString(h[:])+".json")
+}
+
+// do returns the body of a successful search response, from cache if
+// possible.
+func (a *searchCache) do(c *http.Client, req *http.Request) ([]byte,
+	error) {
+	u := req.URL.String()
+	user, _, _ := req.BasicAuth()
+	if a.enabled() {
+		var e cachedSearch
+		buf, err := os.ReadFile(a.file(user, u))
+		if err == nil && json.Unmarshal(buf, &e) == nil && e.URL == u &&
+			e.User == user && time.Since(e.Fetched) < a.ttl {
+			log.Printf("using cached search %s\n", u)
+			return e.Body, nil
+		}
+	}
+	body, err := nexus.Search(c, req)
+	if err != nil {
+		return nil, err
+	}
+	if a.enabled() {
+		a.store(cachedSearch{u, user, time.Now(), body})
+	}
+	return body, nil
+}
+
+// store is best effort, a failing cache must not fail the command.
+func (a *searchCache) store(e cachedSearch) {
+	buf, err := json.Marshal(e)
+	if err == nil {
+		err = os.MkdirAll(a.dir, 0755)
+	}
+	if err == nil {
+		err = os.WriteFile(a.file(e.User, e.URL), buf, 0644)
+	}
+	if err != nil {
+		log.Printf("cannot cache %s: %v\n", e.URL, err)
+	}
+}
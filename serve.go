@@ -0,0 +1,292 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jhinrichsen/nexus-fetch/nexus"
+)
+
+// server streams artifacts from Nexus to clients that do not hold Nexus
+// credentials themselves, either by coordinates or as a pass-through proxy
+// of repository paths. Released artifacts are cached on disk, snapshots
+// and metadata are always fetched upstream.
+type server struct {
+	repo     NexusRepository
+	cacheDir string
+	client   *http.Client
+	metrics  *metrics
+}
+
+func (a *server) routes() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/fetch", a.fetch)
+	mux.HandleFunc("/repositories/", a.proxy)
+	mux.Handle("/metrics", a.metrics)
+	return mux
+}
+
+// fetch handles GET /fetch?gav=g:a:v[:c][@p][&repository=id]
+func (a *server) fetch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	gav, err := parseConcise(r.URL.Query().Get("gav"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	fqa := Fqa{NexusRepository: a.repo, Gav: gav}
+	if id := r.URL.Query().Get("repository"); id != "" {
+		if err := checkRepositoryID(id); err != nil {
+			http.Error(w, "parameter repository "+err.Error(),
+				http.StatusBadRequest)
+			return
+		}
+		fqa.RepositoryID = id
+	}
+	if !fullySpecified(fqa) {
+		http.Error(w, "parameter gav requires group, artifact and version",
+			http.StatusBadRequest)
+		return
+	}
+
+	cached := a.cacheKey(fqa)
+	if a.serveCached(w, r, cached, fqa.Filename()) {
+		log.Printf("served %s from cache\n", fqa.Gav.ConciseNotation())
+		return
+	}
+	a.relay(w, r, fqa.NexusInstance, mavenURL("content", fqa), cached)
+}
+
+// proxy handles GET /repositories/<id>/<path> like the Nexus content URL
+// of the same path, so that build tools can use the server as a mirror.
+func (a *server) proxy(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	p := strings.TrimPrefix(path.Clean(r.URL.Path), "/repositories/")
+	i := strings.Index(p, "/")
+	if i <= 0 || checkRepositoryID(p[:i]) != nil {
+		http.NotFound(w, r)
+		return
+	}
+	repo := a.repo
+	repo.RepositoryID = p[:i]
+	rel := p[i+1:]
+	// a trailing slash requests a directory listing
+	if strings.HasSuffix(r.URL.Path, "/") {
+		rel += "/"
+	}
+	cached := a.proxyCacheKey(repo.RepositoryID, rel)
+	if a.serveCached(w, r, cached, "") {
+		log.Printf("served %s from cache\n", r.URL.Path)
+		return
+	}
+	a.relay(w, r, repo.NexusInstance, RepositoryFileURL(repo, rel), cached)
+}
+
+// serveCached serves the file cached under key, an empty name omits the
+// Content-Disposition header. It reports whether the file was cached.
+func (a *server) serveCached(w http.ResponseWriter, r *http.Request,
+	key, name string) bool {
+	if key == "" {
+		return false
+	}
+	f, err := a.cache().open(key)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	a.metrics.cacheHit()
+	if fi, err := f.Stat(); err == nil {
+		a.metrics.fetched(fi.Size())
+	}
+	if name != "" {
+		w.Header().Set("Content-Disposition",
+			fmt.Sprintf("attachment; filename=%q", name))
+	}
+	http.ServeContent(w, r, path.Base(key), modTime(f), f)
+	return true
+}
+
+// relay streams u from Nexus to the client, caching it under key unless
+// that is empty.
+func (a *server) relay(w http.ResponseWriter, r *http.Request,
+	inst NexusInstance, u, key string) {
+	start := time.Now()
+	res, err := a.upstream(inst, u)
+	a.metrics.observe(time.Since(start))
+	if err == nil && res.StatusCode >= 500 {
+		a.metrics.upstreamError()
+	}
+	if err != nil {
+		a.metrics.upstreamError()
+		log.Printf("upstream error: %v\n", err)
+		http.Error(w, "upstream error", http.StatusBadGateway)
+		return
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		http.Error(w, http.StatusText(res.StatusCode), res.StatusCode)
+		return
+	}
+	for _, h := range []string{"Content-Type", "Content-Length",
+		"Content-Disposition", "Last-Modified"} {
+		if v := res.Header.Get(h); v != "" {
+			w.Header().Set(h, v)
+		}
+	}
+	if r.Method == http.MethodHead {
+		return
+	}
+	var body io.Reader = res.Body
+	var cw *casWriter
+	if key != "" {
+		cw, err = a.cache().create(key)
+		if err != nil {
+			log.Printf("cannot cache %s: %v\n", key, err)
+		} else {
+			body = io.TeeReader(res.Body, cw)
+		}
+	}
+	n, err := io.Copy(w, body)
+	a.metrics.fetched(n)
+	if cw != nil {
+		if cerr := cw.commit(err); cerr != nil && err == nil {
+			log.Printf("cannot cache %s: %v\n", key, cerr)
+		}
+	}
+	if err != nil {
+		log.Printf("error streaming %s: %v\n", u, err)
+	}
+}
+
+// upstream requests u from Nexus using the daemon's credentials.
+func (a *server) upstream(inst NexusInstance, u string) (*http.Response,
+	error) {
+	log.Printf("getting %s\n", u)
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	if inst.Username != "" {
+		req.SetBasicAuth(inst.Username, inst.Password)
+	}
+	return a.client.Do(req)
+}
+
+// checkRepositoryID rejects repository IDs that are not a single path
+// segment.
+func checkRepositoryID(id string) error {
+	if err := nexus.CheckCoordinate(id); err != nil {
+		return err
+	}
+	if id == "." || id == ".." {
+		return fmt.Errorf("%q is not a repository", id)
+	}
+	return nil
+}
+
+func (a *server) cache() casStore {
+	return casStore{a.cacheDir}
+}
+
+// cacheKey returns the key of an artifact in the cache, or an empty string
+// if the artifact must not be cached.
+func (a *server) cacheKey(fqa Fqa) string {
+	if a.cacheDir == "" || strings.HasSuffix(fqa.Version, "SNAPSHOT") ||
+		fqa.Version == "LATEST" || fqa.Version == "RELEASE" {
+		return ""
+	}
+	return fqa.RepositoryID + "/" + fqa.DefaultLayout()
+}
+
+// proxyCacheKey returns the cache key of a repository path. Directory
+// listings, metadata and snapshots change and are not cached.
+func (a *server) proxyCacheKey(id, rel string) string {
+	if a.cacheDir == "" || strings.HasSuffix(rel, "/") ||
+		strings.Contains(rel, "SNAPSHOT") ||
+		strings.HasPrefix(path.Base(rel), "maven-metadata") {
+		return ""
+	}
+	return id + "/" + rel
+}
+
+func modTime(f *os.File) (t time.Time) {
+	if fi, err := f.Stat(); err == nil {
+		t = fi.ModTime()
+	}
+	return
+}
+
+// tempFile creates a temporary file next to its final destination so that
+// renaming is atomic.
+func tempFile(dest string) (*os.File, error) {
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return nil, err
+	}
+	return ioutil.TempFile(filepath.Dir(dest), ".download-")
+}
+
+// commit moves a completely written temporary file into place, or removes
+// it if writing failed.
+func commit(tmp *os.File, dest string, err error) {
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), dest)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+}
+
+func serveCommand(args []string) {
+	fs := newCommand("serve", "")
+	nf := newNexusFlags(fs)
+	var (
+		listen   = fs.String("listen", ":8080", "Listen address")
+		cacheDir = fs.String("cache-dir", "",
+			"Cache released artifacts in this directory, empty to disable")
+		verify = fs.Bool("verify-cache", false,
+			"Check the content of all cached files and exit")
+	)
+	fs.Parse(args)
+	if fs.NArg() != 0 {
+		fs.Usage()
+	}
+	if *verify {
+		corrupt, err := casStore{*cacheDir}.verify()
+		if err != nil {
+			fail(err)
+		}
+		for _, f := range corrupt {
+			fmt.Println(f)
+		}
+		if len(corrupt) > 0 {
+			exit(exitIntegrity)
+		}
+		return
+	}
+
+	srv := &server{
+		repo:     nf.repo(),
+		cacheDir: *cacheDir,
+		client:   httpClient,
+		metrics:  newMetrics(),
+	}
+	log.Printf("listening on %s\n", *listen)
+	fail(http.ListenAndServe(*listen, srv.routes()))
+}
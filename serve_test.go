@@ -0,0 +1,163 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jhinrichsen/nexus-fetch/nexus"
+)
+
+// fakeNexus returns a Nexus instance backed by handler.
+func fakeNexus(t *testing.T, handler http.HandlerFunc) NexusInstance {
+	ts := httptest.NewServer(handler)
+	t.Cleanup(ts.Close)
+	u, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NexusInstance{Protocol: "http", Server: u.Hostname(),
+		Port: u.Port(), Contextroot: "nexus/",
+		Username: "admin", Password: "admin123"}
+}
+
+func TestServeCachesReleases(t *testing.T) {
+	requests := 0
+	inst := fakeNexus(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if u, p, _ := r.BasicAuth(); u != "admin" || p != "admin123" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte("jar"))
+	})
+	srv := &server{
+		repo: NexusRepository{NexusInstance: inst,
+			RepositoryID: "releases"},
+		cacheDir: t.TempDir(),
+		client:   http.DefaultClient,
+		metrics:  newMetrics(),
+	}
+	ts := httptest.NewServer(srv.routes())
+	defer ts.Close()
+
+	for i := 0; i < 2; i++ {
+		res, err := http.Get(ts.URL + "/fetch?gav=g:a:1.0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		if res.StatusCode != 200 || string(body) != "jar" {
+			t.Fatalf("Expected status 200 and body jar but got %d %q\n",
+				res.StatusCode, body)
+		}
+	}
+	if requests != 1 {
+		t.Fatalf("Expected one upstream request but got %d\n", requests)
+	}
+	if srv.metrics.fetches != 2 || srv.metrics.cacheHits != 1 ||
+		srv.metrics.bytes != 6 {
+		t.Fatalf("unexpected metrics %+v\n", srv.metrics)
+	}
+}
+
+func TestServeProxy(t *testing.T) {
+	var paths []string
+	inst := fakeNexus(t, func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		if r.URL.Path == "/nexus/content/repositories/releases/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte("content"))
+	})
+	srv := &server{
+		repo: NexusRepository{NexusInstance: inst,
+			RepositoryID: "releases"},
+		cacheDir: t.TempDir(),
+		client:   http.DefaultClient,
+		metrics:  newMetrics(),
+	}
+	ts := httptest.NewServer(srv.routes())
+	defer ts.Close()
+
+	get := func(p string) int {
+		res, err := http.Get(ts.URL + p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		return res.StatusCode
+	}
+	for i := 0; i < 2; i++ {
+		for _, p := range []string{
+			"/repositories/releases/g/a/1.0/a-1.0.jar",
+			"/repositories/releases/g/a/maven-metadata.xml",
+			"/repositories/snapshots/g/a/1.0-SNAPSHOT/a-1.0-SNAPSHOT.jar",
+		} {
+			if status := get(p); status != http.StatusOK {
+				t.Fatalf("%s: expected 200 but got %d\n", p, status)
+			}
+		}
+	}
+	if status := get("/repositories/releases/missing"); status != 404 {
+		t.Fatalf("Expected 404 but got %d\n", status)
+	}
+	// the release is cached, metadata and snapshot are not
+	if len(paths) != 6 {
+		t.Fatalf("Expected 6 upstream requests but got %v\n", paths)
+	}
+}
+
+func TestServeRejectsRepositoryTraversal(t *testing.T) {
+	inst := fakeNexus(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("jar"))
+	})
+	dir := t.TempDir()
+	srv := &server{
+		repo: NexusRepository{NexusInstance: inst,
+			RepositoryID: "releases"},
+		cacheDir: filepath.Join(dir, "cache"),
+		client:   http.DefaultClient,
+		metrics:  newMetrics(),
+	}
+	ts := httptest.NewServer(srv.routes())
+	defer ts.Close()
+
+	res, err := http.Get(ts.URL + "/fetch?gav=g:a:1.0&repository=../../x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Expected 400 but got %d\n", res.StatusCode)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "x")); !os.IsNotExist(err) {
+		t.Fatalf("Expected nothing written outside the cache but got %v\n",
+			err)
+	}
+}
+
+func TestUpstreamAnonymous(t *testing.T) {
+	var auth string
+	inst := fakeNexus(t, func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+	})
+	inst.Username, inst.Password = "", ""
+	srv := &server{client: http.DefaultClient}
+	res, err := srv.upstream(inst,
+		nexus.BaseURL(NexusRepository{NexusInstance: inst}).String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if auth != "" {
+		t.Fatalf("Expected no credentials but got %q\n", auth)
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"hash"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// checksumHashes maps checksum sidecar extensions to their algorithm.
+var checksumHashes = map[string]func() hash.Hash{
+	".md5":    md5.New,
+	".sha1":   sha1.New,
+	".sha256": sha256.New,
+	".sha512": sha512.New,
+}
+
+// repoContentTypes are the types Maven clients expect, other extensions
+// fall back to the mime package.
+var repoContentTypes = map[string]string{
+	".pom":    "application/xml",
+	".xml":    "application/xml",
+	".jar":    "application/java-archive",
+	".war":    "application/java-archive",
+	".ear":    "application/java-archive",
+	".md5":    "text/plain",
+	".sha1":   "text/plain",
+	".sha256": "text/plain",
+	".sha512": "text/plain",
+	".asc":    "text/plain",
+}
+
+// repoServer serves a local directory in Maven layout read-only.
+// Checksums missing on disk are computed on request.
+type repoServer struct {
+	root  string
+	files http.Handler
+}
+
+func newRepoServer(root string) *repoServer {
+	return &repoServer{root, http.FileServer(http.Dir(root))}
+}
+
+func (a *repoServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	p := path.Clean("/" + r.URL.Path)
+	ext := path.Ext(p)
+	if ct, ok := repoContentTypes[ext]; ok {
+		w.Header().Set("Content-Type", ct)
+	} else if ct := mime.TypeByExtension(ext); ct != "" {
+		w.Header().Set("Content-Type", ct)
+	}
+	if h, ok := checksumHashes[ext]; ok {
+		if a.checksum(w, p, h) {
+			return
+		}
+	}
+	a.files.ServeHTTP(w, r)
+}
+
+// checksum answers requests for checksum files that do not exist on disk
+// but whose artifact does, and reports whether it did.
+func (a *repoServer) checksum(w http.ResponseWriter, p string,
+	h func() hash.Hash) bool {
+	f := filepath.Join(a.root, filepath.FromSlash(p))
+	if _, err := os.Stat(f); err == nil {
+		return false
+	}
+	artifact := strings.TrimSuffix(f, path.Ext(p))
+	if fi, err := os.Stat(artifact); err != nil || fi.IsDir() {
+		return false
+	}
+	sum, err := digestFile(artifact, h())
+	if err != nil {
+		log.Printf("cannot compute checksum of %s: %v\n", artifact, err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return true
+	}
+	w.Write([]byte(sum))
+	return true
+}
+
+func serveRepoCommand(args []string) {
+	fs := newCommand("serve-repo", "<directory in Maven layout>")
+	listen := fs.String("listen", ":8082", "Listen address")
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		fs.Usage()
+	}
+	dir := fs.Arg(0)
+	// flags may follow the directory
+	fs.Parse(fs.Args()[1:])
+	if fs.NArg() != 0 {
+		fs.Usage()
+	}
+	if fi, err := os.Stat(dir); err != nil || !fi.IsDir() {
+		log.Printf("not a directory: %s\n", dir)
+		exit(exitUsage)
+	}
+	log.Printf("serving %s on %s\n", dir, *listen)
+	fail(http.ListenAndServe(*listen, newRepoServer(dir)))
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestServeRepo(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "org", "acme", "app", "1.0")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{
+		"app-1.0.jar": "jar",
+		"app-1.0.pom": "<project/>",
+	} {
+		err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	ts := httptest.NewServer(newRepoServer(root))
+	defer ts.Close()
+
+	for _, tt := range []struct {
+		path, contentType, body string
+	}{
+		{"/org/acme/app/1.0/app-1.0.jar", "application/java-archive", "jar"},
+		{"/org/acme/app/1.0/app-1.0.pom", "application/xml", "<project/>"},
+		// sha1 of "jar"
+		{"/org/acme/app/1.0/app-1.0.jar.sha1", "text/plain",
+			"f92e777f4341930bad9b2422283c4680d00dbc06"},
+	} {
+		res, err := http.Get(ts.URL + tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		if res.StatusCode != http.StatusOK ||
+			!strings.HasPrefix(res.Header.Get("Content-Type"),
+				tt.contentType) {
+			t.Fatalf("%s: expected 200 %s but got %d %s\n", tt.path,
+				tt.contentType, res.StatusCode,
+				res.Header.Get("Content-Type"))
+		}
+		if string(body) != tt.body {
+			t.Fatalf("Expected %q but got %q\n", tt.body, body)
+		}
+	}
+
+	for _, tt := range []struct {
+		method, path string
+		status       int
+	}{
+		{http.MethodPut, "/org/acme/app/1.0/app-1.0.jar",
+			http.StatusMethodNotAllowed},
+		{http.MethodGet, "/org/acme/app/2.0/app-2.0.jar.sha1",
+			http.StatusNotFound},
+		{http.MethodGet, "/../../etc/passwd", http.StatusNotFound},
+	} {
+		req, _ := http.NewRequest(tt.method, ts.URL+tt.path, nil)
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if res.StatusCode != tt.status {
+			t.Fatalf("%s %s: expected %d but got %d\n", tt.method,
+				tt.path, tt.status, res.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"net/http"
+	"net/http/cookiejar"
+)
+
+// sessions keeps cookies issued by Nexus or an SSO proxy for the rest of
+// the run, see -no-session.
+var sessions, _ = cookiejar.New(nil)
+
+func init() {
+	httpClient.Jar = sessions
+}
+
+// disableSessions makes every request authenticate on its own.
+func disableSessions() {
+	httpClient.Jar = nil
+}
+
+// sessionTransport sends requests carrying a session cookie without
+// credentials, so that the server does not authenticate them again. If the
+// session expired, the request is repeated with credentials.
+type sessionTransport struct {
+	http.RoundTripper
+}
+
+func (a sessionTransport) RoundTrip(req *http.Request) (*http.Response,
+	error) {
+	auth := req.Header.Get("Authorization")
+	// requests with a body cannot be repeated
+	if auth == "" || req.Header.Get("Cookie") == "" || req.Body != nil &&
+		req.Body != http.NoBody {
+		return a.RoundTripper.RoundTrip(req)
+	}
+	anonymous := req.Clone(req.Context())
+	anonymous.Header.Del("Authorization")
+	res, err := a.RoundTripper.RoundTrip(anonymous)
+	if err != nil || res.StatusCode != http.StatusUnauthorized {
+		return res, err
+	}
+	res.Body.Close()
+	return a.RoundTripper.RoundTrip(req)
+}
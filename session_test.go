@@ -0,0 +1,102 @@
+package main
+
+import (
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSessionReuse(t *testing.T) {
+	var logins int
+	valid := true
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter,
+		r *http.Request) {
+		if c, err := r.Cookie("NXSESSIONID"); err == nil && valid &&
+			c.Value == "s1" {
+			return
+		}
+		if _, _, ok := r.BasicAuth(); !ok {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		logins++
+		valid = true
+		http.SetCookie(w, &http.Cookie{Name: "NXSESSIONID", Value: "s1"})
+	}))
+	defer ts.Close()
+
+	jar, _ := cookiejar.New(nil)
+	c := &http.Client{Jar: jar, Transport: sessionTransport{
+		http.DefaultTransport}}
+	do := func() {
+		req, _ := http.NewRequest(http.MethodGet, ts.URL, nil)
+		req.SetBasicAuth("admin", "admin123")
+		res, err := c.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if res.StatusCode != http.StatusOK {
+			t.Fatalf("Expected %d but got %d\n", http.StatusOK,
+				res.StatusCode)
+		}
+	}
+	for i := 0; i < 3; i++ {
+		do()
+	}
+	if logins != 1 {
+		t.Fatalf("Expected %d but got %d\n", 1, logins)
+	}
+
+	// expired session falls back to credentials
+	valid = false
+	do()
+	if logins != 2 {
+		t.Fatalf("Expected %d but got %d\n", 2, logins)
+	}
+}
+
+func TestAuthFallback(t *testing.T) {
+	anonymousAllowed := false
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter,
+		r *http.Request) {
+		u, p, ok := r.BasicAuth()
+		switch {
+		case ok && u == "admin" && p == "admin123":
+		case !ok && anonymousAllowed:
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer ts.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, ts.URL, nil)
+	credentials.Store(req.URL.Host, userPassword{"admin", "admin123"})
+	defer credentials.Delete(req.URL.Host)
+	c := &http.Client{Transport: authTransport{http.DefaultTransport}}
+	do := func(user string) int {
+		req, _ := http.NewRequest(http.MethodGet, ts.URL, nil)
+		if user != "" {
+			req.SetBasicAuth(user, "wrong")
+		}
+		res, err := c.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		return res.StatusCode
+	}
+	// anonymous requests are repeated with credentials
+	if got := do(""); got != http.StatusOK {
+		t.Fatalf("Expected %d but got %d\n", http.StatusOK, got)
+	}
+	// rejected credentials are dropped if anonymous access works
+	if got := do("nobody"); got != http.StatusUnauthorized {
+		t.Fatalf("Expected %d but got %d\n", http.StatusUnauthorized, got)
+	}
+	anonymousAllowed = true
+	if got := do("nobody"); got != http.StatusOK {
+		t.Fatalf("Expected %d but got %d\n", http.StatusOK, got)
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/jhinrichsen/nexus-fetch/nexus"
+)
+
+// signer signs all requests of the command line tool if set.
+var signer nexus.RequestSigner
+
+// setHMAC parses key-id:secret.
+func setHMAC(s string) error {
+	i := strings.Index(s, ":")
+	if i <= 0 || i == len(s)-1 {
+		return fmt.Errorf("want key-id:secret but got %q", s)
+	}
+	signer = &nexus.HMACSigner{KeyID: s[:i], Secret: []byte(s[i+1:])}
+	return nil
+}
+
+// signingTransport signs requests with signer once the flags have set it.
+type signingTransport struct {
+	http.RoundTripper
+}
+
+func (a signingTransport) RoundTrip(req *http.Request) (*http.Response,
+	error) {
+	if signer == nil {
+		return a.RoundTripper.RoundTrip(req)
+	}
+	return nexus.SigningTransport(signer, a.RoundTripper).RoundTrip(req)
+}
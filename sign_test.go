@@ -0,0 +1,23 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/jhinrichsen/nexus-fetch/nexus"
+)
+
+func TestSetHMAC(t *testing.T) {
+	defer func() { signer = nil }()
+	for _, s := range []string{"", "id", ":secret", "id:"} {
+		if err := setHMAC(s); err == nil {
+			t.Fatalf("Expected error for %q\n", s)
+		}
+	}
+	if err := setHMAC("id:se:cret"); err != nil {
+		t.Fatal(err)
+	}
+	if h := signer.(*nexus.HMACSigner); h.KeyID != "id" ||
+		string(h.Secret) != "se:cret" {
+		t.Fatalf("unexpected signer %+v\n", h)
+	}
+}
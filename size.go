@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// byteSize is a flag value accepting sizes such as 512, 64K, 500MB or 2GiB.
+// Units are binary (1K = 1024 bytes), 0 means unlimited.
+type byteSize int64
+
+var sizeUnits = []struct {
+	suffix string
+	factor int64
+}{
+	{"EIB", 1 << 60}, {"PIB", 1 << 50},
+	{"TIB", 1 << 40}, {"GIB", 1 << 30}, {"MIB", 1 << 20}, {"KIB", 1 << 10},
+	{"EB", 1 << 60}, {"PB", 1 << 50},
+	{"TB", 1 << 40}, {"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10},
+	{"E", 1 << 60}, {"P", 1 << 50},
+	{"T", 1 << 40}, {"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10},
+	{"B", 1},
+}
+
+func parseSize(s string) (int64, error) {
+	u := strings.ToUpper(strings.TrimSpace(s))
+	factor := int64(1)
+	for _, unit := range sizeUnits {
+		if strings.HasSuffix(u, unit.suffix) {
+			factor = unit.factor
+			u = strings.TrimSpace(strings.TrimSuffix(u, unit.suffix))
+			break
+		}
+	}
+	n, err := strconv.ParseInt(u, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("illegal size %q", s)
+	}
+	if n > math.MaxInt64/factor {
+		return 0, fmt.Errorf("size %q too large", s)
+	}
+	return n * factor, nil
+}
+
+func (a *byteSize) String() string {
+	return humanSize(int64(*a))
+}
+
+func (a *byteSize) Set(s string) error {
+	n, err := parseSize(s)
+	if err != nil {
+		return err
+	}
+	*a = byteSize(n)
+	return nil
+}
+
+// humanSize formats a number of bytes using binary units.
+func humanSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
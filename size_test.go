@@ -0,0 +1,51 @@
+package main
+
+import "testing"
+
+func TestParseSize(t *testing.T) {
+	for in, want := range map[string]int64{
+		"0":                   0,
+		"512":                 512,
+		"64K":                 64 << 10,
+		"500MB":               500 << 20,
+		"2GiB":                2 << 30,
+		"1 tb":                1 << 40,
+		"100B":                100,
+		"  7kb ":              7 << 10,
+		"3P":                  3 << 50,
+		"7EiB":                7 << 60,
+		"9223372036854775807": 1<<63 - 1,
+	} {
+		got, err := parseSize(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want != got {
+			t.Fatalf("%q: expected %d but got %d\n", in, want, got)
+		}
+	}
+}
+
+func TestParseSizeIllegal(t *testing.T) {
+	for _, in := range []string{"", "MB", "-1", "1.5G", "12X", "8E",
+		"9007199254740992K", "9223372036854775808"} {
+		if _, err := parseSize(in); err == nil {
+			t.Fatalf("%q: expected error\n", in)
+		}
+	}
+}
+
+func TestHumanSize(t *testing.T) {
+	for n, want := range map[int64]string{
+		0:          "0B",
+		1023:       "1023B",
+		1024:       "1.0KiB",
+		3 << 50:    "3.0PiB",
+		1<<63 - 1:  "8.0EiB",
+		1536 << 20: "1.5GiB",
+	} {
+		if got := humanSize(n); want != got {
+			t.Fatalf("%d: expected %s but got %s\n", n, want, got)
+		}
+	}
+}
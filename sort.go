@@ -0,0 +1,126 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+)
+
+// sorter orders and pages search results before they are displayed or
+// fetched.
+type sorter struct {
+	key     *string
+	reverse *bool
+	skip    *int
+	limit   *int
+}
+
+func newSorter(fs *flag.FlagSet) *sorter {
+	return &sorter{
+		key: fs.String("sort", "",
+			"Sort results by version, date, size or gav"),
+		reverse: fs.Bool("reverse", false, "Reverse sort order"),
+		skip:    fs.Int("skip", 0, "Skip the first N results"),
+		limit: fs.Int("limit", 0,
+			"Process at most N results, 0 for all"),
+	}
+}
+
+func (a *sorter) validate() error {
+	if *a.skip < 0 {
+		return fmt.Errorf("negative -skip %d", *a.skip)
+	}
+	if *a.limit < 0 {
+		return fmt.Errorf("negative -limit %d", *a.limit)
+	}
+	switch *a.key {
+	case "", "version", "date", "size", "gav":
+		return nil
+	}
+	return fmt.Errorf("unknown sort key %q", *a.key)
+}
+
+// compareGav orders by group, artifact, Maven version, classifier and
+// packaging.
+func compareGav(a, b Gav) int {
+	if c := strings.Compare(a.Group, b.Group); c != 0 {
+		return c
+	}
+	if c := strings.Compare(a.Artifact, b.Artifact); c != 0 {
+		return c
+	}
+	if c := compareVersions(a.Version, b.Version); c != 0 {
+		return c
+	}
+	if c := strings.Compare(a.Classifier, b.Classifier); c != 0 {
+		return c
+	}
+	return strings.Compare(a.Packaging, b.Packaging)
+}
+
+// sort orders results in place, date and size require item metadata.
+func (a *sorter) sort(ls []Fqa, infos infoCache) {
+	var cmp func(x, y Fqa) int
+	switch *a.key {
+	case "":
+		return
+	case "gav":
+		cmp = func(x, y Fqa) int {
+			return compareGav(x.Gav, y.Gav)
+		}
+	case "version":
+		cmp = func(x, y Fqa) int {
+			if c := compareVersions(x.Version, y.Version); c != 0 {
+				return c
+			}
+			return compareGav(x.Gav, y.Gav)
+		}
+	case "date", "size":
+		metric := func(fqa Fqa) int64 {
+			info, err := infos.get(fqa)
+			if err != nil {
+				log.Printf("cannot sort %s: %v\n",
+					fqa.Gav.ConciseNotation(), err)
+				return 0
+			}
+			if *a.key == "date" {
+				return info.Uploaded
+			}
+			return info.Size
+		}
+		cmp = func(x, y Fqa) int {
+			return sign64(metric(x) - metric(y))
+		}
+	}
+	sort.SliceStable(ls, func(i, j int) bool {
+		if *a.reverse {
+			return cmp(ls[j], ls[i]) < 0
+		}
+		return cmp(ls[i], ls[j]) < 0
+	})
+}
+
+func sign64(n int64) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
+
+// page skips the first results and limits the remaining ones, a limit of 0
+// means all.
+func page(ls []Fqa, skip, limit int) []Fqa {
+	if skip >= len(ls) {
+		return nil
+	}
+	ls = ls[skip:]
+	if limit > 0 && limit < len(ls) {
+		ls = ls[:limit]
+	}
+	return ls
+}
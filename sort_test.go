@@ -0,0 +1,89 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSortByVersion(t *testing.T) {
+	key, reverse := "version", false
+	s := sorter{key: &key, reverse: &reverse}
+	var ls []Fqa
+	for _, v := range []string{"1.10", "1.2", "1.0-SNAPSHOT", "1.0"} {
+		ls = append(ls, Fqa{Gav: Gav{Group: "g", Artifact: "a", Version: v}})
+	}
+	s.sort(ls, nil)
+	var got []string
+	for _, a := range ls {
+		got = append(got, a.Version)
+	}
+	want := []string{"1.0-SNAPSHOT", "1.0", "1.2", "1.10"}
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("Expected %v but got %v\n", want, got)
+	}
+
+	reverse = true
+	s.sort(ls, nil)
+	if ls[0].Version != "1.10" {
+		t.Fatalf("Expected 1.10 first but got %v\n", ls[0].Version)
+	}
+}
+
+func TestSortBySize(t *testing.T) {
+	key, reverse := "size", false
+	s := sorter{key: &key, reverse: &reverse}
+	small := Fqa{Gav: Gav{Artifact: "small"}}
+	big := Fqa{Gav: Gav{Artifact: "big"}}
+	infos := infoCache{small: {Size: 1}, big: {Size: 1000}}
+	ls := []Fqa{big, small}
+	s.sort(ls, infos)
+	if ls[0] != small {
+		t.Fatalf("Expected small first but got %+v\n", ls)
+	}
+}
+
+func TestSorterValidate(t *testing.T) {
+	key, reverse := "", false
+	for _, tt := range []struct {
+		skip, limit int
+		ok          bool
+	}{
+		{0, 0, true},
+		{2, 3, true},
+		{-1, 0, false},
+		{0, -1, false},
+	} {
+		s := sorter{&key, &reverse, &tt.skip, &tt.limit}
+		if err := s.validate(); (err == nil) != tt.ok {
+			t.Fatalf("skip %d limit %d: expected ok %t but got %v\n",
+				tt.skip, tt.limit, tt.ok, err)
+		}
+	}
+}
+
+func TestPage(t *testing.T) {
+	ls := make([]Fqa, 5)
+	for i := range ls {
+		ls[i].Version = string(rune('a' + i))
+	}
+	for _, tt := range []struct {
+		skip, limit int
+		want        string
+	}{
+		{0, 0, "abcde"},
+		{0, 3, "abc"},
+		{2, 0, "cde"},
+		{2, 2, "cd"},
+		{4, 3, "e"},
+		{5, 1, ""},
+	} {
+		var got string
+		for _, a := range page(ls, tt.skip, tt.limit) {
+			got += a.Version
+		}
+		if tt.want != got {
+			t.Fatalf("skip %d limit %d: expected %q but got %q\n",
+				tt.skip, tt.limit, tt.want, got)
+		}
+	}
+}
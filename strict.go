@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"path"
+	"strings"
+
+	"github.com/jhinrichsen/nexus-fetch/nexus"
+)
+
+// strict turns warnings into failures, see -strict.
+var strict bool
+
+// warn logs a condition that is suspicious but does not stop the run. With
+// -strict, it returns the condition as an error instead, for the caller to
+// fail with.
+func warn(format string, args ...interface{}) error {
+	msg := fmt.Sprintf(format, args...)
+	if strict {
+		return fmt.Errorf("%s (-strict)", msg)
+	}
+	log.Printf("warning: %s\n", msg)
+	return nil
+}
+
+// checkSidecar compares a download with the checksum files next to it in
+// the repository, if -checksum or -strict ask for it. A missing sidecar
+// is a warning.
+func checkSidecar(fqa Fqa, file string) error {
+	if !strict && checksums == nil || file == "" {
+		return nil
+	}
+	rel := fqa.DefaultLayout()
+	if rv := resolvedVersion(fqa.Gav, file); rv != "" {
+		rel = path.Join(path.Dir(rel), strings.Replace(path.Base(rel),
+			fqa.Version, rv, 1))
+	}
+	cs := verifiedChecksums()
+	if len(cs) == 0 {
+		return fmt.Errorf("no FIPS approved checksum selected to verify %s",
+			rel)
+	}
+	for _, c := range cs {
+		remote, err := remoteChecksum(RepositoryFileURL(
+			fqa.NexusRepository, rel) + "." + c)
+		if nexus.IsNotFound(err) {
+			if err := warn("%s has no .%s checksum sidecar", rel,
+				c); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := verifyChecksum(file, c, remote); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkMetadata warns if the maven-metadata.xml at u does not describe fqa.
+func checkMetadata(u string, fqa Fqa, md mavenMetadata) error {
+	if md.Group != fqa.Group || md.Artifact != fqa.Artifact {
+		if err := warn("%s describes %s:%s", u, md.Group,
+			md.Artifact); err != nil {
+			return err
+		}
+	}
+	if fqa.Version != "" && md.Version != "" && md.Version != fqa.Version {
+		if err := warn("%s describes version %s", u,
+			md.Version); err != nil {
+			return err
+		}
+	}
+	if fqa.Version != "" {
+		return nil
+	}
+	known := make(map[string]bool)
+	for _, v := range md.Versioning.Versions {
+		known[v] = true
+	}
+	for _, v := range []string{md.Versioning.Latest,
+		md.Versioning.Release} {
+		if v != "" && !known[v] {
+			if err := warn("%s names %s which is not among its versions",
+				u, v); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestCheckMetadata(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	fqa := Fqa{Gav: Gav{Group: "com.acme", Artifact: "app"}}
+	md := mavenMetadata{Group: "com.acme", Artifact: "app"}
+	md.Versioning.Versions = []string{"1.0", "1.1"}
+	md.Versioning.Latest = "1.1"
+	md.Versioning.Release = "1.1"
+	checkMetadata("maven-metadata.xml", fqa, md)
+	if buf.Len() != 0 {
+		t.Fatalf("Expected no warning but got %s\n", buf.String())
+	}
+
+	md.Artifact = "lib"
+	md.Versioning.Release = "1.2"
+	checkMetadata("maven-metadata.xml", fqa, md)
+	got := buf.String()
+	for _, want := range []string{"describes com.acme:lib",
+		"names 1.2 which is not among its versions"} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("Expected %s but got %s\n", want, got)
+		}
+	}
+
+	// -strict returns the first warning instead of exiting
+	defer func(s bool) { strict = s }(strict)
+	strict = true
+	err := checkMetadata("maven-metadata.xml", fqa, md)
+	if err == nil || !strings.Contains(err.Error(), "describes com.acme:lib") {
+		t.Fatalf("Expected an error for a wrong artifact but got %v\n", err)
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/jhinrichsen/nexus-fetch/nexus"
+)
+
+// ensureTag creates tag unless it exists.
+func ensureTag(ctx context.Context, inst NexusInstance, tag string) error {
+	err := nexus3(ctx, http.MethodGet, inst, "v1/tags/"+url.PathEscape(tag),
+		nil, nil)
+	if !nexus.IsNotFound(err) {
+		return err
+	}
+	log.Printf("creating tag %s\n", tag)
+	return nexus3(ctx, http.MethodPost, inst, "v1/tags",
+		map[string]interface{}{"name": tag}, nil)
+}
+
+// associateTag tags all components matching gav in the repository of c and
+// returns how many were tagged.
+func associateTag(ctx context.Context, c *Client, tag string, gav Gav) (
+	int, error) {
+	q := c.ComponentQuery(gav)
+	// the search would only find components already tagged
+	q.Del("tag")
+	var res struct {
+		Data struct {
+			Components []interface{} `json:"components associated"`
+		} `json:"data"`
+	}
+	err := nexus3(ctx, http.MethodPost, c.NexusInstance,
+		"v1/tags/associate/"+url.PathEscape(tag)+"?"+q.Encode(), nil, &res)
+	return len(res.Data.Components), err
+}
+
+func tagCommand(args []string) {
+	fs := newCommand("tag", "<tag> <g:a:v>...")
+	nf := newNexusFlags(fs)
+	create := fs.Bool("create", false, "Create the tag if it does not exist")
+	audit := newAuditLog(fs)
+	dryRun := fs.Bool("dry-run", false,
+		"Print what would be tagged without tagging it")
+	fs.Parse(args)
+	if fs.NArg() < 2 {
+		fs.Usage()
+	}
+	tag := fs.Arg(0)
+
+	repo := nf.repo()
+	requireNexus3("tagging")
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt,
+		syscall.SIGTERM)
+	defer stop()
+	if *create && !*dryRun {
+		if err := ensureTag(ctx, repo.NexusInstance, tag); err != nil {
+			fail(err)
+		}
+	}
+	var failures []error
+	for _, arg := range fs.Args()[1:] {
+		gav, err := parseConcise(arg)
+		if err != nil || gav.Group == "" || gav.Artifact == "" ||
+			gav.Version == "" {
+			log.Printf("expected group:artifact:version: %q\n", arg)
+			exit(exitUsage)
+		}
+		if *dryRun {
+			fmt.Printf("would tag %s in %s with %s\n", gav.ConciseNotation(),
+				repo.RepositoryID, tag)
+			continue
+		}
+		n, err := associateTag(ctx, newClient(repo), tag, gav)
+		if err == nil && n == 0 {
+			err = &StatusError{URL: arg, StatusCode: http.StatusNotFound}
+		}
+		if aerr := audit.record(newAuditRecord("tag "+tag, repo, gav, "",
+			err)); aerr != nil {
+			fail(aerr)
+		}
+		if err != nil {
+			log.Printf("%s: %v\n", arg, err)
+			failures = append(failures, err)
+			continue
+		}
+		fmt.Printf("tagged %d components of %s with %s\n", n,
+			gav.ConciseNotation(), tag)
+	}
+	exit(summaryExitCode(fs.NArg()-1, failures))
+}
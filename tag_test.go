@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/jhinrichsen/nexus-fetch/nexus"
+)
+
+func TestAssociateTag(t *testing.T) {
+	var created, associated string
+	inst := fakeNexus(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/nexus/service/rest/v1/tags/build-1234":
+			w.WriteHeader(http.StatusNotFound)
+		case r.URL.Path == "/nexus/service/rest/v1/tags" &&
+			r.Method == http.MethodPost:
+			created = "build-1234"
+			w.Write([]byte(`{"name": "build-1234"}`))
+		case r.URL.Path == "/nexus/service/rest/v1/tags/associate/build-1234":
+			associated = r.URL.RawQuery
+			w.Write([]byte(`{"status": 200, "data": {
+  "components associated": [{"name": "app"}]}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	ctx := context.Background()
+	if err := ensureTag(ctx, inst, "build-1234"); err != nil {
+		t.Fatal(err)
+	}
+	if created != "build-1234" {
+		t.Fatalf("Expected tag to be created\n")
+	}
+	c := newClient(NexusRepository{NexusInstance: inst,
+		RepositoryID: "releases"}, nexus.WithTag("build-1234"))
+	n, err := associateTag(ctx, c, "build-1234", Gav{Group: "com.acme",
+		Artifact: "app", Version: "1.0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Fatalf("Expected 1 component but got %d\n", n)
+	}
+	want := "format=maven2&maven.artifactId=app&maven.groupId=com.acme&" +
+		"repository=releases&version=1.0"
+	if associated != want {
+		t.Fatalf("Expected %s but got %s\n", want, associated)
+	}
+}
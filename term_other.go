@@ -0,0 +1,10 @@
+//go:build !unix
+
+package main
+
+import "errors"
+
+// rawTerminal is not supported on this platform, keys are read as lines.
+func rawTerminal() (func(), error) {
+	return nil, errors.New("no raw terminal mode")
+}
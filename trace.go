@@ -0,0 +1,264 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// traceHTTP logs every request and response, see -trace-http.
+	traceHTTP bool
+	// harFile records every request and response as HAR, see -trace-har.
+	harFile string
+)
+
+func traceFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&traceHTTP, "trace-http", false, "Log method, URL, "+
+		"headers, status, timing and size of every HTTP request")
+	fs.StringVar(&harFile, "trace-har", "",
+		"Record every HTTP request into this HAR file")
+}
+
+// redacted lists headers that are never logged.
+var redacted = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+}
+
+func redact(name, value string) string {
+	if redacted[http.CanonicalHeaderKey(name)] ||
+		strings.Contains(strings.ToLower(name), "token") {
+		return "REDACTED"
+	}
+	return value
+}
+
+// harHeaders returns headers in stable order with secrets redacted.
+func harHeaders(h http.Header) []harNameValue {
+	var nvs []harNameValue
+	for name, values := range h {
+		for _, v := range values {
+			nvs = append(nvs, harNameValue{name, redact(name, v)})
+		}
+	}
+	sort.Slice(nvs, func(i, j int) bool { return nvs[i].Name < nvs[j].Name })
+	return nvs
+}
+
+// tracingTransport logs requests and responses when tracing is enabled.
+type tracingTransport struct {
+	http.RoundTripper
+}
+
+func (a tracingTransport) RoundTrip(req *http.Request) (*http.Response,
+	error) {
+	if !traceHTTP && harFile == "" {
+		return a.RoundTripper.RoundTrip(req)
+	}
+	start := time.Now()
+	if traceHTTP {
+		log.Printf("> %s %s\n", req.Method, req.URL)
+		for _, nv := range harHeaders(req.Header) {
+			log.Printf("> %s: %s\n", nv.Name, nv.Value)
+		}
+	}
+	res, err := a.RoundTripper.RoundTrip(req)
+	if err != nil {
+		if traceHTTP {
+			log.Printf("< %s %s failed after %v: %v\n", req.Method,
+				req.URL, time.Since(start), err)
+		}
+		return res, err
+	}
+	wait := time.Since(start)
+	if traceHTTP {
+		log.Printf("< %s after %v\n", res.Status, wait)
+		for _, nv := range harHeaders(res.Header) {
+			log.Printf("< %s: %s\n", nv.Name, nv.Value)
+		}
+	}
+	res.Body = &tracedBody{ReadCloser: res.Body, close: func(n int64) {
+		total := time.Since(start)
+		if traceHTTP {
+			log.Printf("< %d bytes from %s in %v\n", n, req.URL, total)
+		}
+		if harFile != "" {
+			har.add(req, res, n, start, wait, total)
+		}
+	}}
+	return res, nil
+}
+
+// tracedBody counts the bytes read and reports them once on Close.
+type tracedBody struct {
+	io.ReadCloser
+	n     int64
+	close func(n int64)
+	once  sync.Once
+}
+
+func (a *tracedBody) Read(p []byte) (int, error) {
+	n, err := a.ReadCloser.Read(p)
+	a.n += int64(n)
+	return n, err
+}
+
+func (a *tracedBody) Close() error {
+	err := a.ReadCloser.Close()
+	a.once.Do(func() { a.close(a.n) })
+	return err
+}
+
+// HAR 1.2, reduced to what is needed to analyze requests.
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harEntry struct {
+	StartedDateTime time.Time `json:"startedDateTime"`
+	Time            float64   `json:"time"`
+	Request         struct {
+		Method      string         `json:"method"`
+		URL         string         `json:"url"`
+		HTTPVersion string         `json:"httpVersion"`
+		Headers     []harNameValue `json:"headers"`
+		QueryString []harNameValue `json:"queryString"`
+		Cookies     []harNameValue `json:"cookies"`
+		HeadersSize int            `json:"headersSize"`
+		BodySize    int64          `json:"bodySize"`
+	} `json:"request"`
+	Response struct {
+		Status      int            `json:"status"`
+		StatusText  string         `json:"statusText"`
+		HTTPVersion string         `json:"httpVersion"`
+		Headers     []harNameValue `json:"headers"`
+		Cookies     []harNameValue `json:"cookies"`
+		Content     struct {
+			Size     int64  `json:"size"`
+			MimeType string `json:"mimeType"`
+		} `json:"content"`
+		RedirectURL string `json:"redirectURL"`
+		HeadersSize int    `json:"headersSize"`
+		BodySize    int64  `json:"bodySize"`
+	} `json:"response"`
+	Cache   struct{} `json:"cache"`
+	Timings struct {
+		Send    float64 `json:"send"`
+		Wait    float64 `json:"wait"`
+		Receive float64 `json:"receive"`
+	} `json:"timings"`
+}
+
+// harHeader starts a HAR file, the entries follow.
+const harHeader = `{
+  "log": {
+    "version": "1.2",
+    "creator": {"name": "nexus-fetch", "version": "unknown"},
+    "entries": [
+`
+
+// harFooter completes a HAR file.
+const harFooter = `
+    ]
+  }
+}
+`
+
+// harLog appends entries to the HAR file as they come in, close completes
+// it.
+type harLog struct {
+	mu sync.Mutex
+	f  *os.File
+}
+
+var har harLog
+
+func millisOf(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// add appends an entry to the HAR file, creating it on the first entry.
+func (a *harLog) add(req *http.Request, res *http.Response, n int64,
+	start time.Time, wait, total time.Duration) {
+	var e harEntry
+	e.StartedDateTime = start
+	e.Time = millisOf(total)
+	e.Request.Method = req.Method
+	e.Request.URL = req.URL.String()
+	e.Request.HTTPVersion = req.Proto
+	e.Request.Headers = harHeaders(req.Header)
+	e.Request.QueryString = []harNameValue{}
+	for name, values := range req.URL.Query() {
+		for _, v := range values {
+			e.Request.QueryString = append(e.Request.QueryString,
+				harNameValue{name, v})
+		}
+	}
+	e.Request.Cookies = []harNameValue{}
+	e.Request.HeadersSize = -1
+	e.Request.BodySize = -1
+	e.Response.Status = res.StatusCode
+	e.Response.StatusText = http.StatusText(res.StatusCode)
+	e.Response.HTTPVersion = res.Proto
+	e.Response.Headers = harHeaders(res.Header)
+	e.Response.Cookies = []harNameValue{}
+	e.Response.Content.Size = n
+	e.Response.Content.MimeType = res.Header.Get("Content-Type")
+	e.Response.RedirectURL = res.Header.Get("Location")
+	e.Response.HeadersSize = -1
+	e.Response.BodySize = n
+	e.Timings.Wait = millisOf(wait)
+	e.Timings.Receive = millisOf(total - wait)
+
+	buf, err := json.MarshalIndent(e, "      ", "  ")
+	if err == nil {
+		err = a.write(buf)
+	}
+	if err != nil {
+		log.Printf("cannot write HAR file %s: %v\n", harFile, err)
+	}
+}
+
+func (a *harLog) write(entry []byte) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	sep := ",\n"
+	if a.f == nil {
+		f, err := os.OpenFile(harFile, os.O_CREATE|os.O_TRUNC|os.O_WRONLY,
+			0600)
+		if err != nil {
+			return err
+		}
+		a.f, sep = f, harHeader
+	}
+	_, err := io.WriteString(a.f, sep+"      "+string(entry))
+	return err
+}
+
+// close completes the HAR file, if any request has been recorded.
+func (a *harLog) close() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.f == nil {
+		return
+	}
+	_, err := io.WriteString(a.f, harFooter)
+	if cerr := a.f.Close(); err == nil {
+		err = cerr
+	}
+	a.f = nil
+	if err != nil {
+		log.Printf("cannot write HAR file %s: %v\n", harFile, err)
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestTraceHAR(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter,
+		r *http.Request) {
+		w.Write([]byte("hello"))
+	}))
+	defer ts.Close()
+	harFile = filepath.Join(t.TempDir(), "trace.har")
+	defer func() {
+		harFile = ""
+		har = harLog{}
+	}()
+
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequest(http.MethodGet, ts.URL+"/x?r=releases",
+			nil)
+		req.SetBasicAuth("admin", "secret")
+		res, err := httpClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ioutil.ReadAll(res.Body)
+		res.Body.Close()
+	}
+	har.close()
+
+	buf, err := ioutil.ReadFile(harFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Log struct {
+			Entries []harEntry `json:"entries"`
+		} `json:"log"`
+	}
+	if err := json.Unmarshal(buf, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Log.Entries) != 2 {
+		t.Fatalf("Expected 2 entries but got %d\n", len(doc.Log.Entries))
+	}
+	e := doc.Log.Entries[0]
+	if e.Response.Status != 200 || e.Response.Content.Size != 5 {
+		t.Fatalf("Unexpected response %+v\n", e.Response)
+	}
+	for _, h := range e.Request.Headers {
+		if h.Name == "Authorization" && h.Value != "REDACTED" {
+			t.Fatalf("Expected redacted credentials but got %s\n",
+				h.Value)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// pinnedAddrs maps host:port to ip:port, see -resolve.
+var pinnedAddrs = make(map[string]string)
+
+// hostHeader overrides the Host header of all requests, see -host-header.
+var hostHeader string
+
+// baseTransport is the transport of httpClient, dialing pinned addresses.
+var baseTransport = newBaseTransport()
+
+func newBaseTransport() *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	var d net.Dialer
+	t.DialContext = func(ctx context.Context, network, addr string) (
+		net.Conn, error) {
+		if pinned, ok := pinnedAddrs[addr]; ok {
+			addr = pinned
+		}
+		return d.DialContext(ctx, network, addr)
+	}
+	return t
+}
+
+// hostTransport applies the Host header override.
+type hostTransport struct {
+	http.RoundTripper
+}
+
+func (a hostTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if hostHeader != "" {
+		req = req.Clone(req.Context())
+		req.Host = hostHeader
+	}
+	return a.RoundTripper.RoundTrip(req)
+}
+
+// limiter spaces requests, see -rps.
+var limiter rateLimiter
+
+// rateLimiter lets requests pass at most every interval, its zero value
+// does not limit.
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+// wait blocks until the next request may be sent.
+func (a *rateLimiter) wait(ctx context.Context) error {
+	a.mu.Lock()
+	if a.interval == 0 {
+		a.mu.Unlock()
+		return nil
+	}
+	t := a.next
+	if now := time.Now(); t.Before(now) {
+		t = now
+	}
+	a.next = t.Add(a.interval)
+	a.mu.Unlock()
+
+	timer := time.NewTimer(time.Until(t))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (a *rateLimiter) Set(s string) error {
+	rps, err := strconv.ParseFloat(s, 64)
+	if err != nil || rps < 0 {
+		return fmt.Errorf("want a positive number of requests per "+
+			"second but got %q", s)
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.interval = 0
+	if rps > 0 {
+		a.interval = time.Duration(float64(time.Second) / rps)
+	}
+	return nil
+}
+
+func (a *rateLimiter) String() string {
+	return ""
+}
+
+// limitedTransport waits for the rate limiter before each request.
+type limitedTransport struct {
+	http.RoundTripper
+}
+
+func (a limitedTransport) RoundTrip(req *http.Request) (*http.Response,
+	error) {
+	if err := limiter.wait(req.Context()); err != nil {
+		return nil, err
+	}
+	return a.RoundTripper.RoundTrip(req)
+}
+
+// transportFlags registers flags changing how Nexus is reached.
+func transportFlags(fs *flag.FlagSet) {
+	fs.Var(&limiter, "rps", "Send at most this many requests per "+
+		"second, 0 for no limit")
+	fs.Func("resolve", "Connect to ip instead of resolving host, "+
+		"format host:port:ip, may be repeated", pinAddr)
+	fs.Func("host-header", "Send this Host header, also used to verify "+
+		"the TLS certificate", setHostHeader)
+	fs.Func("sign-hmac", "Sign requests with HMAC-SHA256 for an API "+
+		"gateway, format key-id:secret", setHMAC)
+	fs.BoolFunc("no-session", "Authenticate every request instead of "+
+		"reusing session cookies", func(string) error {
+		disableSessions()
+		return nil
+	})
+	fs.BoolVar(&anonymous, "anonymous", false, "Never send credentials, "+
+		"not even to retry requests rejected with 401")
+}
+
+// pinAddr parses host:port:ip as used by curl.
+func pinAddr(s string) error {
+	parts := strings.SplitN(s, ":", 3)
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" ||
+		parts[2] == "" {
+		return fmt.Errorf("want host:port:ip but got %q", s)
+	}
+	ip := strings.Trim(parts[2], "[]")
+	if net.ParseIP(ip) == nil {
+		return fmt.Errorf("not an IP address: %q", parts[2])
+	}
+	pinnedAddrs[net.JoinHostPort(parts[0], parts[1])] =
+		net.JoinHostPort(ip, parts[1])
+	return nil
+}
+
+func setHostHeader(s string) error {
+	hostHeader = s
+	name := s
+	if h, _, err := net.SplitHostPort(s); err == nil {
+		name = h
+	}
+	if baseTransport.TLSClientConfig == nil {
+		baseTransport.TLSClientConfig = &tls.Config{}
+	}
+	baseTransport.TLSClientConfig.ServerName = name
+	return nil
+}
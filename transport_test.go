@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestResolveAndHostHeader(t *testing.T) {
+	var host string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter,
+		r *http.Request) {
+		host = r.Host
+	}))
+	defer ts.Close()
+	defer func() {
+		pinnedAddrs = make(map[string]string)
+		hostHeader = ""
+		baseTransport.TLSClientConfig.ServerName = ""
+	}()
+	_, port, _ := net.SplitHostPort(ts.Listener.Addr().String())
+
+	if err := pinAddr("nexus.invalid:" + port + ":127.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	res, err := httpClient.Get("http://nexus.invalid:" + port + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if host != "nexus.invalid:"+port {
+		t.Fatalf("Expected pinned host but got %s\n", host)
+	}
+
+	if err := setHostHeader("green.example.com"); err != nil {
+		t.Fatal(err)
+	}
+	res, err = httpClient.Get(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if host != "green.example.com" {
+		t.Fatalf("Expected host header override but got %s\n", host)
+	}
+	if baseTransport.TLSClientConfig.ServerName != "green.example.com" {
+		t.Fatalf("Expected TLS server name to follow host header\n")
+	}
+}
+
+func TestPinAddrRejects(t *testing.T) {
+	for _, s := range []string{"nexus", "nexus:8081", "nexus:8081:nohost",
+		":8081:127.0.0.1"} {
+		if err := pinAddr(s); err == nil {
+			t.Fatalf("%s: Expected error\n", s)
+		}
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	var l rateLimiter
+	if err := l.Set("50"); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		if err := l.wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the first request passes immediately
+	if d := time.Since(start); d < 4*20*time.Millisecond {
+		t.Fatalf("Expected 5 requests to take at least 80ms but took %v\n",
+			d)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	l.Set("0.001")
+	l.wait(ctx)
+	if err := l.wait(ctx); err != context.Canceled {
+		t.Fatalf("Expected cancellation but got %v\n", err)
+	}
+	if err := l.Set("-1"); err == nil {
+		t.Fatalf("Expected error for negative rate\n")
+	}
+}
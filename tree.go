@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+)
+
+// treeNode is a group, artifact or version of a tree view.
+type treeNode struct {
+	name     string
+	children map[string]*treeNode
+}
+
+func (a *treeNode) child(name string) *treeNode {
+	if a.children == nil {
+		a.children = make(map[string]*treeNode)
+	}
+	c, ok := a.children[name]
+	if !ok {
+		c = &treeNode{name: name}
+		a.children[name] = c
+	}
+	return c
+}
+
+// sorted returns children by name, versions in Maven order.
+func (a *treeNode) sorted(versions bool) []*treeNode {
+	var cs []*treeNode
+	for _, c := range a.children {
+		cs = append(cs, c)
+	}
+	sort.Slice(cs, func(i, j int) bool {
+		if versions {
+			return compareVersions(cs[i].name, cs[j].name) < 0
+		}
+		return cs[i].name < cs[j].name
+	})
+	return cs
+}
+
+// renderTree writes groups, their artifacts and their versions as a tree.
+func renderTree(w io.Writer, ls []Fqa) {
+	var root treeNode
+	for _, a := range ls {
+		root.child(a.Group).child(a.Artifact).child(a.Version)
+	}
+	for _, g := range root.sorted(false) {
+		fmt.Fprintln(w, g.name)
+		as := g.sorted(false)
+		for i, a := range as {
+			branch, indent := "├── ", "│   "
+			if i == len(as)-1 {
+				branch, indent = "└── ", "    "
+			}
+			fmt.Fprintf(w, "%s%s\n", branch, a.name)
+			vs := a.sorted(true)
+			for j, v := range vs {
+				branch := "├── "
+				if j == len(vs)-1 {
+					branch = "└── "
+				}
+				fmt.Fprintf(w, "%s%s%s\n", indent, branch, v.name)
+			}
+		}
+	}
+}
+
+func treeCommand(args []string) {
+	fs := newCommand("tree", "-group <group> [-artifact <artifact>]")
+	nf := newNexusFlags(fs)
+	srCache.flags(fs)
+	group := fs.String("group", "", "Maven group, may end in *")
+	artifact := fs.String("artifact", "", "Maven artifact")
+	fs.Parse(args)
+	if *group == "" || fs.NArg() != 0 {
+		fs.Usage()
+	}
+
+	ls, err := searchAll(nf.repos(), Gav{Group: *group, Artifact: *artifact})
+	if err != nil {
+		fail(err)
+	}
+	if len(ls) == 0 {
+		log.Printf("nothing found for group %s\n", *group)
+		exit(exitNotFound)
+	}
+	renderTree(os.Stdout, ls)
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderTree(t *testing.T) {
+	var ls []Fqa
+	for _, c := range []string{"com.acme:lib:1.0", "com.acme:app:1.10",
+		"com.acme:app:1.2", "com.acme:app:1.2@pom", "com.other:x:1"} {
+		gav, err := parseConcise(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ls = append(ls, Fqa{Gav: gav})
+	}
+	var sb strings.Builder
+	renderTree(&sb, ls)
+	want := `com.acme
+├── app
+│   ├── 1.2
+│   └── 1.10
+└── lib
+    └── 1.0
+com.other
+└── x
+    └── 1
+`
+	if sb.String() != want {
+		t.Fatalf("Expected\n%s but got\n%s\n", want, sb.String())
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+
+	"github.com/jhinrichsen/nexus-fetch/nexus"
+)
+
+// verifyReport counts the outcome of comparing a local tree against Nexus.
+type verifyReport struct {
+	ok, missing, extra, mismatch int
+}
+
+// verifyTree walks a local Maven layout directory and compares every file
+// against the same path in repo. Missing files are reported for each local
+// directory that contains files.
+func verifyTree(repo NexusRepository, root string, w io.Writer) (
+	verifyReport, error) {
+	var rep verifyReport
+	// sidecars of the first selected checksum tell which files exist
+	cs := verifiedChecksums()
+	if len(cs) == 0 {
+		return rep, fmt.Errorf("no FIPS approved checksum selected")
+	}
+	c := cs[0]
+	dirs := make(map[string]map[string]bool)
+	err := filepath.Walk(root, func(p string, fi os.FileInfo, err error) error {
+		if err != nil || fi.IsDir() || nexus.Ignored(fi.Name()) {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		dir := path.Dir(rel)
+		if dirs[dir] == nil {
+			dirs[dir] = make(map[string]bool)
+		}
+		dirs[dir][fi.Name()] = true
+
+		local, err := digestFile(p, checksumAlgorithms[c]())
+		if err != nil {
+			return err
+		}
+		remote, err := remoteChecksum(RepositoryFileURL(repo, rel) + "." + c)
+		switch {
+		case nexus.IsNotFound(err):
+			rep.extra++
+			fmt.Fprintf(w, "extra    %s\n", rel)
+		case err != nil:
+			return err
+		case remote != local:
+			rep.mismatch++
+			fmt.Fprintf(w, "mismatch %s\n", rel)
+		default:
+			rep.ok++
+		}
+		return nil
+	})
+	if err != nil {
+		return rep, err
+	}
+
+	var ds []string
+	for dir := range dirs {
+		ds = append(ds, dir)
+	}
+	sort.Strings(ds)
+	for _, dir := range ds {
+		items, err := listContent(repo, dir)
+		if nexus.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return rep, err
+		}
+		for _, item := range items {
+			if item.Leaf && !nexus.Ignored(item.Name) && !dirs[dir][item.Name] {
+				rep.missing++
+				fmt.Fprintf(w, "missing  %s\n", path.Join(dir, item.Name))
+			}
+		}
+	}
+	return rep, nil
+}
+
+func verifyTreeCommand(args []string) {
+	fs := newCommand("verify-tree", "<directory in Maven layout>")
+	nf := newNexusFlags(fs)
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+	}
+	rep, err := verifyTree(nf.repo(), fs.Arg(0), os.Stdout)
+	if err != nil {
+		fail(err)
+	}
+	log.Printf("%d ok, %d missing, %d extra, %d mismatching\n",
+		rep.ok, rep.missing, rep.extra, rep.mismatch)
+	switch {
+	case rep.mismatch > 0:
+		exit(exitIntegrity)
+	case rep.missing > 0 || rep.extra > 0:
+		exit(exitError)
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jhinrichsen/nexus-fetch/nexusfetchtest"
+)
+
+func TestVerifyTree(t *testing.T) {
+	_, inst := newFakeNexus(t,
+		nexusfetchtest.Artifact{Repository: "releases", Group: "g",
+			Artifact: "a", Version: "1.0", Content: []byte("ok")},
+		nexusfetchtest.Artifact{Repository: "releases", Group: "g",
+			Artifact: "a", Version: "1.0", Extension: "pom",
+			Content: []byte("<project/>")},
+		nexusfetchtest.Artifact{Repository: "releases", Group: "g",
+			Artifact: "a", Version: "1.1", Content: []byte("remote")})
+	root := t.TempDir()
+	for name, content := range map[string]string{
+		"g/a/1.0/a-1.0.jar":      "ok",
+		"g/a/1.0/a-1.0.jar.sha1": "ignored",
+		"g/a/1.0/a-1.0-x.jar":    "extra",
+		"g/a/1.1/a-1.1.jar":      "local",
+	} {
+		f := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(f), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(f, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var sb strings.Builder
+	rep, err := verifyTree(NexusRepository{NexusInstance: inst,
+		RepositoryID: "releases"}, root, &sb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := verifyReport{ok: 1, missing: 1, extra: 1, mismatch: 1}
+	if want != rep {
+		t.Fatalf("Expected %+v but got %+v\n%s", want, rep, sb.String())
+	}
+	for _, line := range []string{"missing  g/a/1.0/a-1.0.pom",
+		"extra    g/a/1.0/a-1.0-x.jar", "mismatch g/a/1.1/a-1.1.jar"} {
+		if !strings.Contains(sb.String(), line) {
+			t.Fatalf("Expected %q in\n%s", line, sb.String())
+		}
+	}
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+// compareVersions compares two Maven versions the way Maven's
+// ComparableVersion does, returning -1, 0 or 1. Numbers compare
+// numerically, and qualifiers are ordered
+// alpha < beta < milestone < rc < snapshot < (release) < sp < others.
+func compareVersions(a, b string) int {
+	return parseVersion(a).compare(parseVersion(b))
+}
+
+// qualifiers in ascending order, the empty string is a release
+var qualifiers = []string{"alpha", "beta", "milestone", "rc", "snapshot", "",
+	"sp"}
+
+var qualifierAliases = map[string]string{
+	"ga":      "",
+	"final":   "",
+	"release": "",
+	"cr":      "rc",
+}
+
+// releaseIndex is the comparable value of a plain release.
+var releaseIndex = strconv.Itoa(indexOf(qualifiers, ""))
+
+func indexOf(ss []string, s string) int {
+	for i := range ss {
+		if ss[i] == s {
+			return i
+		}
+	}
+	return -1
+}
+
+func comparableQualifier(q string) string {
+	if i := indexOf(qualifiers, q); i >= 0 {
+		return strconv.Itoa(i)
+	}
+	// unknown qualifiers sort lexically after all known ones
+	return strconv.Itoa(len(qualifiers)) + "-" + q
+}
+
+// versionItem is a number, a qualifier or a nested list of items.
+type versionItem interface {
+	// compare returns the order against other, which may be nil
+	compare(other versionItem) int
+	isNull() bool
+}
+
+type intItem string // decimal digits without leading zeros
+
+type stringItem string
+
+type listItem struct {
+	items []versionItem
+}
+
+func newIntItem(s string) intItem {
+	return intItem(strings.TrimLeft(s, "0"))
+}
+
+func newStringItem(s string, followedByDigit bool) stringItem {
+	if followedByDigit && len(s) == 1 {
+		switch s {
+		case "a":
+			s = "alpha"
+		case "b":
+			s = "beta"
+		case "m":
+			s = "milestone"
+		}
+	}
+	if alias, ok := qualifierAliases[s]; ok {
+		s = alias
+	}
+	return stringItem(s)
+}
+
+func (a intItem) isNull() bool {
+	return a == ""
+}
+
+func (a intItem) compare(other versionItem) int {
+	switch o := other.(type) {
+	case nil:
+		if a.isNull() {
+			return 0
+		}
+		return 1
+	case intItem:
+		if len(a) != len(o) {
+			return sign(len(a) - len(o))
+		}
+		return strings.Compare(string(a), string(o))
+	}
+	// numbers are newer than qualifiers and sublists
+	return 1
+}
+
+func (a stringItem) isNull() bool {
+	return comparableQualifier(string(a)) == releaseIndex
+}
+
+func (a stringItem) compare(other versionItem) int {
+	switch o := other.(type) {
+	case nil:
+		return strings.Compare(comparableQualifier(string(a)), releaseIndex)
+	case stringItem:
+		return strings.Compare(comparableQualifier(string(a)),
+			comparableQualifier(string(o)))
+	}
+	return -1
+}
+
+func (a *listItem) isNull() bool {
+	return len(a.items) == 0
+}
+
+func (a *listItem) compare(other versionItem) int {
+	switch o := other.(type) {
+	case nil:
+		if len(a.items) == 0 {
+			return 0
+		}
+		return a.items[0].compare(nil)
+	case intItem:
+		return -1
+	case stringItem:
+		return 1
+	case *listItem:
+		for i := 0; i < len(a.items) || i < len(o.items); i++ {
+			var l, r versionItem
+			if i < len(a.items) {
+				l = a.items[i]
+			}
+			if i < len(o.items) {
+				r = o.items[i]
+			}
+			var c int
+			if l == nil {
+				c = -r.compare(nil)
+			} else {
+				c = l.compare(r)
+			}
+			if c != 0 {
+				return c
+			}
+		}
+	}
+	return 0
+}
+
+// normalize removes trailing null items such as 0, "final" or "ga".
+func (a *listItem) normalize() {
+	for i := len(a.items) - 1; i >= 0; i-- {
+		if a.items[i].isNull() {
+			a.items = append(a.items[:i], a.items[i+1:]...)
+		} else if _, ok := a.items[i].(*listItem); !ok {
+			break
+		}
+	}
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func parseItem(digit bool, s string) versionItem {
+	if digit {
+		return newIntItem(s)
+	}
+	return newStringItem(s, false)
+}
+
+// parseVersion splits a version at '.', '-' and at transitions between
+// digits and letters, the latter two starting a new sublist.
+func parseVersion(version string) *listItem {
+	v := strings.ToLower(version)
+	root := &listItem{}
+	list := root
+	stack := []*listItem{root}
+	sublist := func() {
+		l := &listItem{}
+		list.items = append(list.items, l)
+		list = l
+		stack = append(stack, l)
+	}
+	digit := false
+	start := 0
+	for i := 0; i < len(v); i++ {
+		c := v[i]
+		switch {
+		case c == '.' || c == '-':
+			if i == start {
+				list.items = append(list.items, newIntItem("0"))
+			} else {
+				list.items = append(list.items, parseItem(digit, v[start:i]))
+			}
+			start = i + 1
+			if c == '-' {
+				sublist()
+			}
+		case isDigit(c):
+			if !digit && i > start {
+				list.items = append(list.items,
+					newStringItem(v[start:i], true))
+				start = i
+				sublist()
+			}
+			digit = true
+		default:
+			if digit && i > start {
+				list.items = append(list.items, parseItem(true, v[start:i]))
+				start = i
+				sublist()
+			}
+			digit = false
+		}
+	}
+	if len(v) > start {
+		list.items = append(list.items, parseItem(digit, v[start:]))
+	}
+	for i := len(stack) - 1; i >= 0; i-- {
+		stack[i].normalize()
+	}
+	return root
+}
+
+// inRange reports whether a Maven version range such as [1.0,2.0) or
+// (,1.0],[1.2,) contains v. Malformed ranges contain every version.
+func inRange(spec, v string) bool {
+	rest := strings.TrimSpace(spec)
+	for rest != "" {
+		end := strings.IndexAny(rest, "])")
+		if end < 0 || rest[0] != '[' && rest[0] != '(' {
+			return true
+		}
+		left, right, bounds := rest[0], rest[end], rest[1:end]
+		rest = strings.TrimLeft(rest[end+1:], ", ")
+		i := strings.Index(bounds, ",")
+		if i < 0 {
+			// [1.0] is exactly 1.0
+			if compareVersions(strings.TrimSpace(bounds), v) == 0 {
+				return true
+			}
+			continue
+		}
+		lo := strings.TrimSpace(bounds[:i])
+		hi := strings.TrimSpace(bounds[i+1:])
+		if lo != "" {
+			if c := compareVersions(v, lo); c < 0 || c == 0 && left == '(' {
+				continue
+			}
+		}
+		if hi != "" {
+			if c := compareVersions(v, hi); c > 0 || c == 0 && right == ')' {
+				continue
+			}
+		}
+		return true
+	}
+	return false
+}
@@ -0,0 +1,73 @@
+package main
+
+import "testing"
+
+// ascending versions taken from Maven's ComparableVersionTest
+var versionsQualifier = []string{"1-alpha2snapshot", "1-alpha2",
+	"1-alpha-123", "1-beta-2", "1-beta123", "1-m2", "1-m11", "1-rc", "1-cr2",
+	"1-rc123", "1-SNAPSHOT", "1", "1-sp", "1-sp2", "1-sp123", "1-abc",
+	"1-def", "1-pom-1", "1-1-snapshot", "1-1", "1-2", "1-123"}
+
+var versionsNumber = []string{"2.0", "2-1", "2.0.a", "2.0.2", "2.0.123",
+	"2.1.0", "2.1-a", "2.1b", "2.1-c", "2.1-1", "2.1.0.1", "2.2", "2.123",
+	"11.a2", "11.a11", "11.b2", "11.b11", "11.m2", "11.m11", "11", "11.a",
+	"11b", "11c", "11m"}
+
+func testVersionsOrder(t *testing.T, vs []string) {
+	for i := range vs {
+		for j := range vs {
+			want := sign(i - j)
+			if got := compareVersions(vs[i], vs[j]); want != got {
+				t.Fatalf("compare(%s, %s): expected %d but got %d\n",
+					vs[i], vs[j], want, got)
+			}
+		}
+	}
+}
+
+func TestCompareVersionsQualifier(t *testing.T) {
+	testVersionsOrder(t, versionsQualifier)
+}
+
+func TestCompareVersionsNumber(t *testing.T) {
+	testVersionsOrder(t, versionsNumber)
+}
+
+func TestCompareVersionsEqual(t *testing.T) {
+	for _, pair := range [][2]string{
+		{"1", "1.0.0"}, {"1-ga", "1"}, {"1.0-final", "1"},
+		{"1-release", "1"}, {"1a1", "1-alpha-1"}, {"1-cr1", "1-rc-1"},
+		{"1.0.0-SNAPSHOT", "1-snapshot"}, {"01", "1"},
+	} {
+		if got := compareVersions(pair[0], pair[1]); got != 0 {
+			t.Fatalf("compare(%s, %s): expected 0 but got %d\n",
+				pair[0], pair[1], got)
+		}
+	}
+}
+
+func TestInRange(t *testing.T) {
+	for _, tt := range []struct {
+		spec, v string
+		want    bool
+	}{
+		{"[1.0,2.0)", "1.0", true},
+		{"[1.0,2.0)", "1.5", true},
+		{"[1.0,2.0)", "2.0", false},
+		{"(1.0,2.0]", "1.0", false},
+		{"(1.0,2.0]", "2.0", true},
+		{"[1.0,)", "9", true},
+		{"(,1.0]", "0.9", true},
+		{"(,1.0]", "1.1", false},
+		{"[1.0]", "1", true},
+		{"[1.0]", "1.1", false},
+		{"(,1.0],[1.2,)", "1.1", false},
+		{"(,1.0],[1.2,)", "1.3", true},
+		{"${lib.version}", "1.0", true},
+	} {
+		if got := inRange(tt.spec, tt.v); tt.want != got {
+			t.Fatalf("%s contains %s: expected %t but got %t\n",
+				tt.spec, tt.v, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+)
+
+// ossIndexURL is the component report endpoint of Sonatype OSS Index.
+const ossIndexURL = "https://ossindex.sonatype.org/api/v3/component-report"
+
+// ossIndexBatch is the maximum number of coordinates per request.
+const ossIndexBatch = 128
+
+// componentReport is the OSS Index answer for a single purl, also the
+// format of offline advisory databases.
+type componentReport struct {
+	Coordinates     string          `json:"coordinates"`
+	Vulnerabilities []vulnerability `json:"vulnerabilities"`
+}
+
+type vulnerability struct {
+	ID        string  `json:"id"`
+	Title     string  `json:"title"`
+	CVE       string  `json:"cve"`
+	CVSSScore float64 `json:"cvssScore"`
+	Reference string  `json:"reference"`
+}
+
+// name prefers the CVE over the OSS Index ID.
+func (a vulnerability) name() string {
+	if a.CVE != "" {
+		return a.CVE
+	}
+	return a.ID
+}
+
+// vulnFlags looks up known vulnerabilities of resolved artifacts.
+type vulnFlags struct {
+	enabled *bool
+	db      *string
+	url     *string
+	user    *string
+	failAt  *float64
+}
+
+func newVulnFlags(fs *flag.FlagSet) *vulnFlags {
+	return &vulnFlags{
+		enabled: fs.Bool("check-vulns", false, "Report known "+
+			"vulnerabilities of all artifacts from OSS Index"),
+		db: fs.String("vuln-db", "", "Offline advisory database in OSS "+
+			"Index component report format instead of OSS Index"),
+		url: fs.String("oss-index-url", ossIndexURL,
+			"OSS Index component report endpoint"),
+		user: fs.String("oss-index-user", "", "OSS Index credentials, "+
+			"format user:token"),
+		failAt: fs.Float64("fail-on-cvss", 0, "Fail if a vulnerability "+
+			"has at least this CVSS score, 0 to only report"),
+	}
+}
+
+// reports returns the component reports for purls.
+func (a *vulnFlags) reports(ctx context.Context, purls []string) (
+	[]componentReport, error) {
+	if *a.db != "" {
+		return readAdvisories(*a.db, purls)
+	}
+	var rs []componentReport
+	for i := 0; i < len(purls); i += ossIndexBatch {
+		j := i + ossIndexBatch
+		if j > len(purls) {
+			j = len(purls)
+		}
+		batch, err := a.query(ctx, purls[i:j])
+		if err != nil {
+			return nil, err
+		}
+		rs = append(rs, batch...)
+	}
+	return rs, nil
+}
+
+func (a *vulnFlags) query(ctx context.Context, purls []string) (
+	[]componentReport, error) {
+	body, err := json.Marshal(map[string][]string{"coordinates": purls})
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("querying %s for %d components\n", *a.url, len(purls))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, *a.url,
+		bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if i := strings.Index(*a.user, ":"); i > 0 {
+		req.SetBasicAuth((*a.user)[:i], (*a.user)[i+1:])
+	}
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, &StatusError{URL: *a.url, StatusCode: res.StatusCode}
+	}
+	var rs []componentReport
+	err = json.NewDecoder(res.Body).Decode(&rs)
+	return rs, err
+}
+
+// readAdvisories returns the reports of an offline database for purls.
+func readAdvisories(filename string, purls []string) ([]componentReport,
+	error) {
+	buf, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var all []componentReport
+	if err := json.Unmarshal(buf, &all); err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	wanted := make(map[string]bool)
+	for _, p := range purls {
+		wanted[p] = true
+	}
+	var rs []componentReport
+	for _, r := range all {
+		if wanted[r.Coordinates] {
+			rs = append(rs, r)
+		}
+	}
+	return rs, nil
+}
+
+// check reports vulnerabilities of gavs to w and fails if one reaches
+// -fail-on-cvss.
+func (a *vulnFlags) check(ctx context.Context, w io.Writer,
+	gavs []Gav) error {
+	if !*a.enabled {
+		return nil
+	}
+	var purls []string
+	names := make(map[string]string)
+	for _, gav := range gavs {
+		p := sbomComponent{Gav: gav.Normalize()}.purl()
+		if names[p] == "" {
+			purls = append(purls, p)
+		}
+		names[p] = gav.ConciseNotation()
+	}
+	rs, err := a.reports(ctx, purls)
+	if err != nil {
+		return err
+	}
+	sort.Slice(rs, func(i, j int) bool {
+		return rs[i].Coordinates < rs[j].Coordinates
+	})
+	var n, failed int
+	for _, r := range rs {
+		for _, v := range r.Vulnerabilities {
+			n++
+			name := names[r.Coordinates]
+			if name == "" {
+				name = r.Coordinates
+			}
+			fmt.Fprintf(w, "%s  %s  %.1f  %s\n", name, v.name(),
+				v.CVSSScore, v.Title)
+			if *a.failAt > 0 && v.CVSSScore >= *a.failAt {
+				failed++
+			}
+		}
+	}
+	log.Printf("%d known vulnerabilities in %d components\n", n,
+		len(purls))
+	if failed > 0 {
+		return fmt.Errorf("%d vulnerabilities with CVSS score of at "+
+			"least %.1f", failed, *a.failAt)
+	}
+	return nil
+}
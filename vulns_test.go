@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckVulns(t *testing.T) {
+	lib := "pkg:maven/com.acme/lib@1.0"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter,
+		r *http.Request) {
+		var q struct {
+			Coordinates []string `json:"coordinates"`
+		}
+		json.NewDecoder(r.Body).Decode(&q)
+		var rs []componentReport
+		for _, c := range q.Coordinates {
+			r := componentReport{Coordinates: c}
+			if c == lib {
+				r.Vulnerabilities = []vulnerability{{ID: "x",
+					CVE: "CVE-2024-0001", CVSSScore: 7.5, Title: "RCE"}}
+			}
+			rs = append(rs, r)
+		}
+		json.NewEncoder(w).Encode(rs)
+	}))
+	defer ts.Close()
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	vf := newVulnFlags(fs)
+	fs.Parse([]string{"-check-vulns", "-oss-index-url", ts.URL})
+	gavs := []Gav{{Group: "com.acme", Artifact: "lib", Version: "1.0"},
+		{Group: "com.acme", Artifact: "app", Version: "1.0"}}
+	var buf bytes.Buffer
+	if err := vf.check(context.Background(), &buf, gavs); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "com.acme:lib:1.0  CVE-2024-0001  "+
+		"7.5  RCE") {
+		t.Fatalf("Expected CVE but got %q\n", buf.String())
+	}
+
+	fs.Parse([]string{"-fail-on-cvss", "7"})
+	if err := vf.check(context.Background(), &buf, gavs); err == nil {
+		t.Fatalf("Expected failure above CVSS 7 but got nil\n")
+	}
+
+	// offline database
+	db := filepath.Join(t.TempDir(), "advisories.json")
+	err := os.WriteFile(db, []byte(`[{"coordinates":"`+lib+`",`+
+		`"vulnerabilities":[{"id":"x","cvssScore":9.8}]}]`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs.Parse([]string{"-vuln-db", db, "-oss-index-url", "http://invalid/"})
+	buf.Reset()
+	if err := vf.check(context.Background(), &buf, gavs); err == nil {
+		t.Fatalf("Expected failure above CVSS 7 but got nil\n")
+	}
+	if !strings.Contains(buf.String(), "9.8") {
+		t.Fatalf("Expected offline advisory but got %q\n", buf.String())
+	}
+}
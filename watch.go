@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// newVersions returns all versions of cur that are not part of old.
+func newVersions(old, cur mavenMetadata) []string {
+	known := make(map[string]bool)
+	for _, v := range old.Versioning.Versions {
+		known[v] = true
+	}
+	var vs []string
+	for _, v := range cur.Versioning.Versions {
+		if !known[v] {
+			vs = append(vs, v)
+		}
+	}
+	return vs
+}
+
+// watch polls maven-metadata.xml and calls found for every version that
+// appears after the first poll. If fqa has a (snapshot) version, every new
+// build of that version is reported instead.
+// Polling errors are logged and retried on the next tick, watching ends when
+// ctx is done.
+func watch(ctx context.Context, fqa Fqa, interval time.Duration, m *metrics,
+	found func(Fqa)) {
+	var last *mavenMetadata
+	for {
+		start := time.Now()
+		md, err := fetchMetadata(fqa)
+		m.observe(time.Since(start))
+		if err != nil {
+			m.upstreamError()
+			log.Printf("cannot poll metadata: %v\n", err)
+		} else {
+			if last != nil {
+				for _, a := range changes(fqa, *last, md) {
+					found(a)
+				}
+			}
+			last = &md
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+func changes(fqa Fqa, old, cur mavenMetadata) []Fqa {
+	var as []Fqa
+	if fqa.Version != "" {
+		if cur.build() != old.build() {
+			as = append(as, fqa)
+		}
+		return as
+	}
+	for _, v := range newVersions(old, cur) {
+		a := fqa
+		a.Version = v
+		as = append(as, a)
+	}
+	return as
+}
+
+func watchCommand(args []string) {
+	fs := newCommand("watch", "<group:artifact[:version][@packaging]>")
+	nf := newNexusFlags(fs)
+	var (
+		interval = fs.Duration("interval", 5*time.Minute,
+			"Polling interval")
+		notifyOnly = fs.Bool("notify-only", false,
+			"Only report new versions, do not download them")
+		outputDir = fs.String("outputDir", ".", "Download directory")
+		nt        = newNotifier(fs)
+		dryRun    = fs.Bool("dry-run", false,
+			"Print what would be fetched without downloading")
+		listen = fs.String("metrics-listen", "",
+			"Expose Prometheus metrics on this address, e.g. :9090")
+	)
+	mdCache.flags(fs)
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+	}
+	if err := nt.validate(); err != nil {
+		log.Println(err)
+		fs.Usage()
+	}
+	gav, err := parseConcise(fs.Arg(0))
+	if err != nil {
+		log.Printf("%v\n", err)
+		exit(2)
+	}
+	if gav.Group == "" || gav.Artifact == "" {
+		log.Printf("watch requires group and artifact: %q\n", fs.Arg(0))
+		exit(exitUsage)
+	}
+
+	m := newMetrics()
+	if *listen != "" {
+		go func() {
+			fail(http.ListenAndServe(*listen, m))
+		}()
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt,
+		syscall.SIGTERM)
+	defer stop()
+	log.Printf("watching %s every %v\n", gav.ConciseNotation(), *interval)
+	fqa := Fqa{NexusRepository: nf.repo(), Gav: gav}
+	watch(ctx, fqa, *interval, m, func(a Fqa) {
+		fmt.Println(a.Gav.ConciseNotation())
+		if *notifyOnly {
+			nt.notify(newNotification("detected", a, ""))
+			return
+		}
+		if *dryRun {
+			planFetch(ctx, mavenURL("content", a), *outputDir, "", a.Gav)
+			return
+		}
+		res, err := content(ctx, a)
+		if err != nil {
+			log.Printf("cannot fetch %s: %v\n", a.Gav.ConciseNotation(), err)
+			m.upstreamError()
+			return
+		}
+		f, err := filename("", res, a.Gav)
+		if err != nil {
+			res.Body.Close()
+			log.Println(err)
+			return
+		}
+		p, err := persistBody(res, *outputDir, f, 0)
+		if err != nil {
+			log.Println(err)
+			return
+		}
+		if fi, err := os.Stat(p); err == nil {
+			m.fetched(fi.Size())
+		}
+		nt.notify(newNotification("fetched", a, p))
+	})
+}
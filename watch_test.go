@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/xml"
+	"reflect"
+	"testing"
+)
+
+const artifactMetadata = `<?xml version="1.0" encoding="UTF-8"?>
+<metadata>
+  <groupId>g</groupId>
+  <artifactId>a</artifactId>
+  <versioning>
+    <latest>1.1</latest>
+    <release>1.1</release>
+    <versions>
+      <version>1.0</version>
+      <version>1.1</version>
+    </versions>
+    <lastUpdated>20180312173914</lastUpdated>
+  </versioning>
+</metadata>`
+
+func TestChangesNewVersion(t *testing.T) {
+	var old mavenMetadata
+	if err := xml.Unmarshal([]byte(artifactMetadata), &old); err != nil {
+		t.Fatal(err)
+	}
+	cur := old
+	cur.Versioning.Versions = append([]string{}, old.Versioning.Versions...)
+	cur.Versioning.Versions = append(cur.Versioning.Versions, "1.2")
+
+	fqa := Fqa{Gav: Gav{Group: "g", Artifact: "a"}}
+	got := changes(fqa, old, cur)
+	want := []Fqa{{Gav: Gav{Group: "g", Artifact: "a", Version: "1.2"}}}
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("Expected %+v but got %+v\n", want, got)
+	}
+	if got := changes(fqa, cur, cur); len(got) != 0 {
+		t.Fatalf("Expected no changes but got %+v\n", got)
+	}
+}
+
+func TestChangesNewSnapshotBuild(t *testing.T) {
+	old := mavenMetadata{Versioning: versioning{
+		Snapshot: &snapshot{"20180312.173914", 4}}}
+	cur := mavenMetadata{Versioning: versioning{
+		Snapshot: &snapshot{"20180313.080000", 5}}}
+	fqa := Fqa{Gav: Gav{Group: "g", Artifact: "a", Version: "1.0-SNAPSHOT"}}
+	if got := changes(fqa, old, cur); len(got) != 1 {
+		t.Fatalf("Expected one new build but got %+v\n", got)
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+)
+
+// exists reports whether a repository contains fqa. Nexus 2 resolves the
+// coordinates, which also finds snapshots, other servers are asked for the
+// file.
+func exists(ctx context.Context, fqa Fqa) (bool, error) {
+	method, u := http.MethodHead, contentURL(fqa)
+	if !layoutOnly() {
+		method, u = http.MethodGet, mavenURL("resolve", fqa)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, nil)
+	if err != nil {
+		return false, err
+	}
+	if fqa.Username != "" {
+		req.SetBasicAuth(fqa.Username, fqa.Password)
+	}
+	log.Printf("checking %s\n", u)
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+	res.Body.Close()
+	switch res.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	}
+	return false, &StatusError{URL: u, StatusCode: res.StatusCode}
+}
+
+// whereCell abbreviates the result of exists for the matrix.
+func whereCell(found bool, err error) string {
+	switch {
+	case err != nil && exitCode(err) == exitAuth:
+		return "denied"
+	case err != nil:
+		return "error"
+	case found:
+		return "yes"
+	}
+	return "-"
+}
+
+// where checks all gavs in all repositories, rows are repositories and
+// columns gavs.
+func where(ctx context.Context, repos []NexusRepository, gavs []Gav) (
+	[][]string, error) {
+	var m [][]string
+	for _, repo := range repos {
+		var row []string
+		for _, gav := range gavs {
+			found, err := exists(ctx, Fqa{NexusRepository: repo, Gav: gav})
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			if err != nil {
+				log.Printf("%s in %s: %v\n", gav.ConciseNotation(),
+					repo.RepositoryID, err)
+			}
+			row = append(row, whereCell(found, err))
+		}
+		m = append(m, row)
+	}
+	return m, nil
+}
+
+// writeWhere prints the matrix with a header of gavs.
+func writeWhere(w io.Writer, repos []NexusRepository, gavs []Gav,
+	m [][]string) {
+	width := len("repository")
+	for _, r := range repos {
+		if len(r.RepositoryID) > width {
+			width = len(r.RepositoryID)
+		}
+	}
+	widths := make([]int, len(gavs))
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%-*s", width, "repository")
+	for i, gav := range gavs {
+		widths[i] = len(gav.ConciseNotation())
+		fmt.Fprintf(&sb, "  %s", gav.ConciseNotation())
+	}
+	fmt.Fprintln(w, sb.String())
+	for i, r := range repos {
+		sb.Reset()
+		fmt.Fprintf(&sb, "%-*s", width, r.RepositoryID)
+		for j, cell := range m[i] {
+			fmt.Fprintf(&sb, "  %-*s", widths[j], cell)
+		}
+		fmt.Fprintln(w, strings.TrimRight(sb.String(), " "))
+	}
+}
+
+func whereCommand(args []string) {
+	fs := newCommand("where", "<g:a:v>...")
+	nf := newNexusFlags(fs)
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		fs.Usage()
+	}
+	var gavs []Gav
+	for _, arg := range fs.Args() {
+		gav, err := parseConcise(arg)
+		if err != nil {
+			log.Println(err)
+			exit(exitUsage)
+		}
+		gavs = append(gavs, gav)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt,
+		syscall.SIGTERM)
+	defer stop()
+	// all repositories of the server unless given explicitly
+	repos := nf.repos()
+	if !nf.repository.set {
+		inst := nf.instance()
+		rs, err := repositories(inst)
+		if err != nil {
+			fail(err)
+		}
+		repos = nil
+		for _, r := range rs {
+			repos = append(repos, NexusRepository{NexusInstance: inst,
+				RepositoryID: r.ID})
+		}
+	}
+	m, err := where(ctx, repos, gavs)
+	if err != nil {
+		fail(err)
+	}
+	writeWhere(os.Stdout, repos, gavs, m)
+}
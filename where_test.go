@@ -0,0 +1,36 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/jhinrichsen/nexus-fetch/nexusfetchtest"
+)
+
+func TestWhere(t *testing.T) {
+	_, inst := newFakeNexus(t, nexusfetchtest.Artifact{
+		Repository: "thirdparty", Group: "com.acme", Artifact: "lib",
+		Version: "1.0", Extension: "jar", Content: []byte("jar")})
+	repos := []NexusRepository{{NexusInstance: inst, RepositoryID: "releases"},
+		{NexusInstance: inst, RepositoryID: "thirdparty"}}
+	gavs := []Gav{{Group: "com.acme", Artifact: "lib", Version: "1.0"}}
+	m, err := where(context.Background(), repos, gavs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{{"-"}, {"yes"}}
+	if !reflect.DeepEqual(want, m) {
+		t.Fatalf("Expected %v but got %v\n", want, m)
+	}
+
+	var buf bytes.Buffer
+	writeWhere(&buf, repos, gavs, m)
+	wantOut := "repository  com.acme:lib:1.0\n" +
+		"releases    -\n" +
+		"thirdparty  yes\n"
+	if buf.String() != wantOut {
+		t.Fatalf("Expected %q but got %q\n", wantOut, buf.String())
+	}
+}